@@ -19,6 +19,10 @@ func main() {
 // handleError will process the error.
 // If the user wants to then a bug report is sent.
 func handleError(err error) {
+	if exitErr, ok := err.(secrethub.ExitError); ok {
+		os.Exit(exitErr.Code)
+	}
+
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Encountered an error: %s\n", err)
 		os.Exit(1)
@@ -0,0 +1,63 @@
+package masker
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"unicode/utf8"
+)
+
+// withEncodings returns the given sequences together with their common encoded variants, which
+// have the same name as the sequence they are derived from. Variants that are equal to the sequence
+// itself or to another variant are omitted.
+func withEncodings(sequences []Sequence) []Sequence {
+	res := make([]Sequence, 0, len(sequences))
+	seen := make(map[string]struct{})
+	for _, sequence := range sequences {
+		for _, variant := range append([][]byte{sequence.Value}, encodings(sequence.Value)...) {
+			if _, ok := seen[string(variant)]; ok || len(variant) == 0 {
+				continue
+			}
+			seen[string(variant)] = struct{}{}
+			res = append(res, Sequence{Value: variant, Name: sequence.Name})
+		}
+	}
+	return res
+}
+
+// encodings returns the encoded variants of the given sequence: base64 (standard and URL-safe,
+// with and without padding), URL encoding (query and path escaping), JSON string escaping
+// (with and without escaping of HTML characters) and hexadecimal encoding (lower and upper case).
+func encodings(sequence []byte) [][]byte {
+	res := [][]byte{
+		[]byte(base64.StdEncoding.EncodeToString(sequence)),
+		[]byte(base64.RawStdEncoding.EncodeToString(sequence)),
+		[]byte(base64.URLEncoding.EncodeToString(sequence)),
+		[]byte(base64.RawURLEncoding.EncodeToString(sequence)),
+		[]byte(url.QueryEscape(string(sequence))),
+		[]byte(url.PathEscape(string(sequence))),
+		[]byte(hex.EncodeToString(sequence)),
+		bytes.ToUpper([]byte(hex.EncodeToString(sequence))),
+	}
+
+	// JSON encoding replaces invalid UTF-8, so the result would never occur in the output.
+	if utf8.Valid(sequence) {
+		res = append(res, jsonEscape(sequence, true), jsonEscape(sequence, false))
+	}
+
+	return res
+}
+
+// jsonEscape returns the sequence as it is escaped in a JSON string, without the surrounding quotes.
+func jsonEscape(sequence []byte, escapeHTML bool) []byte {
+	buf := bytes.Buffer{}
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(escapeHTML)
+	// Encoding a string never fails.
+	_ = encoder.Encode(string(sequence))
+
+	// Remove the quotes and the newline added by the encoder.
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\"\n"))[1:]
+}
@@ -0,0 +1,88 @@
+package masker
+
+import (
+	"testing"
+
+	"github.com/secrethub/secrethub-go/internals/assert"
+)
+
+func TestWithEncodings(t *testing.T) {
+	cases := map[string]struct {
+		sequences [][]byte
+		expected  []string
+	}{
+		"alphanumeric": {
+			sequences: [][]byte{[]byte("foo")},
+			expected: []string{
+				"foo",
+				"Zm9v",
+				"666f6f",
+				"666F6F",
+			},
+		},
+		"url special characters": {
+			sequences: [][]byte{[]byte("a b/c+d")},
+			expected: []string{
+				"a b/c+d",
+				"YSBiL2MrZA==",
+				"YSBiL2MrZA",
+				"a+b%2Fc%2Bd",
+				"a%20b%2Fc+d",
+				"6120622f632b64",
+				"6120622F632B64",
+			},
+		},
+		"json special characters": {
+			sequences: [][]byte{[]byte("<\"\n>")},
+			expected: []string{
+				"<\"\n>",
+				"PCIKPg==",
+				"PCIKPg",
+				"%3C%22%0A%3E",
+				"3c220a3e",
+				"3C220A3E",
+				"\\u003c\\\"\\n\\u003e",
+				"<\\\"\\n>",
+			},
+		},
+		"invalid utf-8": {
+			sequences: [][]byte{{0xff, 0xfe}},
+			expected: []string{
+				"\xff\xfe",
+				"//4=",
+				"//4",
+				"__4=",
+				"__4",
+				"%FF%FE",
+				"fffe",
+				"FFFE",
+			},
+		},
+		"duplicate sequences": {
+			sequences: [][]byte{[]byte("foo"), []byte("foo")},
+			expected: []string{
+				"foo",
+				"Zm9v",
+				"666f6f",
+				"666F6F",
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			sequences := make([]Sequence, len(tc.sequences))
+			for i, sequence := range tc.sequences {
+				sequences[i] = Sequence{Value: sequence, Name: name}
+			}
+
+			var actual []string
+			for _, sequence := range withEncodings(sequences) {
+				actual = append(actual, string(sequence.Value))
+				assert.Equal(t, sequence.Name, name)
+			}
+
+			assert.Equal(t, actual, tc.expected)
+		})
+	}
+}
@@ -1,10 +1,30 @@
 package masker
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"io"
+	"strings"
+	"sync"
 	"time"
 )
 
+const (
+	// DefaultFormat is the default replacement of masked sequences.
+	DefaultFormat = "<redacted by SecretHub>"
+	// NamePlaceholder is replaced by the name of the masked sequence in the format of the replacement.
+	NamePlaceholder = "{{name}}"
+	// HashPlaceholder is replaced by a short hash of the name of the masked sequence in the format of the replacement.
+	// It can be used to tell masked sequences apart without revealing their names.
+	HashPlaceholder = "{{hash}}"
+)
+
+// Sequence is a sequence of bytes to mask, together with the name that identifies it in its replacement.
+type Sequence struct {
+	Value []byte
+	Name  string
+}
+
 // Masker handles the creation and synchronization of streams that have all their writes scanned for secrets and
 // have them redacted if any matches are found. Masking of secrets is a best effort attempt. Output on all streams is
 // buffered to increase the chance of finding secrets if they are spread across multiple writes, but it cannot be
@@ -16,11 +36,16 @@ import (
 // 3. Run the Start() method in a separate goroutine
 // 4. After everything has been written to the io.Writers, flush all buffers using Stop()
 type Masker struct {
-	bufferDelay time.Duration
-	sequences   [][]byte
-	frames      chan frame
-	stopChan    chan struct{}
-	err         error
+	bufferDelay   time.Duration
+	maskEncodings bool
+	format        string
+	sequences     []Sequence
+	names         map[string]string
+	streams       []*stream
+	mutex         sync.Mutex
+	frames        chan frame
+	stopChan      chan struct{}
+	err           error
 }
 
 // Options for configuring masking behavior.
@@ -38,17 +63,31 @@ type Options struct {
 	// FrameBufferLength is the number of frames that can be in the buffer simultaneously.
 	// If the frame buffer is full, writing to a stream blocks until there is space.
 	FrameBufferLength int
+
+	// MaskEncodings enables masking of common encodings of the sequences as well, so that a secret is
+	// also masked when it is written base64 encoded, URL encoded, JSON escaped or hex encoded.
+	MaskEncodings bool
+
+	// Format is the replacement of masked sequences. The NamePlaceholder and HashPlaceholder in
+	// the format are replaced by the name and a short hash of the name of the masked sequence.
+	// Defaults to DefaultFormat if not set.
+	Format string
 }
 
 // New creates a new Masker that scans all streams for the given sequences and masks them.
-func New(sequences [][]byte, opts *Options) *Masker {
+func New(sequences []Sequence, opts *Options) *Masker {
 	masker := &Masker{
 		bufferDelay: time.Millisecond * 50,
-		sequences:   sequences,
+		format:      DefaultFormat,
+		names:       make(map[string]string),
 		stopChan:    make(chan struct{}),
 	}
 	frameChanlength := 1024
 	if opts != nil {
+		masker.maskEncodings = opts.MaskEncodings
+		if opts.Format != "" {
+			masker.format = opts.Format
+		}
 		if opts.DisableBuffer {
 			masker.bufferDelay = 0
 			frameChanlength = 0
@@ -63,19 +102,81 @@ func New(sequences [][]byte, opts *Options) *Masker {
 
 	}
 	masker.frames = make(chan frame, frameChanlength)
+	masker.addSequences(sequences)
 
 	return masker
 }
 
 // AddStream takes in an io.Writer to mask secrets on and returns an io.Writer that has secrets on its output masked.
 func (m *Masker) AddStream(w io.Writer) io.Writer {
-	s := stream{
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	values := make([][]byte, len(m.sequences))
+	for i, sequence := range m.sequences {
+		values[i] = sequence.Value
+	}
+
+	s := &stream{
 		dest:          w,
 		registerFrame: m.registerFrame,
+		replacement:   m.replacement,
 		matches:       matches{},
-		matcher:       newMatcher(m.sequences),
+		matcher:       newMatcher(values),
+	}
+	m.streams = append(m.streams, s)
+	return s
+}
+
+// AddSequences starts masking the given sequences on all existing streams and on streams that are added later.
+// This can be used to mask new values, for example after a secret has been rotated, while the streams are in use.
+// Matches of the new sequences that started before they were added are not masked.
+func (m *Masker) AddSequences(sequences []Sequence) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	values := m.addSequences(sequences)
+	for _, s := range m.streams {
+		s.addSequences(values)
+	}
+}
+
+// addSequences adds the given sequences and their encodings if enabled to the sequences of the masker
+// and returns the values of the added sequences. The name of a value is the name of the sequence it
+// was first added for.
+func (m *Masker) addSequences(sequences []Sequence) [][]byte {
+	if m.maskEncodings {
+		sequences = withEncodings(sequences)
+	}
+
+	values := make([][]byte, len(sequences))
+	for i, sequence := range sequences {
+		if _, ok := m.names[string(sequence.Value)]; !ok {
+			m.names[string(sequence.Value)] = sequence.Name
+		}
+		values[i] = sequence.Value
 	}
-	return &s
+
+	m.sequences = append(m.sequences, sequences...)
+	return values
+}
+
+// replacement returns the replacement of the given masked sequence.
+func (m *Masker) replacement(masked []byte) []byte {
+	if !strings.Contains(m.format, NamePlaceholder) && !strings.Contains(m.format, HashPlaceholder) {
+		return []byte(m.format)
+	}
+
+	m.mutex.Lock()
+	name := m.names[string(masked)]
+	m.mutex.Unlock()
+
+	hash := sha256.Sum256([]byte(name))
+	replacer := strings.NewReplacer(
+		NamePlaceholder, name,
+		HashPlaceholder, hex.EncodeToString(hash[:4]),
+	)
+	return []byte(replacer.Replace(m.format))
 }
 
 // Start continuously flushes the input buffer for each frame for which the buffer delay has passed.
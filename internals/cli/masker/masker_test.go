@@ -103,6 +103,18 @@ func TestMasker(t *testing.T) {
 			options:  &Options{BufferDelay: delay1us},
 			expected: "foo " + maskString + " test",
 		},
+		"mask at start of write": {
+			maskStrings: []string{"foo", "bar"},
+			inputFunc: func(w io.Writer) {
+				_, err := w.Write([]byte("test "))
+				assert.OK(t, err)
+				time.Sleep(time.Millisecond * 10)
+				_, err = w.Write([]byte("foo test"))
+				assert.OK(t, err)
+			},
+			options:  &Options{BufferDelay: delay1us},
+			expected: "test " + maskString + " test",
+		},
 		"no buffering": {
 			maskStrings: []string{"foo", "bar"},
 			inputFunc: func(w io.Writer) {
@@ -150,9 +162,9 @@ func TestMasker(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			var buf bytes.Buffer
 
-			var maskStrings [][]byte
+			var maskStrings []Sequence
 			for _, s := range tc.maskStrings {
-				maskStrings = append(maskStrings, []byte(s))
+				maskStrings = append(maskStrings, Sequence{Value: []byte(s)})
 			}
 
 			m := New(maskStrings, tc.options)
@@ -180,7 +192,7 @@ func (w errWriter) Write(p []byte) (n int, err error) {
 func TestMasker_WriteError(t *testing.T) {
 	expectedErr := fmt.Errorf("test")
 
-	m := New([][]byte{[]byte("test")}, nil)
+	m := New([]Sequence{{Value: []byte("test")}}, nil)
 	writer := m.AddStream(&errWriter{err: expectedErr})
 
 	go m.Start()
@@ -192,11 +204,11 @@ func TestMasker_WriteError(t *testing.T) {
 }
 
 func TestMasker_MultipleStreams(t *testing.T) {
-	sequences := [][]byte{
-		[]byte("Gandalf"),
-		[]byte("uruk-hai army"),
-		[]byte("Aragorn, son of Arathorn"),
-		[]byte("hobbit"),
+	sequences := []Sequence{
+		{Value: []byte("Gandalf")},
+		{Value: []byte("uruk-hai army")},
+		{Value: []byte("Aragorn, son of Arathorn")},
+		{Value: []byte("hobbit")},
 	}
 
 	input := [][]byte{
@@ -238,7 +250,132 @@ func TestMasker_MultipleStreams(t *testing.T) {
 	assert.OK(t, err)
 
 	for _, sequence := range sequences {
-		expected = strings.ReplaceAll(expected, string(sequence), maskString)
+		expected = strings.ReplaceAll(expected, string(sequence.Value), maskString)
 	}
 	assert.Equal(t, outputBuffer.String(), expected)
 }
+
+func TestMasker_AddSequences(t *testing.T) {
+	m := New([]Sequence{{Value: []byte("foo")}}, &Options{
+		BufferDelay: 10 * time.Millisecond,
+	})
+
+	var buf bytes.Buffer
+	writer := m.AddStream(&buf)
+
+	go m.Start()
+
+	_, err := writer.Write([]byte("foo bar "))
+	assert.OK(t, err)
+
+	m.AddSequences([]Sequence{{Value: []byte("bar")}})
+
+	_, err = writer.Write([]byte("baz foo bar"))
+	assert.OK(t, err)
+
+	err = m.Stop()
+	assert.OK(t, err)
+
+	expected := maskString + " bar baz " + maskString + " " + maskString
+	assert.Equal(t, buf.String(), expected)
+}
+
+func TestMasker_MaskEncodings(t *testing.T) {
+	m := New([]Sequence{{Value: []byte("s3cr3t/p@ss")}}, &Options{
+		MaskEncodings: true,
+	})
+
+	var buf bytes.Buffer
+	writer := m.AddStream(&buf)
+
+	go m.Start()
+
+	_, err := writer.Write([]byte("raw=s3cr3t/p@ss base64=czNjcjN0L3BAc3M= url=s3cr3t%2Fp%40ss hex=7333637233742f70407373"))
+	assert.OK(t, err)
+
+	m.AddSequences([]Sequence{{Value: []byte("a\"b")}})
+
+	_, err = writer.Write([]byte(" json={\"password\":\"a\\\"b\"}"))
+	assert.OK(t, err)
+
+	err = m.Stop()
+	assert.OK(t, err)
+
+	expected := "raw=" + maskString + " base64=" + maskString + " url=" + maskString + " hex=" + maskString +
+		" json={\"password\":\"" + maskString + "\"}"
+	assert.Equal(t, buf.String(), expected)
+}
+
+func TestMasker_Format(t *testing.T) {
+	sequences := []Sequence{
+		{Value: []byte("foo"), Name: "FOO"},
+		{Value: []byte("bar"), Name: "namespace/repo/bar"},
+		{Value: []byte("baz")},
+	}
+
+	cases := map[string]struct {
+		format        string
+		maskEncodings bool
+		input         string
+		expected      string
+	}{
+		"default": {
+			input:    "foo bar baz",
+			expected: maskString + " " + maskString + " " + maskString,
+		},
+		"name": {
+			format:   "<redacted:{{name}}>",
+			input:    "foo bar baz",
+			expected: "<redacted:FOO> <redacted:namespace/repo/bar> <redacted:>",
+		},
+		"hash": {
+			format:   "<redacted:{{hash}}>",
+			input:    "foo bar",
+			expected: "<redacted:9520437c> <redacted:2848ab6d>",
+		},
+		"name and hash": {
+			format:   "[{{name}} {{hash}}]",
+			input:    "foo",
+			expected: "[FOO 9520437c]",
+		},
+		"without placeholders": {
+			format:   "***",
+			input:    "foo bar",
+			expected: "*** ***",
+		},
+		"encoded": {
+			format:        "<redacted:{{name}}>",
+			maskEncodings: true,
+			input:         "foo Zm9v",
+			expected:      "<redacted:FOO> <redacted:FOO>",
+		},
+		"adjacent matches": {
+			format:   "<redacted:{{name}}>",
+			input:    "foobar",
+			expected: "<redacted:FOO>",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			m := New(sequences, &Options{
+				DisableBuffer: true,
+				MaskEncodings: tc.maskEncodings,
+				Format:        tc.format,
+			})
+
+			var buf bytes.Buffer
+			writer := m.AddStream(&buf)
+
+			go m.Start()
+
+			_, err := writer.Write([]byte(tc.input))
+			assert.OK(t, err)
+
+			err = m.Stop()
+			assert.OK(t, err)
+
+			assert.Equal(t, buf.String(), tc.expected)
+		})
+	}
+}
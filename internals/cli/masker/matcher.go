@@ -1,10 +1,5 @@
 package masker
 
-import (
-	"bytes"
-	"crypto/subtle"
-)
-
 // matches represents a set of sequence matches. The key is the index at which the match is found and the value is the
 // length of the match. The index corresponds to the index of the byte in the BufferedIndex of the stream.
 type matches map[int64]int
@@ -18,121 +13,175 @@ func (m matches) add(index int64, length int) matches {
 	return m
 }
 
-// matcher combines multiple sequenceMatchers to check for matches of secrets against any of them.
+const (
+	// root is the index of the root node of the automaton, which corresponds to the empty prefix.
+	root = 0
+	// denseEdgesThreshold is the number of edges of a node above which its children are looked up
+	// in a table instead of searched for in its edges. The root node always uses a table.
+	denseEdgesThreshold = 8
+)
+
+// matcher checks the bytes written to it for matches of any of its sequences.
+//
+// It is implemented as an Aho-Corasick automaton: the sequences are stored in a trie and every node of
+// the trie has a failure link to the node of the longest proper suffix of its prefix that is also in the trie.
+// Every byte written to the matcher moves the current state through the trie, so the amortized time needed
+// to process a byte does not grow with the number of sequences. The state is kept between writes, so matches
+// across multiple writes are found.
+//
+// The automaton does not run in constant time: the number of steps needed for a byte depends on the edges
+// and failure links it follows, so it depends on how much of the input matches a prefix of a sequence.
+// This does not reveal the sequences, because the time at which output is written is set by the stream
+// before the matcher runs: it is released after the constant buffer delay, which is far longer than the
+// time needed to match a write. The only party that can observe the matching time directly is the process
+// writing the output, which already knows the secrets in its environment. When buffering is disabled,
+// output is written as soon as it is matched, so the matching time is observable, just like the output itself.
 type matcher struct {
-	detectors    []*sequenceDetector
+	nodes        []node
+	state        int32
 	currentIndex int64
 }
 
-// newMatcher returns a new matcher that contains a sequenceDetector for all given sequences.
+// node is a node in the trie of the automaton.
+type node struct {
+	edges []edge
+	// dense contains the children of the node by byte when it has many edges,
+	// with the root indicating that there is no child for a byte.
+	dense *[256]int32
+	// fail is the node of the longest proper suffix of this node's prefix that is in the trie.
+	fail int32
+	// output is the first node in the chain of failure links that is the end of a sequence,
+	// or the root if there is no such node.
+	output int32
+	// length is the length of the sequence that ends at this node, or 0 if no sequence ends here.
+	length int
+}
+
+// edge is a transition to the child of a node.
+type edge struct {
+	b    byte
+	node int32
+}
+
+// newMatcher returns a new matcher that matches all given sequences.
 func newMatcher(sequences [][]byte) *matcher {
 	res := &matcher{
-		detectors: make([]*sequenceDetector, 0, len(sequences)),
+		nodes: []node{{dense: &[256]int32{}}},
 	}
+	res.addSequences(sequences)
+	return res
+}
 
+// addSequences adds the given sequences to the matcher. Sequences that are partially
+// written to the matcher when they are added are not matched.
+func (m *matcher) addSequences(sequences [][]byte) {
 	for _, sequence := range sequences {
-		res.detectors = append(res.detectors, &sequenceDetector{
-			sequence: sequence,
-			offset:   0,
-		})
-		// Add detectors for any repetitions in the sequence.
-		// For example, if the sequence is "aaab", we also require a detector for "aaaab" and "aaaaab".
-		for length, count := range sequenceRepetitions(sequence) {
-			for i := 1; i <= count; i++ {
-				prefixedSequence := make([]byte, len(sequence)+length*i)
-				copy(prefixedSequence, sequence[:length*i])
-				copy(prefixedSequence[length*i:], sequence)
-				res.detectors = append(res.detectors, &sequenceDetector{
-					sequence: prefixedSequence,
-					offset:   length * i,
-				})
+		if len(sequence) == 0 {
+			continue
+		}
+
+		current := int32(root)
+		for _, b := range sequence {
+			next, ok := m.child(current, b)
+			if !ok {
+				next = int32(len(m.nodes))
+				m.nodes = append(m.nodes, node{})
+				m.addEdge(current, b, next)
 			}
+			current = next
 		}
+		m.nodes[current].length = len(sequence)
 	}
-	return res
+
+	m.link()
 }
 
-// write takes in a slice of bytes and returns all matches found by any of its detectors.
-func (m *matcher) write(in []byte) matches {
-	res := matches{}
-	for i, b := range in {
-		for _, detector := range m.detectors {
-			match := detector.writeByte(b)
-			if match {
-				res = res.add(m.currentIndex+int64(i-detector.length()+1), detector.length())
-			}
+// addEdge adds the given child to the node.
+func (m *matcher) addEdge(n int32, b byte, child int32) {
+	m.nodes[n].edges = append(m.nodes[n].edges, edge{b: b, node: child})
+
+	if m.nodes[n].dense == nil && len(m.nodes[n].edges) > denseEdgesThreshold {
+		m.nodes[n].dense = &[256]int32{}
+		for _, e := range m.nodes[n].edges {
+			m.nodes[n].dense[e.b] = e.node
 		}
+	} else if m.nodes[n].dense != nil {
+		m.nodes[n].dense[b] = child
 	}
-	m.currentIndex += int64(len(in))
-	return res
 }
 
-// sequenceDetector detects if a sequence is present in the bytes it receives.
-type sequenceDetector struct {
-	sequence []byte
-	offset   int
-	index    int
+// link (re)computes the failure and output links of all nodes.
+// The nodes are processed in breadth-first order, so the links of all shorter prefixes are known
+// when the links of a node are computed.
+func (m *matcher) link() {
+	queue := make([]int32, 0, len(m.nodes))
+	for _, e := range m.nodes[root].edges {
+		m.nodes[e.node].fail = root
+		m.nodes[e.node].output = root
+		queue = append(queue, e.node)
+	}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, e := range m.nodes[current].edges {
+			fail := m.next(m.nodes[current].fail, e.b)
+
+			m.nodes[e.node].fail = fail
+			if m.nodes[fail].length > 0 {
+				m.nodes[e.node].output = fail
+			} else {
+				m.nodes[e.node].output = m.nodes[fail].output
+			}
+
+			queue = append(queue, e.node)
+		}
+	}
 }
 
-// length returns the length of the sequence corrected for its offset.
-func (d *sequenceDetector) length() int {
-	return len(d.sequence) - d.offset
+// child returns the child of the given node for the given byte, if it exists.
+func (m *matcher) child(n int32, b byte) (int32, bool) {
+	if m.nodes[n].dense != nil {
+		child := m.nodes[n].dense[b]
+		return child, child != root
+	}
+
+	for _, e := range m.nodes[n].edges {
+		if e.b == b {
+			return e.node, true
+		}
+	}
+	return root, false
 }
 
-// writeByte takes in a new byte to match against.
-// Returns true if the given byte results in a match with sequence
-// The implementation tries to reduce the effect of the input on the execution duration as much as possible
-// to limit the information that can be derived from measuring the execution time of the masking functionality.
-func (d *sequenceDetector) writeByte(in byte) bool {
-	// Implementation of the following code that limits data-dependency as much as possible.
-	//
-	// 	if d.sequence[d.index] == in {
-	// 		d.index++
-	//
-	//		if d.index == len(d.sequence) {
-	//			d.index = 0
-	//			return true
-	//		}
-	//		return false
-	//	} else if d.sequence[0] == in{
-	//		d.index = d.offset + 1
-	//	} else {
-	//      d.index = 0
-	//  }
-	//	return false
-
-	newIndex := d.index
-
-	correctInput := subtle.ConstantTimeByteEq(d.sequence[newIndex], in)
-	newIndex = subtle.ConstantTimeSelect(correctInput, newIndex+1, newIndex)
-	sequenceComplete := subtle.ConstantTimeEq(int32(len(d.sequence)), int32(newIndex))
-
-	newIndexIfNotCorrectInput := subtle.ConstantTimeSelect(subtle.ConstantTimeByteEq(d.sequence[0], in), d.offset+1, 0)
-	newIndex = subtle.ConstantTimeSelect(correctInput, newIndex, newIndexIfNotCorrectInput)
-
-	d.index = subtle.ConstantTimeSelect(sequenceComplete, 0, newIndex)
-
-	return sequenceComplete == 1
+// next returns the state of the automaton after the given byte is processed in the given state.
+func (m *matcher) next(state int32, b byte) int32 {
+	for {
+		next, ok := m.child(state, b)
+		if ok || state == root {
+			return next
+		}
+		state = m.nodes[state].fail
+	}
 }
 
-// sequenceRepetitions finds all repetitions of bytes in the start of the sequence and returns a map of the length of
-// repeated sequences as the key and the number of repetitions as the value.
-// Example, if the input is aabaabaab, the "a" is repeated 1 time and "aab" is repeated 2 times,
-// so the result is: {1:1, 3:2}
-func sequenceRepetitions(seq []byte) map[int]int {
-	res := map[int]int{}
-	for i := 1; i < len(seq); i++ {
-		count := 0
-		for j := 1; i*(j+1) <= len(seq); j++ {
-			if bytes.Equal(seq[0:i], seq[i*j:i*(j+1)]) {
-				count++
-			} else {
-				break
-			}
+// write takes in a slice of bytes and returns all matches of any of the sequences.
+// Matches that overlap with each other are all returned.
+func (m *matcher) write(in []byte) matches {
+	res := matches{}
+	for i, b := range in {
+		m.state = m.next(m.state, b)
+
+		end := m.currentIndex + int64(i) + 1
+		if length := m.nodes[m.state].length; length > 0 {
+			res = res.add(end-int64(length), length)
 		}
-		if count > 0 {
-			res[i] = count
+		for n := m.nodes[m.state].output; n != root; n = m.nodes[n].output {
+			length := m.nodes[n].length
+			res = res.add(end-int64(length), length)
 		}
 	}
+	m.currentIndex += int64(len(in))
 	return res
 }
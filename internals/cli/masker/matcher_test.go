@@ -1,12 +1,12 @@
 package masker
 
 import (
+	"bytes"
 	"fmt"
 	"math/rand"
 	"strconv"
 	"strings"
 	"testing"
-	"time"
 
 	"github.com/secrethub/secrethub-go/internals/assert"
 	"github.com/secrethub/secrethub-go/pkg/randchar"
@@ -223,7 +223,7 @@ func TestSequenceDetector(t *testing.T) {
 		{
 			matchString:     "foofoobar",
 			input:           "foofoofoobar",
-			expectedMatches: []int{}, // This case is handled by adding multiple detectors with newMatcher
+			expectedMatches: []int{}, // This case is handled by adding multiple detectors with newDetectorMatcher
 		},
 		{
 			matchString:     "test",
@@ -319,57 +319,114 @@ func TestSequenceRepetitions(t *testing.T) {
 	}
 }
 
-func doBench(sequences [][]byte, input []byte) int {
-	m := newMatcher(sequences)
-	_ = m.write(input)
-	maxIndex := 0
-	for _, d := range m.detectors {
-		if d.index > maxIndex {
-			maxIndex = d.index
-		}
+func TestMatcher_BruteForce(t *testing.T) {
+	// A small alphabet results in many (overlapping) matches.
+	alphabet := randchar.NewCharset("ab")
+
+	for i := 0; i < 100; i++ {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			sequences := make([][]byte, 1+rand.Intn(5))
+			for j := range sequences {
+				sequence, err := randchar.MustNewRand(alphabet).Generate(1 + rand.Intn(6))
+				assert.OK(t, err)
+				sequences[j] = sequence
+			}
+
+			input, err := randchar.MustNewRand(alphabet).Generate(rand.Intn(100))
+			assert.OK(t, err)
+
+			expected := matches{}
+			for index := range input {
+				for _, sequence := range sequences {
+					if bytes.HasPrefix(input[index:], sequence) {
+						expected = expected.add(int64(index), len(sequence))
+					}
+				}
+			}
+
+			// Write the input in random chunks to check that matches across writes are found.
+			m := newMatcher(sequences)
+			actual := matches{}
+			for len(input) > 0 {
+				n := 1 + rand.Intn(len(input))
+				for index, length := range m.write(input[:n]) {
+					actual = actual.add(index, length)
+				}
+				input = input[n:]
+			}
+
+			assert.Equal(t, actual, expected)
+		})
 	}
-	return maxIndex
 }
 
-func BenchmarkMatcher(b *testing.B) {
-	sequences := make([][]byte, 100)
-	goodSequences := make([][]byte, 100)
-	badSequences := make([][]byte, 100)
-	for i := range sequences {
-		seq, err := randchar.Generate(1024)
-		assert.OK(b, err)
-		sequences[i] = seq
+func TestMatcher_AddSequences(t *testing.T) {
+	m := newMatcher([][]byte{[]byte("foo")})
+
+	assert.Equal(t, m.write([]byte("foo ba")), matches{0: 3})
 
-		goodSequences[i] = make([]byte, 512)
-		copy(goodSequences[i], seq)
+	// The bar that started before the sequence was added is not matched.
+	m.addSequences([][]byte{[]byte("bar"), []byte("oo b")})
 
-		badSeq, err := randchar.Generate(512)
+	assert.Equal(t, m.write([]byte("r foo bar")), matches{8: 3, 9: 4, 12: 3})
+}
+
+func benchmarkSequences(b *testing.B, n int, length int) [][]byte {
+	sequences := make([][]byte, n)
+	for i := range sequences {
+		sequence, err := randchar.Generate(length)
 		assert.OK(b, err)
-		badSequences[i] = badSeq
+		sequences[i] = sequence
 	}
+	return sequences
+}
 
-	startTime := time.Now()
-	for -time.Until(startTime) < time.Minute {
-		for i := 0; i < len(sequences); i++ {
-			index := doBench(sequences, goodSequences[i])
-			assert.Equal(b, index, 512)
-			index = doBench(sequences, badSequences[i])
-			assert.Equal(b, index < 512, true)
-		}
-	}
+func BenchmarkMatcher(b *testing.B) {
+	input, err := randchar.Generate(64 * 1024)
+	assert.OK(b, err)
 
-	fmt.Println("end warming up")
+	for _, n := range []int{1, 10, 100, 500} {
+		sequences := benchmarkSequences(b, n, 32)
 
-	b.Run("random sequences", func(b *testing.B) {
-		for i := 0; i < b.N; i++ {
-			doBench(sequences, badSequences[rand.Intn(len(badSequences))])
+		// Add some of the sequences to the input, so that matches are found as well.
+		for i := 0; i < 10; i++ {
+			copy(input[rand.Intn(len(input)-32):], sequences[rand.Intn(len(sequences))])
 		}
-	})
 
-	b.Run("matching sequences", func(b *testing.B) {
-		for i := 0; i < b.N; i++ {
-			doBench(sequences, goodSequences[rand.Intn(len(goodSequences))])
-		}
-	})
+		b.Run(fmt.Sprintf("automaton/%d sequences", n), func(b *testing.B) {
+			m := newMatcher(sequences)
+			b.SetBytes(int64(len(input)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				m.write(input)
+			}
+		})
 
+		b.Run(fmt.Sprintf("detectors/%d sequences", n), func(b *testing.B) {
+			m := newDetectorMatcher(sequences)
+			b.SetBytes(int64(len(input)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				m.write(input)
+			}
+		})
+	}
+}
+
+func BenchmarkNewMatcher(b *testing.B) {
+	for _, n := range []int{10, 100, 500} {
+		sequences := benchmarkSequences(b, n, 32)
+
+		b.Run(fmt.Sprintf("automaton/%d sequences", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				newMatcher(sequences)
+			}
+		})
+
+		b.Run(fmt.Sprintf("detectors/%d sequences", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				newDetectorMatcher(sequences)
+			}
+		})
+	}
 }
@@ -12,8 +12,13 @@ type stream struct {
 	dest          io.Writer
 	buf           indexedBuffer
 	registerFrame func(*stream, time.Duration, int)
+	replacement   func([]byte) []byte
+	// maskedUntil is the index up to which the bytes have been replaced by the last replacement,
+	// or 0 if nothing has been replaced yet.
+	maskedUntil int64
 
 	matcher     *matcher
+	matcherLock sync.Mutex
 	matches     matches
 	matchesLock sync.Mutex
 }
@@ -28,7 +33,11 @@ func (s *stream) Write(p []byte) (int, error) {
 
 	n, err := s.buf.write(p)
 
-	for index, length := range s.matcher.write(p[:n]) {
+	s.matcherLock.Lock()
+	found := s.matcher.write(p[:n])
+	s.matcherLock.Unlock()
+
+	for index, length := range found {
 		s.addMatch(index, length)
 	}
 
@@ -39,6 +48,14 @@ func (s *stream) Write(p []byte) (int, error) {
 	return n, err
 }
 
+// addSequences adds the given sequences to the sequences the stream is scanned for.
+func (s *stream) addSequences(sequences [][]byte) {
+	s.matcherLock.Lock()
+	defer s.matcherLock.Unlock()
+
+	s.matcher.addSequences(sequences)
+}
+
 // addMatch adds the match of a secret at the given index and with the given length to the map of matches.
 // If the associated bytes have already been written to the destination, the match is ignored to avoid storing matches
 // that are never being processed by flush().
@@ -71,17 +88,19 @@ func (s *stream) flush(n int) error {
 				return err
 			}
 
-			// Only write the redaction text if there were bytes between this match and the previous match
-			// or this is the first flush for the buffer.
-			if len(beforeMatch) > 0 || s.buf.currentIndex == 0 {
-				_, err = s.dest.Write([]byte("<redacted by SecretHub>"))
+			// Drop all bytes until the end of the mask.
+			masked := s.buf.upToIndex(i + int64(length))
+
+			// Only write the replacement if this match does not overlap with or directly follow the previous match.
+			if s.maskedUntil == 0 || i > s.maskedUntil {
+				_, err = s.dest.Write(s.replacement(masked))
 				if err != nil {
 					return err
 				}
 			}
-
-			// Drop all bytes until the end of the mask.
-			_ = s.buf.upToIndex(i + int64(length))
+			if end := i + int64(length); end > s.maskedUntil {
+				s.maskedUntil = end
+			}
 
 			delete(s.matches, i)
 		}
@@ -0,0 +1,84 @@
+// Package pty allows for running processes in a pseudo-terminal, providing implementations for different operating systems.
+package pty
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/secrethub/secrethub-go/internals/errio"
+)
+
+var (
+	// This should be set per OS
+	available bool
+
+	errPTY = errio.Namespace("pty")
+
+	// ErrNotSupported is returned when pseudo-terminals are not available for the platform.
+	ErrNotSupported = errPTY.Code("not_supported").Error("pseudo-terminals are not supported")
+	// ErrOpenFailed is returned when a pseudo-terminal could not be allocated.
+	ErrOpenFailed = errPTY.Code("open_failed").ErrorPref("could not open a pseudo-terminal: %s")
+)
+
+// Supported returns true if pseudo-terminals are available on the system.
+func Supported() bool {
+	return available
+}
+
+// Start starts the given command with its stdin, stdout and stderr connected to a new
+// pseudo-terminal that becomes the controlling terminal of the process. Streams of the
+// command that are already set are left untouched.
+// Output processing is disabled on the pseudo-terminal, so newlines are read from the master as
+// they are written. Callers that copy the output to a terminal in raw mode should translate them.
+// It returns the master side of the pseudo-terminal, which should be closed by the caller.
+func Start(command *exec.Cmd) (*os.File, error) {
+	if !Supported() {
+		return nil, ErrNotSupported
+	}
+
+	master, slave, err := open()
+	if err != nil {
+		return nil, ErrOpenFailed(err)
+	}
+	defer slave.Close()
+
+	err = disableOutputProcessing(slave)
+	if err != nil {
+		_ = master.Close()
+		return nil, ErrOpenFailed(err)
+	}
+
+	// The controlling terminal is set by the file descriptor it has in the process,
+	// which is 0, 1 or 2 for stdin, stdout and stderr respectively.
+	ctty := -1
+	if command.Stderr == nil {
+		command.Stderr = slave
+		ctty = 2
+	}
+	if command.Stdout == nil {
+		command.Stdout = slave
+		ctty = 1
+	}
+	if command.Stdin == nil {
+		command.Stdin = slave
+		ctty = 0
+	}
+	if ctty >= 0 {
+		setControllingTerminal(command, ctty)
+	}
+
+	err = command.Start()
+	if err != nil {
+		_ = master.Close()
+		return nil, err
+	}
+	return master, nil
+}
+
+// InheritSize sets the window size of the pseudo-terminal to the window size of the given terminal.
+func InheritSize(terminal *os.File, pty *os.File) error {
+	if !Supported() {
+		return ErrNotSupported
+	}
+	return inheritSize(terminal, pty)
+}
@@ -0,0 +1,63 @@
+package pty
+
+import (
+	"bytes"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Requests to get and set the attributes of a terminal.
+const (
+	ioctlGetTermios = unix.TIOCGETA
+	ioctlSetTermios = unix.TIOCSETA
+)
+
+// open allocates a pseudo-terminal using the /dev/ptmx multiplexer and returns its master and slave.
+func open() (*os.File, *os.File, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	name, err := slaveName(master)
+	if err != nil {
+		_ = master.Close()
+		return nil, nil, err
+	}
+
+	slave, err := os.OpenFile(name, os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		_ = master.Close()
+		return nil, nil, err
+	}
+	return master, slave, nil
+}
+
+// slaveName grants access to and unlocks the slave of the given master and returns its device name.
+func slaveName(master *os.File) (string, error) {
+	err := ioctl(master.Fd(), syscall.TIOCPTYGRANT, 0)
+	if err != nil {
+		return "", err
+	}
+
+	err = ioctl(master.Fd(), syscall.TIOCPTYUNLK, 0)
+	if err != nil {
+		return "", err
+	}
+
+	// TIOCPTYGNAME writes a null terminated name of at most 128 bytes.
+	name := make([]byte, 128)
+	err = ioctl(master.Fd(), syscall.TIOCPTYGNAME, uintptr(unsafe.Pointer(&name[0])))
+	if err != nil {
+		return "", err
+	}
+
+	end := bytes.IndexByte(name, 0)
+	if end < 0 {
+		end = len(name)
+	}
+	return string(name[:end]), nil
+}
@@ -0,0 +1,64 @@
+package pty
+
+import (
+	"bytes"
+	"io"
+	"os/exec"
+	"testing"
+
+	"github.com/secrethub/secrethub-go/internals/assert"
+)
+
+func TestStart(t *testing.T) {
+	if !Supported() {
+		t.Skip("pseudo-terminals are not supported")
+	}
+
+	command := exec.Command("sh", "-c", "test -t 1 && echo terminal")
+	master, err := Start(command)
+	assert.OK(t, err)
+	defer master.Close()
+
+	var output bytes.Buffer
+	// Reading from the master returns an error instead of io.EOF on some
+	// platforms when the process has exited, so the error is ignored.
+	_, _ = io.Copy(&output, master)
+
+	assert.OK(t, command.Wait())
+	assert.Equal(t, output.String(), "terminal\n")
+}
+
+func TestStart_Stdin(t *testing.T) {
+	if !Supported() {
+		t.Skip("pseudo-terminals are not supported")
+	}
+
+	command := exec.Command("sh", "-c", "test -t 0 || cat")
+	command.Stdin = bytes.NewBufferString("piped")
+	master, err := Start(command)
+	assert.OK(t, err)
+	defer master.Close()
+
+	var output bytes.Buffer
+	_, _ = io.Copy(&output, master)
+
+	assert.OK(t, command.Wait())
+	assert.Equal(t, output.String(), "piped")
+}
+
+func TestStart_MultilineOutput(t *testing.T) {
+	if !Supported() {
+		t.Skip("pseudo-terminals are not supported")
+	}
+
+	command := exec.Command("sh", "-c", `printf -- "-----BEGIN\nKEY\n-----END"`)
+	master, err := Start(command)
+	assert.OK(t, err)
+	defer master.Close()
+
+	var output bytes.Buffer
+	_, _ = io.Copy(&output, master)
+
+	assert.OK(t, command.Wait())
+	assert.Equal(t, output.String(), "-----BEGIN\nKEY\n-----END")
+}
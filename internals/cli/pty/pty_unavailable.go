@@ -0,0 +1,26 @@
+// +build !linux,!darwin
+
+package pty
+
+import (
+	"os"
+	"os/exec"
+)
+
+func init() {
+	available = false
+}
+
+func open() (*os.File, *os.File, error) {
+	return nil, nil, ErrNotSupported
+}
+
+func inheritSize(terminal *os.File, pty *os.File) error {
+	return ErrNotSupported
+}
+
+func disableOutputProcessing(tty *os.File) error {
+	return ErrNotSupported
+}
+
+func setControllingTerminal(command *exec.Cmd, fd int) {}
@@ -0,0 +1,64 @@
+// +build linux darwin
+
+package pty
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+func init() {
+	available = true
+}
+
+// winsize is the window size of a terminal as used by the TIOCGWINSZ and TIOCSWINSZ ioctls.
+type winsize struct {
+	rows   uint16
+	cols   uint16
+	xPixel uint16
+	yPixel uint16
+}
+
+func inheritSize(terminal *os.File, pty *os.File) error {
+	var size winsize
+	err := ioctl(terminal.Fd(), syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&size)))
+	if err != nil {
+		return err
+	}
+	return ioctl(pty.Fd(), syscall.TIOCSWINSZ, uintptr(unsafe.Pointer(&size)))
+}
+
+// disableOutputProcessing disables the post-processing of output written to the terminal,
+// so newlines written by the process are not translated to carriage return and newline
+// pairs. The output read from the master is then identical to the output written by the
+// process, which is needed to mask secrets that span multiple lines.
+func disableOutputProcessing(tty *os.File) error {
+	termios, err := unix.IoctlGetTermios(int(tty.Fd()), ioctlGetTermios)
+	if err != nil {
+		return err
+	}
+	termios.Oflag &^= unix.OPOST
+	return unix.IoctlSetTermios(int(tty.Fd()), ioctlSetTermios, termios)
+}
+
+// setControllingTerminal starts the command in a new session with the given file descriptor as controlling terminal.
+func setControllingTerminal(command *exec.Cmd, fd int) {
+	if command.SysProcAttr == nil {
+		command.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	command.SysProcAttr.Setsid = true
+	command.SysProcAttr.Setctty = true
+	command.SysProcAttr.Ctty = fd
+}
+
+func ioctl(fd uintptr, request uintptr, arg uintptr) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, arg)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
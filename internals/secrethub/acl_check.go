@@ -17,6 +17,7 @@ import (
 type ACLCheckCommand struct {
 	path        api.DirPath
 	accountName api.AccountName
+	format      listFormat
 	io          ui.IO
 	newClient   newClientFunc
 }
@@ -34,6 +35,7 @@ func (cmd *ACLCheckCommand) Register(r command.Registerer) {
 	clause := r.Command("check", "Checks the effective permission of accounts on a path.")
 	clause.Arg("dir-path", "The path of the directory to check the effective permission for").Required().PlaceHolder(optionalDirPathPlaceHolder).SetValue(&cmd.path)
 	clause.Arg("account-name", "Check permissions of a specific account name (username or service name). When left empty, all accounts with permission on the path are printed out.").SetValue(&cmd.accountName)
+	cmd.format.register(clause)
 
 	command.BindAction(clause, cmd.Run)
 }
@@ -46,19 +48,43 @@ func (cmd *ACLCheckCommand) Run() error {
 	}
 
 	if cmd.accountName != "" {
+		permission := api.PermissionNone
 		for _, level := range levels {
 			if level.Account.Name == cmd.accountName {
-				fmt.Fprintf(cmd.io.Output(), "%s\n", level.Permission.String())
-				return nil
+				permission = level.Permission
+				break
 			}
 		}
 
-		fmt.Fprintln(cmd.io.Output(), api.PermissionNone.String())
+		if !cmd.format.isTable() {
+			formatter, err := cmd.format.newFormatter(cmd.io.Output(), "permission", "account")
+			if err != nil {
+				return err
+			}
+			return formatter.Write([]string{permission.String(), cmd.accountName.String()})
+		}
+
+		fmt.Fprintln(cmd.io.Output(), permission.String())
 		return nil
 	}
 
 	sort.Sort(api.SortAccessLevels(levels))
 
+	if !cmd.format.isTable() {
+		formatter, err := cmd.format.newFormatter(cmd.io.Output(), "permission", "account")
+		if err != nil {
+			return err
+		}
+
+		for _, level := range levels {
+			err = formatter.Write([]string{level.Permission.String(), level.Account.Name.String()})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
 	tabWriter := tabwriter.NewWriter(cmd.io.Output(), 0, 4, 4, ' ', 0)
 	fmt.Fprintf(tabWriter, "%s\t%s\n", "PERMISSIONS", "ACCOUNT")
 
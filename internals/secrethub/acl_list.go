@@ -19,6 +19,7 @@ type ACLListCommand struct {
 	depth         int
 	ancestors     bool
 	useTimestamps bool
+	format        listFormat
 	timeFormatter TimeFormatter
 	io            ui.IO
 	newClient     newClientFunc
@@ -40,6 +41,7 @@ func (cmd *ACLListCommand) Register(r command.Registerer) {
 	clause.Flag("depth", "The maximum depth to which the rules of child directories should be displayed. Defaults to -1 (no limit).").Short('d').Default("-1").IntVar(&cmd.depth)
 	clause.Flag("all", "List all rules that apply on the directory, including rules on parent directories.").Short('a').BoolVar(&cmd.ancestors)
 	registerTimestampFlag(clause).BoolVar(&cmd.useTimestamps)
+	cmd.format.register(clause)
 
 	command.BindAction(clause, cmd.Run)
 }
@@ -52,7 +54,7 @@ func (cmd *ACLListCommand) Run() error {
 
 // beforeRun configures the command using the flag values.
 func (cmd *ACLListCommand) beforeRun() {
-	cmd.timeFormatter = NewTimeFormatter(cmd.useTimestamps)
+	cmd.timeFormatter = NewTimeFormatter(cmd.useTimestamps || !cmd.format.isTable())
 }
 
 func (cmd *ACLListCommand) run() error {
@@ -103,6 +105,28 @@ func (cmd *ACLListCommand) run() error {
 
 	sort.Sort(api.SortDirPaths(paths))
 
+	if !cmd.format.isTable() {
+		formatter, err := cmd.format.newFormatter(cmd.io.Output(), "path", "permission", "last changed at", "account")
+		if err != nil {
+			return err
+		}
+
+		for _, p := range paths {
+			for _, rule := range ruleMap[p] {
+				err = formatter.Write([]string{
+					p.String(),
+					rule.Permission.String(),
+					cmd.timeFormatter.Format(rule.LastChangedAt.Local()),
+					rule.Account.Name.String(),
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
 	tabWriter := tabwriter.NewWriter(cmd.io.Output(), 0, 4, 4, ' ', 0)
 	fmt.Fprintf(tabWriter, "%s\t%s\t%s\t%s\n", "PATH", "PERMISSIONS", "LAST EDITED", "ACCOUNT")
 
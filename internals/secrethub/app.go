@@ -62,6 +62,17 @@ var (
 	ErrFileAlreadyExists        = errMain.Code("file_already_exists").Error("file already exists")
 )
 
+// ExitError is returned by a command that wants the application to exit with the given exit code,
+// without printing an error. For example, it is used to pass on the exit code of the process started by run.
+type ExitError struct {
+	Code int
+}
+
+// Error implements the error interface.
+func (e ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
 // App is the secrethub command-line application.
 type App struct {
 	credentialStore CredentialConfig
@@ -170,7 +181,9 @@ func (app *App) registerCommands() {
 	NewAccountCommand(app.io, app.clientFactory.NewClient, app.credentialStore).Register(app.cli)
 	NewCredentialCommand(app.io, app.clientFactory, app.credentialStore).Register(app.cli)
 	NewConfigCommand(app.io, app.credentialStore).Register(app.cli)
-	NewEnvCommand(app.io, app.clientFactory.NewClient).Register(app.cli)
+	NewEnvCommand(app.io, app.clientFactory.NewClient, app.credentialStore).Register(app.cli)
+	NewCacheCommand(app.io, app.credentialStore).Register(app.cli)
+	NewTemplateCommand(app.io, app.clientFactory.NewClient).Register(app.cli)
 
 	// Commands
 	NewInitCommand(app.io, app.clientFactory.NewUnauthenticatedClient, app.clientFactory.NewClientWithCredentials, app.credentialStore).Register(app.cli)
@@ -181,11 +194,16 @@ func (app *App) registerCommands() {
 	NewLsCommand(app.io, app.clientFactory.NewClient).Register(app.cli)
 	NewMkDirCommand(app.io, app.clientFactory.NewClient).Register(app.cli)
 	NewRmCommand(app.io, app.clientFactory.NewClient).Register(app.cli)
+	NewCpCommand(app.io, app.clientFactory.NewClient).Register(app.cli)
+	NewMvCommand(app.io, app.clientFactory.NewClient).Register(app.cli)
+	NewDiffCommand(app.io, app.clientFactory.NewClient).Register(app.cli)
+	NewApplyCommand(app.io, app.clientFactory.NewClient).Register(app.cli)
 	NewTreeCommand(app.io, app.clientFactory.NewClient).Register(app.cli)
 	NewInspectCommand(app.io, app.clientFactory.NewClient).Register(app.cli)
 	NewAuditCommand(app.io, app.clientFactory.NewClient).Register(app.cli)
-	NewInjectCommand(app.io, app.clientFactory.NewClient).Register(app.cli)
-	NewRunCommand(app.io, app.clientFactory.NewClient).Register(app.cli)
+	NewInjectCommand(app.io, app.clientFactory.NewClient, app.credentialStore).Register(app.cli)
+	NewRunCommand(app.io, app.clientFactory.NewClient, app.credentialStore).Register(app.cli)
+	NewMaskCommand(app.io, app.clientFactory.NewClient).Register(app.cli)
 	NewPrintEnvCommand(app.cli, app.io).Register(app.cli)
 
 	// Hidden commands
@@ -0,0 +1,502 @@
+package secrethub
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/secrethub/secrethub-cli/internals/cli/posix"
+	"github.com/secrethub/secrethub-cli/internals/cli/ui"
+	"github.com/secrethub/secrethub-cli/internals/secrethub/command"
+
+	"github.com/secrethub/secrethub-go/internals/api"
+	"github.com/secrethub/secrethub-go/pkg/secrethub"
+	"github.com/secrethub/secrethub-go/pkg/secrethub/credentials"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Errors
+var (
+	ErrInvalidApplyManifest = errMain.Code("invalid_apply_manifest").ErrorPref("invalid manifest %s: %s")
+	ErrApplyDrift           = errMain.Code("apply_drift").ErrorPref("the live state does not match %s: %s to apply")
+)
+
+const (
+	defaultApplyManifest = "secrethub.yaml"
+
+	// serviceCredentialFileMode is the file mode of the credential files of created services.
+	serviceCredentialFileMode = 0440
+)
+
+// ApplyCommand creates the repositories, directories, access rules and services
+// declared in a manifest file that do not exist yet.
+type ApplyCommand struct {
+	file      string
+	planOnly  bool
+	force     bool
+	io        ui.IO
+	newClient newClientFunc
+}
+
+// NewApplyCommand creates a new ApplyCommand.
+func NewApplyCommand(io ui.IO, newClient newClientFunc) *ApplyCommand {
+	return &ApplyCommand{
+		io:        io,
+		newClient: newClient,
+	}
+}
+
+// Register registers the command, arguments and flags on the provided Registerer.
+func (cmd *ApplyCommand) Register(r command.Registerer) {
+	clause := r.Command("apply", "Make the repositories, directories, access rules and services declared in a manifest file match the live state. The changes are shown and have to be confirmed before they are made. Access rules and services that are not declared in the manifest are left untouched.")
+	clause.Flag("file", "The path to the manifest file.").Short('f').Default(defaultApplyManifest).StringVar(&cmd.file)
+	clause.Flag("plan", "Only show the changes that would be made, without making them. Exits with a non-zero exit code when there are changes to make, so it can be used to detect drift.").BoolVar(&cmd.planOnly)
+	clause.Flag("force", "Make the changes without asking for confirmation.").BoolVar(&cmd.force)
+
+	command.BindAction(clause, cmd.Run)
+}
+
+// Run shows the changes needed to make the live state match the manifest and makes them on confirmation.
+func (cmd *ApplyCommand) Run() error {
+	manifest, err := readApplyManifest(cmd.file)
+	if err != nil {
+		return err
+	}
+
+	client, err := cmd.newClient()
+	if err != nil {
+		return err
+	}
+
+	plan, err := newApplyPlan(client, manifest)
+	if err != nil {
+		return err
+	}
+
+	if len(plan) == 0 {
+		fmt.Fprintf(cmd.io.Output(), "No changes. The live state matches %s.\n", cmd.file)
+		return nil
+	}
+
+	plan.print(cmd.io.Output())
+
+	if cmd.planOnly {
+		return ErrApplyDrift(cmd.file, pluralize("change", "changes", len(plan)))
+	}
+
+	if !cmd.force {
+		confirmed, err := ui.AskYesNo(cmd.io, "Do you want to make these changes?", ui.DefaultNo)
+		if err != nil {
+			return err
+		}
+
+		if !confirmed {
+			fmt.Fprintln(cmd.io.Output(), "Aborting.")
+			return nil
+		}
+	}
+
+	for i, change := range plan {
+		err = cmd.apply(client, change)
+		if err != nil {
+			fmt.Fprintf(cmd.io.Output(), "Made %s before an error occurred.\n", pluralize("change", "changes", i))
+			return err
+		}
+	}
+
+	fmt.Fprintf(cmd.io.Output(), "Apply complete! Made %s.\n", pluralize("change", "changes", len(plan)))
+
+	return nil
+}
+
+// apply makes the given change.
+func (cmd *ApplyCommand) apply(client secrethub.ClientInterface, change applyChange) error {
+	switch change.kind {
+	case applyCreateRepo:
+		_, err := client.Repos().Create(change.path)
+		return err
+	case applyCreateDir:
+		_, err := client.Dirs().Create(change.path)
+		return err
+	case applySetAccessRule, applyUpdateAccessRule:
+		_, err := client.AccessRules().Set(change.path, change.permission.String(), change.account.Value())
+		return err
+	case applyCreateService:
+		return cmd.createService(client, change)
+	default:
+		return fmt.Errorf("unknown change: %s", change)
+	}
+}
+
+// createService creates the service of the given change and writes its credential
+// to the configured file or to the output when no file is configured.
+func (cmd *ApplyCommand) createService(client secrethub.ClientInterface, change applyChange) error {
+	repoPath := api.RepoPath(change.path)
+
+	credential := credentials.CreateKey()
+	service, err := client.Services().Create(repoPath.Value(), change.service.Description, credential)
+	if err != nil {
+		return err
+	}
+
+	out, err := credential.Export()
+	if err != nil {
+		return err
+	}
+
+	// The credential is written before the permission is given, so it is not lost when that fails.
+	err = cmd.writeServiceCredential(service, change.service.CredentialFile, out)
+	if err != nil {
+		return err
+	}
+
+	if change.service.Permission != "" {
+		err = givePermission(service, repoPath, change.service.Permission, client)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeServiceCredential writes the exported credential of the created service to the given file,
+// or to the output when no file is given.
+func (cmd *ApplyCommand) writeServiceCredential(service *api.Service, file string, credential []byte) error {
+	if file == "" {
+		fmt.Fprintf(cmd.io.Output(), "Account configuration for %s:\n%s", service.ServiceID, posix.AddNewLine(credential))
+		return nil
+	}
+
+	err := ioutil.WriteFile(file, posix.AddNewLine(credential), serviceCredentialFileMode)
+	if err != nil {
+		return ErrCannotWrite(file, err)
+	}
+
+	fmt.Fprintf(
+		cmd.io.Output(),
+		"Written account configuration for %s to %s. Be sure to remove it when you're done.\n",
+		service.ServiceID,
+		file,
+	)
+	return nil
+}
+
+// applyManifest declares the repositories, directories, access rules and services that should exist.
+type applyManifest struct {
+	Repos []applyRepo `yaml:"repos"`
+}
+
+// applyRepo declares a repository. The paths of directories and access rules are relative to the repository.
+type applyRepo struct {
+	Path     string            `yaml:"path"`
+	Dirs     []string          `yaml:"dirs"`
+	ACL      []applyAccessRule `yaml:"acl"`
+	Services []applyService    `yaml:"services"`
+}
+
+// applyAccessRule declares the permission an account has on a directory.
+type applyAccessRule struct {
+	Path       string `yaml:"path"`
+	Account    string `yaml:"account"`
+	Permission string `yaml:"permission"`
+}
+
+// applyService declares a service account of a repository. Services are identified by their
+// description, which therefore has to be unique within a repository. The permission has the
+// same format as the --permission flag of the service init command.
+type applyService struct {
+	Description    string `yaml:"description"`
+	Permission     string `yaml:"permission"`
+	CredentialFile string `yaml:"credential_file"`
+}
+
+// readApplyManifest reads and validates the manifest in the given file.
+func readApplyManifest(filename string) (*applyManifest, error) {
+	raw, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, ErrReadFile(filename, err)
+	}
+
+	manifest, err := parseApplyManifest(raw)
+	if err != nil {
+		return nil, ErrInvalidApplyManifest(filename, err)
+	}
+	return manifest, nil
+}
+
+// parseApplyManifest parses and validates a manifest.
+func parseApplyManifest(raw []byte) (*applyManifest, error) {
+	var manifest applyManifest
+	err := yaml.UnmarshalStrict(raw, &manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := make(map[string]bool, len(manifest.Repos))
+	for _, repo := range manifest.Repos {
+		var repoPath api.RepoPath
+		err = repoPath.Set(repo.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		if repos[repoPath.Value()] {
+			return nil, fmt.Errorf("repository %s is declared more than once", repoPath)
+		}
+		repos[repoPath.Value()] = true
+
+		for _, dir := range repo.Dirs {
+			_, err = api.NewDirPath(api.JoinPaths(repoPath.GetDirPath().String(), dir))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, rule := range repo.ACL {
+			_, err = api.NewDirPath(api.JoinPaths(repoPath.GetDirPath().String(), rule.Path))
+			if err != nil {
+				return nil, err
+			}
+
+			var accountName api.AccountName
+			err = accountName.Set(rule.Account)
+			if err != nil {
+				return nil, err
+			}
+
+			var permission api.Permission
+			err = permission.Set(rule.Permission)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		descriptions := make(map[string]bool, len(repo.Services))
+		for _, service := range repo.Services {
+			if service.Description == "" {
+				return nil, fmt.Errorf("the services of %s should have a description", repoPath)
+			}
+
+			if descriptions[service.Description] {
+				return nil, fmt.Errorf("the service %q of %s is declared more than once", service.Description, repoPath)
+			}
+			descriptions[service.Description] = true
+
+			if service.Permission != "" {
+				subdir, permissionValue := parsePermissionFlag(service.Permission)
+				_, err = api.NewDirPath(api.JoinPaths(repoPath.GetDirPath().String(), subdir))
+				if err != nil {
+					return nil, err
+				}
+
+				var permission api.Permission
+				err = permission.Set(permissionValue)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return &manifest, nil
+}
+
+// applyChangeKind is the kind of change an apply makes.
+type applyChangeKind int
+
+const (
+	applyCreateRepo applyChangeKind = iota
+	applyCreateDir
+	applyCreateService
+	applySetAccessRule
+	applyUpdateAccessRule
+)
+
+// applyChange is a change that an apply makes to the live state.
+// For access rules, the previous permission is set when the rule is updated.
+type applyChange struct {
+	kind               applyChangeKind
+	path               string
+	account            api.AccountName
+	permission         api.Permission
+	previousPermission api.Permission
+	service            applyService
+}
+
+// String returns a description of the change, prefixed with + when something is
+// created and with ~ when something is updated.
+func (c applyChange) String() string {
+	switch c.kind {
+	case applyCreateRepo:
+		return fmt.Sprintf("+ repository %s", c.path)
+	case applyCreateDir:
+		return fmt.Sprintf("+ directory %s", c.path)
+	case applyCreateService:
+		res := fmt.Sprintf("+ service %q in %s", c.service.Description, c.path)
+		if c.service.Permission != "" {
+			res += fmt.Sprintf(" (permission %s)", c.service.Permission)
+		}
+		return res
+	case applySetAccessRule:
+		return fmt.Sprintf("+ access rule for %s on %s: %s", c.account, c.path, c.permission)
+	case applyUpdateAccessRule:
+		return fmt.Sprintf("~ access rule for %s on %s: %s -> %s", c.account, c.path, c.previousPermission, c.permission)
+	default:
+		return "unknown change"
+	}
+}
+
+// applyPlan contains the changes needed to make the live state match a manifest, in the order they should be made.
+type applyPlan []applyChange
+
+// print writes the changes of the plan to the given writer.
+func (p applyPlan) print(w io.Writer) {
+	fmt.Fprintln(w, "The following changes are needed to make the live state match the manifest:")
+	fmt.Fprintln(w)
+
+	created, updated := 0, 0
+	for _, change := range p {
+		fmt.Fprintln(w, change)
+		if change.kind == applyUpdateAccessRule {
+			updated++
+		} else {
+			created++
+		}
+	}
+
+	fmt.Fprintf(w, "\n%d to create and %d to update.\n", created, updated)
+}
+
+// newApplyPlan compares the given manifest with the live state and returns the changes needed to make them match.
+func newApplyPlan(client secrethub.ClientInterface, manifest *applyManifest) (applyPlan, error) {
+	var plan applyPlan
+	for _, repo := range manifest.Repos {
+		changes, err := planApplyRepo(client, repo)
+		if err != nil {
+			return nil, err
+		}
+		plan = append(plan, changes...)
+	}
+	return plan, nil
+}
+
+// planApplyRepo returns the changes needed to make the live state of a repository match its declaration.
+// Directories are created before access rules are set on them and before services are given permission on them.
+func planApplyRepo(client secrethub.ClientInterface, repo applyRepo) ([]applyChange, error) {
+	repoPath := api.RepoPath(repo.Path)
+	repoDir := repoPath.GetDirPath().Value()
+
+	repoExists := true
+	existingDirs := map[string]bool{}
+	existingRules := map[string]api.Permission{}
+	existingServices := map[string]bool{}
+
+	tree, err := client.Dirs().GetTree(repoDir, -1, false)
+	if api.IsErrNotFound(err) {
+		repoExists = false
+	} else if err != nil {
+		return nil, err
+	} else {
+		dirs, _ := walkDir(tree.RootDir)
+		for _, dir := range dirs {
+			existingDirs[dir] = true
+		}
+
+		rules, err := client.AccessRules().List(repoDir, -1, false)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, rule := range rules {
+			dirPath, err := tree.AbsDirPath(rule.DirID)
+			if err != nil {
+				return nil, err
+			}
+			existingRules[accessRuleKey(relativeDirPath(repoDir, dirPath.Value()), rule.Account.Name.Value())] = rule.Permission
+		}
+
+		services, err := client.Services().List(repoPath.Value())
+		if err != nil {
+			return nil, err
+		}
+
+		for _, service := range services {
+			existingServices[service.Description] = true
+		}
+	}
+
+	var changes []applyChange
+	if !repoExists {
+		changes = append(changes, applyChange{kind: applyCreateRepo, path: repoPath.Value()})
+	}
+
+	// The directories of access rules and service permissions are created as well.
+	declaredDirs := append([]string{}, repo.Dirs...)
+	for _, rule := range repo.ACL {
+		declaredDirs = append(declaredDirs, rule.Path)
+	}
+	for _, service := range repo.Services {
+		subdir, _ := parsePermissionFlag(service.Permission)
+		declaredDirs = append(declaredDirs, subdir)
+	}
+
+	var missingDirs []string
+	for _, declared := range declaredDirs {
+		for dir := relativeDirPath(repoDir, dirPathIn(repoPath, declared)); dir != "" && dir != "."; dir = path.Dir(dir) {
+			if !existingDirs[dir] {
+				existingDirs[dir] = true
+				missingDirs = append(missingDirs, dir)
+			}
+		}
+	}
+
+	// Sorting makes sure parent directories are created before their subdirectories.
+	sort.Strings(missingDirs)
+	for _, dir := range missingDirs {
+		changes = append(changes, applyChange{kind: applyCreateDir, path: repoDir + "/" + dir})
+	}
+
+	for _, rule := range repo.ACL {
+		dirPath := dirPathIn(repoPath, rule.Path)
+
+		var accountName api.AccountName
+		_ = accountName.Set(rule.Account)
+
+		var permission api.Permission
+		_ = permission.Set(rule.Permission)
+
+		previous, exists := existingRules[accessRuleKey(relativeDirPath(repoDir, dirPath), accountName.Value())]
+		if !exists {
+			changes = append(changes, applyChange{kind: applySetAccessRule, path: dirPath, account: accountName, permission: permission})
+		} else if previous != permission {
+			changes = append(changes, applyChange{kind: applyUpdateAccessRule, path: dirPath, account: accountName, permission: permission, previousPermission: previous})
+		}
+	}
+
+	for _, service := range repo.Services {
+		if !existingServices[service.Description] {
+			changes = append(changes, applyChange{kind: applyCreateService, path: repoPath.Value(), service: service})
+		}
+	}
+
+	return changes, nil
+}
+
+// dirPathIn returns the absolute path of the directory at the given path relative to the repository.
+func dirPathIn(repoPath api.RepoPath, dir string) string {
+	return path.Join(repoPath.GetDirPath().Value(), dir)
+}
+
+// relativeDirPath returns the path of the directory relative to the repository directory.
+// The repository directory itself is returned as an empty string.
+func relativeDirPath(repoDir string, dir string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(dir, repoDir), "/")
+}
+
+func accessRuleKey(dir string, accountName string) string {
+	return dir + ":" + strings.ToLower(accountName)
+}
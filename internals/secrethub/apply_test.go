@@ -0,0 +1,265 @@
+package secrethub
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/secrethub/secrethub-cli/internals/cli/ui/fakeui"
+
+	"github.com/secrethub/secrethub-go/internals/api"
+	"github.com/secrethub/secrethub-go/internals/api/uuid"
+	"github.com/secrethub/secrethub-go/internals/assert"
+	"github.com/secrethub/secrethub-go/pkg/secrethub/credentials"
+	"github.com/secrethub/secrethub-go/pkg/secrethub/fakeclient"
+)
+
+func TestParseApplyManifest(t *testing.T) {
+	cases := map[string]struct {
+		raw      string
+		expected *applyManifest
+		err      bool
+	}{
+		"valid": {
+			raw: `
+repos:
+  - path: namespace/repo
+    dirs:
+      - staging
+    acl:
+      - path: staging
+        account: developer
+        permission: read
+    services:
+      - description: deploy
+        permission: staging:read
+        credential_file: deploy.cred
+`,
+			expected: &applyManifest{
+				Repos: []applyRepo{
+					{
+						Path: "namespace/repo",
+						Dirs: []string{"staging"},
+						ACL: []applyAccessRule{
+							{Path: "staging", Account: "developer", Permission: "read"},
+						},
+						Services: []applyService{
+							{Description: "deploy", Permission: "staging:read", CredentialFile: "deploy.cred"},
+						},
+					},
+				},
+			},
+		},
+		"unknown field": {
+			raw: "repos:\n  - path: namespace/repo\n    directories: [staging]\n",
+			err: true,
+		},
+		"invalid repo path": {
+			raw: "repos:\n  - path: namespace\n",
+			err: true,
+		},
+		"duplicate repo": {
+			raw: "repos:\n  - path: namespace/repo\n  - path: namespace/repo\n",
+			err: true,
+		},
+		"invalid permission": {
+			raw: "repos:\n  - path: namespace/repo\n    acl:\n      - account: developer\n        permission: owner\n",
+			err: true,
+		},
+		"service without description": {
+			raw: "repos:\n  - path: namespace/repo\n    services:\n      - permission: read\n",
+			err: true,
+		},
+		"duplicate service": {
+			raw: "repos:\n  - path: namespace/repo\n    services:\n      - description: deploy\n      - description: deploy\n",
+			err: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			actual, err := parseApplyManifest([]byte(tc.raw))
+
+			assert.Equal(t, err != nil, tc.err)
+			if !tc.err {
+				assert.Equal(t, actual, tc.expected)
+			}
+		})
+	}
+}
+
+func TestNewApplyPlan(t *testing.T) {
+	testError := errors.New("test error")
+
+	repoID := uuid.New()
+	stagingID := uuid.New()
+
+	tree := &api.Tree{
+		ParentPath: "namespace",
+		RootDir: &api.Dir{
+			DirID: repoID,
+			Name:  "repo",
+			SubDirs: []*api.Dir{
+				{DirID: stagingID, ParentID: &repoID, Name: "staging"},
+			},
+		},
+		Dirs: map[uuid.UUID]*api.Dir{
+			repoID:    {DirID: repoID, Name: "repo"},
+			stagingID: {DirID: stagingID, ParentID: &repoID, Name: "staging"},
+		},
+	}
+
+	rules := []*api.AccessRule{
+		{DirID: repoID, Account: &api.Account{Name: "admin"}, Permission: api.PermissionAdmin},
+		{DirID: stagingID, Account: &api.Account{Name: "developer"}, Permission: api.PermissionRead},
+	}
+
+	services := []*api.Service{
+		{ServiceID: "s-existing", Description: "deploy"},
+	}
+
+	cases := map[string]struct {
+		manifest   applyManifest
+		getTreeErr error
+		listErr    error
+		expected   applyPlan
+		err        error
+	}{
+		"no changes": {
+			manifest: applyManifest{
+				Repos: []applyRepo{
+					{
+						Path: "namespace/repo",
+						Dirs: []string{"staging"},
+						ACL: []applyAccessRule{
+							{Account: "admin", Permission: "admin"},
+							{Path: "staging", Account: "Developer", Permission: "read"},
+						},
+						Services: []applyService{{Description: "deploy", Permission: "staging:read"}},
+					},
+				},
+			},
+		},
+		"changes": {
+			manifest: applyManifest{
+				Repos: []applyRepo{
+					{
+						Path: "namespace/repo",
+						Dirs: []string{"staging", "prod/db"},
+						ACL: []applyAccessRule{
+							{Path: "staging", Account: "developer", Permission: "write"},
+							{Path: "prod", Account: "ops", Permission: "admin"},
+						},
+						Services: []applyService{
+							{Description: "deploy"},
+							{Description: "monitoring", Permission: "prod:read"},
+						},
+					},
+				},
+			},
+			expected: applyPlan{
+				{kind: applyCreateDir, path: "namespace/repo/prod"},
+				{kind: applyCreateDir, path: "namespace/repo/prod/db"},
+				{kind: applyUpdateAccessRule, path: "namespace/repo/staging", account: "developer", permission: api.PermissionWrite, previousPermission: api.PermissionRead},
+				{kind: applySetAccessRule, path: "namespace/repo/prod", account: "ops", permission: api.PermissionAdmin},
+				{kind: applyCreateService, path: "namespace/repo", service: applyService{Description: "monitoring", Permission: "prod:read"}},
+			},
+		},
+		"new repo": {
+			manifest: applyManifest{
+				Repos: []applyRepo{
+					{
+						Path: "namespace/repo",
+						ACL: []applyAccessRule{
+							{Path: "staging", Account: "developer", Permission: "read"},
+						},
+						Services: []applyService{{Description: "deploy"}},
+					},
+				},
+			},
+			getTreeErr: api.ErrDirNotFound,
+			expected: applyPlan{
+				{kind: applyCreateRepo, path: "namespace/repo"},
+				{kind: applyCreateDir, path: "namespace/repo/staging"},
+				{kind: applySetAccessRule, path: "namespace/repo/staging", account: "developer", permission: api.PermissionRead},
+				{kind: applyCreateService, path: "namespace/repo", service: applyService{Description: "deploy"}},
+			},
+		},
+		"get tree error": {
+			manifest:   applyManifest{Repos: []applyRepo{{Path: "namespace/repo"}}},
+			getTreeErr: testError,
+			err:        testError,
+		},
+		"list access rules error": {
+			manifest: applyManifest{Repos: []applyRepo{{Path: "namespace/repo"}}},
+			listErr:  testError,
+			err:      testError,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			client := fakeclient.Client{
+				DirService: &fakeclient.DirService{
+					GetTreeFunc: func(path string, depth int, ancestors bool) (*api.Tree, error) {
+						if tc.getTreeErr != nil {
+							return nil, tc.getTreeErr
+						}
+						return tree, nil
+					},
+				},
+				AccessRuleService: &fakeclient.AccessRuleService{
+					ListFunc: func(path string, depth int, ancestors bool) ([]*api.AccessRule, error) {
+						return rules, tc.listErr
+					},
+				},
+				ServiceService: &fakeclient.ServiceService{
+					ListFunc: func(path string) ([]*api.Service, error) {
+						return services, nil
+					},
+				},
+			}
+
+			plan, err := newApplyPlan(client, &tc.manifest)
+
+			assert.Equal(t, err, tc.err)
+			assert.Equal(t, plan, tc.expected)
+		})
+	}
+}
+
+func TestApplyCommand_createService(t *testing.T) {
+	testError := errors.New("test error")
+
+	io := fakeui.NewIO(t)
+	cmd := ApplyCommand{io: io}
+
+	var deleted string
+	client := fakeclient.Client{
+		ServiceService: &fakeclient.ServiceService{
+			CreateFunc: func(path string, description string, credentialCreator credentials.Creator) (*api.Service, error) {
+				return &api.Service{ServiceID: "s-test"}, credentialCreator.Create()
+			},
+			DeleteFunc: func(id string) (*api.RevokeRepoResponse, error) {
+				deleted = id
+				return &api.RevokeRepoResponse{}, nil
+			},
+		},
+		AccessRuleService: &fakeclient.AccessRuleService{
+			SetFunc: func(path string, permission string, accountName string) (*api.AccessRule, error) {
+				return nil, testError
+			},
+		},
+	}
+
+	err := cmd.createService(client, applyChange{
+		kind:    applyCreateService,
+		path:    "namespace/repo",
+		service: applyService{Description: "deploy", Permission: "read"},
+	})
+
+	assert.Equal(t, err, testError)
+	assert.Equal(t, deleted, "s-test")
+	// The credential is written before the permission is given.
+	assert.Equal(t, strings.HasPrefix(io.Out.String(), "Account configuration for s-test:\n"), true)
+}
@@ -43,7 +43,7 @@ type AuditCommand struct {
 	terminalWidth      func(int) (int, error)
 	perPage            int
 	maxResults         int
-	format             string
+	format             listFormat
 }
 
 // NewAuditCommand creates a new audit command.
@@ -69,7 +69,8 @@ func (cmd *AuditCommand) Register(r command.Registerer) {
 	clause := r.Command("audit", "Show the audit log.")
 	clause.Arg("repo-path or secret-path", "Path to the repository or the secret to audit "+repoPathPlaceHolder+" or "+secretPathPlaceHolder).SetValue(&cmd.path)
 	clause.Flag("per-page", "Number of audit events shown per page").Default("20").Hidden().IntVar(&cmd.perPage)
-	clause.Flag("output-format", "Specify the format in which to output the log. Options are: table and json. If the output of the command is parsed by a script an alternative of the table format must be used.").HintOptions("table", "json").Default("table").StringVar(&cmd.format)
+	cmd.format.registerWithTemplate(clause)
+	clause.Flag("output-format", "Deprecated: use --format instead.").Hidden().StringVar((*string)(&cmd.format))
 	clause.Flag("max-results", "Specify the number of entries to list. If maxResults < 0 all entries are displayed. If the output of the command is piped, maxResults defaults to 1000.").Default(strconv.Itoa(defaultLimit)).IntVar(&cmd.maxResults)
 	registerTimestampFlag(clause).BoolVar(&cmd.useTimestamps)
 
@@ -84,7 +85,7 @@ func (cmd *AuditCommand) Run() error {
 
 // beforeRun configures the command using the flag values.
 func (cmd *AuditCommand) beforeRun() {
-	if cmd.format == formatJSON {
+	if !cmd.format.isTable() {
 		cmd.timeFormatter = NewTimeFormatter(true)
 	} else {
 		cmd.timeFormatter = NewTimeFormatter(cmd.useTimestamps)
@@ -108,19 +109,20 @@ func (cmd *AuditCommand) run() error {
 	}
 	defer paginatedWriter.Close()
 
-	var formatter listFormatter
-	if cmd.format == formatJSON {
-		formatter = newJSONFormatter(paginatedWriter, auditTable.header())
-	} else if cmd.format == formatTable && cmd.io.IsOutputPiped() {
-		formatter = newLineFormatter(paginatedWriter)
-	} else if cmd.format == formatTable {
+	var formatter *itemFormatter
+	if !cmd.format.isTable() {
+		formatter, err = cmd.format.newItemFormatter(paginatedWriter, auditTable.header()...)
+		if err != nil {
+			return err
+		}
+	} else if cmd.io.IsOutputPiped() {
+		formatter = &itemFormatter{list: newLineFormatter(paginatedWriter)}
+	} else {
 		terminalWidth, err := cmd.terminalWidth(int(cmd.io.Stdout().Fd()))
 		if err != nil {
 			terminalWidth = defaultTerminalWidth
 		}
-		formatter = newTableFormatter(paginatedWriter, terminalWidth, auditTable.columns())
-	} else {
-		return errNoSuchFormat(cmd.format)
+		formatter = &itemFormatter{list: newTableFormatter(paginatedWriter, terminalWidth, auditTable.columns())}
 	}
 
 	for lineCount := 0; lineCount != cmd.maxResults; lineCount++ {
@@ -131,12 +133,16 @@ func (cmd *AuditCommand) run() error {
 			return err
 		}
 
-		row, err := auditTable.row(event)
-		if err != nil {
-			return err
+		// A template is executed on the event itself, so the row is only needed for the other formats.
+		var row []string
+		if !cmd.format.isTemplate() {
+			row, err = auditTable.row(event)
+			if err != nil {
+				return err
+			}
 		}
 
-		err = formatter.Write(row)
+		err = formatter.Write(event, row)
 		if err == pager.ErrPagerClosed {
 			break
 		} else if err != nil {
@@ -72,6 +72,80 @@ func TestAuditSecretCommand_run(t *testing.T) {
 				"            ret                     T01:01:01+\n" +
 				"                                    01:00     \n",
 		},
+		"format template": {
+			cmd: AuditCommand{
+				path: "namespace/repo/secret",
+				newClient: func() (secrethub.ClientInterface, error) {
+					return fakeclient.Client{
+						DirService: &fakeclient.DirService{
+							ExistsFunc: func(_ string) (bool, error) {
+								return false, nil
+							},
+						},
+						SecretService: &fakeclient.SecretService{
+							AuditEventIterator: &fakeclient.AuditEventIterator{
+								Events: []api.Audit{
+									{
+										Action: "create",
+										Actor: api.AuditActor{
+											Type: "user",
+											User: &api.User{
+												Username: "developer",
+											},
+										},
+										IPAddress: "127.0.0.1",
+									},
+								},
+							},
+						},
+					}, nil
+				},
+				format:     "{{upper .Action}} by {{.Actor.User.Username}} from {{.IPAddress}}",
+				perPage:    20,
+				maxResults: -1,
+			},
+			out: "CREATE by developer from 127.0.0.1\n",
+		},
+		"yaml format": {
+			cmd: AuditCommand{
+				path: "namespace/repo/secret",
+				newClient: func() (secrethub.ClientInterface, error) {
+					return fakeclient.Client{
+						DirService: &fakeclient.DirService{
+							ExistsFunc: func(_ string) (bool, error) {
+								return false, nil
+							},
+						},
+						SecretService: &fakeclient.SecretService{
+							AuditEventIterator: &fakeclient.AuditEventIterator{
+								Events: []api.Audit{
+									{
+										Action: "create",
+										Actor: api.AuditActor{
+											Type: "user",
+											User: &api.User{
+												Username: "developer",
+											},
+										},
+										IPAddress: "127.0.0.1",
+									},
+								},
+							},
+						},
+					}, nil
+				},
+				format:     formatYAML,
+				perPage:    20,
+				maxResults: -1,
+				timeFormatter: &fakes.TimeFormatter{
+					Response: "2018-01-01T01:01:01+01:00",
+				},
+			},
+			out: "- Author: developer\n" +
+				"  Event: create.\n" +
+				"  IPAddress: 127.0.0.1\n" +
+				"  Date: \"2018-01-01T01:01:01+01:00\"\n",
+		},
 		"0 events": {
 			cmd: AuditCommand{
 				path: "namespace/repo/secret",
@@ -0,0 +1,41 @@
+package secrethub
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/secrethub/secrethub-cli/internals/cli/ui"
+	"github.com/secrethub/secrethub-cli/internals/secrethub/command"
+)
+
+// CacheClearCommand removes all secrets from the offline cache.
+type CacheClearCommand struct {
+	io              ui.IO
+	credentialStore CredentialConfig
+}
+
+// NewCacheClearCommand creates a new CacheClearCommand.
+func NewCacheClearCommand(io ui.IO, store CredentialConfig) *CacheClearCommand {
+	return &CacheClearCommand{
+		io:              io,
+		credentialStore: store,
+	}
+}
+
+// Register registers the command, arguments and flags on the provided Registerer.
+func (cmd *CacheClearCommand) Register(r command.Registerer) {
+	clause := r.Command("clear", "Remove all secrets from the offline cache, for all account credentials.")
+	command.BindAction(clause, cmd.Run)
+}
+
+// Run removes the offline cache directory.
+func (cmd *CacheClearCommand) Run() error {
+	err := os.RemoveAll(filepath.Join(cmd.credentialStore.ConfigDir().Path(), cacheDirName))
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cmd.io.Output(), "The offline cache has been cleared.")
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 
 	"github.com/secrethub/secrethub-go/pkg/secrethub"
 	"github.com/secrethub/secrethub-go/pkg/secrethub/configdir"
@@ -33,6 +34,7 @@ func NewClientFactory(store CredentialConfig) ClientFactory {
 
 type clientFactory struct {
 	client           *secrethub.Client
+	clientMutex      sync.Mutex
 	ServerURL        *url.URL
 	identityProvider string
 	proxyAddress     *url.URL
@@ -48,7 +50,11 @@ func (f *clientFactory) Register(r FlagRegisterer) {
 
 // NewClient returns a new client that is configured to use the remote that
 // is set with the flag.
+// It is safe to call NewClient concurrently.
 func (f *clientFactory) NewClient() (secrethub.ClientInterface, error) {
+	f.clientMutex.Lock()
+	defer f.clientMutex.Unlock()
+
 	if f.client == nil {
 		var credentialProvider credentials.Provider
 		switch strings.ToLower(f.identityProvider) {
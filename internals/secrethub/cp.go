@@ -0,0 +1,288 @@
+package secrethub
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/secrethub/secrethub-cli/internals/cli/ui"
+	"github.com/secrethub/secrethub-cli/internals/secrethub/command"
+
+	"github.com/secrethub/secrethub-go/internals/api"
+	"github.com/secrethub/secrethub-go/pkg/secrethub"
+)
+
+// Errors
+var (
+	ErrCannotCopyDir          = errMain.Code("cannot_copy_dir").Error("cannot copy a directory. Use the -r flag to copy directories and their contents recursively.")
+	ErrCopyToSelf             = errMain.Code("copy_to_self").ErrorPref("cannot copy %s to itself or one of its subdirectories")
+	ErrCopyDestinationExists  = errMain.Code("copy_destination_exists").ErrorPref("the destination already contains %s. Use the --force flag to write the copied values as new versions")
+	ErrCopyVersionAllVersions = errMain.Code("copy_version_all_versions").Error("the --all-versions flag cannot be used to copy a specific secret version")
+)
+
+// CpCommand copies secrets and directories.
+type CpCommand struct {
+	src       api.Path
+	dst       api.Path
+	options   copyOptions
+	io        ui.IO
+	newClient newClientFunc
+}
+
+// NewCpCommand creates a new CpCommand.
+func NewCpCommand(io ui.IO, newClient newClientFunc) *CpCommand {
+	return &CpCommand{
+		io:        io,
+		newClient: newClient,
+	}
+}
+
+// Register registers the command, arguments and flags on the provided Registerer.
+func (cmd *CpCommand) Register(r command.Registerer) {
+	clause := r.Command("cp", "Copy a secret, secret version or directory, also to another repository.")
+	clause.Alias("copy")
+	clause.Arg("src-path", "The path to the secret, secret version or directory to copy (<namespace>/<repo>[/<path>][:<version>])").Required().SetValue(&cmd.src)
+	clause.Arg("dst-path", "The path to copy to (<namespace>/<repo>[/<path>]). A secret copied to an existing directory is placed in that directory. The contents of a copied directory are merged into the destination directory.").Required().SetValue(&cmd.dst)
+	cmd.options.register(clause)
+
+	command.BindAction(clause, cmd.Run)
+}
+
+// Run copies the resource at the source path to the destination path.
+func (cmd *CpCommand) Run() error {
+	client, err := cmd.newClient()
+	if err != nil {
+		return err
+	}
+
+	copied, _, err := copyPath(client, cmd.src, cmd.dst, cmd.options)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(
+		cmd.io.Output(),
+		"Copy complete! Copied %s from %s to %s.\n",
+		pluralize("secret", "secrets", copied),
+		cmd.src,
+		cmd.dst,
+	)
+
+	return nil
+}
+
+// copyOptions configures how secrets and directories are copied.
+type copyOptions struct {
+	recursive   bool
+	allVersions bool
+	force       bool
+}
+
+// register registers the flags of the cp command.
+func (o *copyOptions) register(r FlagRegisterer) {
+	o.registerShared(r)
+	r.Flag("all-versions", "Copy all versions of every secret in order, preserving the version history. By default, only the latest version is copied.").BoolVar(&o.allVersions)
+}
+
+// registerShared registers the flags that are shared by the cp and mv commands.
+func (o *copyOptions) registerShared(r FlagRegisterer) {
+	r.Flag("recursive", "Copy directories and their contents recursively.").Short('r').BoolVar(&o.recursive)
+	r.Flag("force", "Write the copied values of secrets that already exist at the destination as new versions.").Short('f').BoolVar(&o.force)
+}
+
+// copyPath copies the secret, secret version or directory at the source path to the destination path.
+// It returns the number of secrets that have been copied and whether the source is a directory.
+func copyPath(client secrethub.ClientInterface, src api.Path, dst api.Path, options copyOptions) (int, bool, error) {
+	if !src.HasVersion() {
+		srcDir, err := src.ToDirPath()
+		if err != nil {
+			return 0, false, err
+		}
+
+		tree, err := client.Dirs().GetTree(srcDir.Value(), -1, false)
+		if err == nil {
+			if !options.recursive {
+				return 0, true, ErrCannotCopyDir
+			}
+
+			dstDir, err := dst.ToDirPath()
+			if err != nil {
+				return 0, true, err
+			}
+
+			copied, err := copyDir(client, tree, srcDir, dstDir, options)
+			return copied, true, err
+		} else if !api.IsErrNotFound(err) {
+			return 0, false, err
+		}
+	}
+
+	srcSecret, err := src.ToSecretPath()
+	if err != nil {
+		return 0, false, err
+	}
+
+	if srcSecret.HasVersion() && options.allVersions {
+		return 0, false, ErrCopyVersionAllVersions
+	}
+
+	dstSecret, err := copyDestinationSecretPath(client, srcSecret, dst)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if dstSecret.Value() == srcSecret.Value() {
+		return 0, false, ErrCopyToSelf(srcSecret)
+	}
+
+	if !options.force {
+		exists, err := client.Secrets().Exists(dstSecret.Value())
+		if err != nil {
+			return 0, false, err
+		}
+		if exists {
+			return 0, false, ErrCopyDestinationExists("the secret " + dstSecret.String())
+		}
+	}
+
+	err = copySecret(client, srcSecret, dstSecret, options.allVersions)
+	if err != nil {
+		return 0, false, err
+	}
+	return 1, false, nil
+}
+
+// copyDestinationSecretPath returns the path to copy the given secret to.
+// When the destination is an existing directory, the secret is copied into it.
+func copyDestinationSecretPath(client secrethub.ClientInterface, src api.SecretPath, dst api.Path) (api.SecretPath, error) {
+	if dst.HasVersion() {
+		return "", errCannotWriteToVersion
+	}
+
+	dstDir, err := dst.ToDirPath()
+	if err != nil {
+		return "", err
+	}
+
+	isDir, err := client.Dirs().Exists(dstDir.Value())
+	if err != nil {
+		return "", err
+	}
+	if isDir {
+		return api.NewSecretPath(dstDir.Value() + "/" + src.GetSecret())
+	}
+
+	return dst.ToSecretPath()
+}
+
+// copyDir copies the contents of the given tree at the source path into the destination directory,
+// creating the destination directory when it does not exist yet. It returns the number of secrets
+// that have been copied.
+func copyDir(client secrethub.ClientInterface, tree *api.Tree, src api.DirPath, dst api.DirPath, options copyOptions) (int, error) {
+	if strings.HasPrefix(dst.Value()+"/", src.Value()+"/") {
+		return 0, ErrCopyToSelf(src)
+	}
+
+	dirs, secrets := walkDir(tree.RootDir)
+
+	dstExists := true
+	var existingDirs, existingSecrets []string
+	dstTree, err := client.Dirs().GetTree(dst.Value(), -1, false)
+	if api.IsErrNotFound(err) {
+		dstExists = false
+	} else if err != nil {
+		return 0, err
+	} else {
+		existingDirs, existingSecrets = walkDir(dstTree.RootDir)
+	}
+
+	if !options.force {
+		conflicts := len(intersectSorted(secrets, existingSecrets))
+		if conflicts > 0 {
+			return 0, ErrCopyDestinationExists(pluralize("secret", "secrets", conflicts))
+		}
+	}
+
+	if !dstExists {
+		err = client.Dirs().CreateAll(dst.Value())
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	dirExists := make(map[string]bool, len(existingDirs))
+	for _, dir := range existingDirs {
+		dirExists[dir] = true
+	}
+
+	for _, dir := range dirs {
+		if dirExists[dir] {
+			continue
+		}
+
+		_, err = client.Dirs().Create(dst.Value() + "/" + dir)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	for i, secret := range secrets {
+		err = copySecret(client, api.SecretPath(src.Value()+"/"+secret), api.SecretPath(dst.Value()+"/"+secret), options.allVersions)
+		if err != nil {
+			return i, err
+		}
+	}
+
+	return len(secrets), nil
+}
+
+// copySecret writes the value of the source secret to the destination secret. When allVersions is set,
+// all versions of the source secret are written in order. Otherwise, only the given version or the latest
+// version of the source secret is written.
+func copySecret(client secrethub.ClientInterface, src api.SecretPath, dst api.SecretPath, allVersions bool) error {
+	var versions []*api.SecretVersion
+	if allVersions {
+		var err error
+		versions, err = client.Secrets().Versions().ListWithData(src.Value())
+		if err != nil {
+			return err
+		}
+
+		sort.Slice(versions, func(i, j int) bool {
+			return versions[i].Version < versions[j].Version
+		})
+	} else {
+		version, err := client.Secrets().Versions().GetWithData(src.Value())
+		if err != nil {
+			return err
+		}
+		versions = []*api.SecretVersion{version}
+	}
+
+	for _, version := range versions {
+		_, err := client.Secrets().Write(dst.Value(), version.Data)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// intersectSorted returns the elements that are in both of the given sorted lists.
+func intersectSorted(a, b []string) []string {
+	var res []string
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			res = append(res, a[i])
+			i++
+			j++
+		}
+	}
+	return res
+}
@@ -0,0 +1,343 @@
+package secrethub
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/secrethub/secrethub-cli/internals/cli/ui/fakeui"
+
+	"github.com/secrethub/secrethub-go/internals/api"
+	"github.com/secrethub/secrethub-go/internals/assert"
+	"github.com/secrethub/secrethub-go/pkg/secrethub"
+	"github.com/secrethub/secrethub-go/pkg/secrethub/fakeclient"
+)
+
+// fakeRepo is an in-memory repository to test copying and moving secrets.
+type fakeRepo struct {
+	trees   map[string]*api.Tree
+	secrets map[string][]string
+	created []string
+	written []string
+	deleted []string
+	// read contains the paths of which the data is retrieved.
+	read []string
+}
+
+func (r *fakeRepo) client() secrethub.ClientInterface {
+	return fakeRepoClient{
+		repo: r,
+		Client: fakeclient.Client{
+			DirService: &fakeclient.DirService{
+				GetTreeFunc: func(path string, depth int, ancestors bool) (*api.Tree, error) {
+					tree, ok := r.trees[path]
+					if !ok {
+						return nil, api.ErrDirNotFound
+					}
+					return tree, nil
+				},
+				ExistsFunc: func(path string) (bool, error) {
+					_, ok := r.trees[path]
+					return ok, nil
+				},
+				CreateFunc: func(path string) (*api.Dir, error) {
+					r.created = append(r.created, path)
+					return &api.Dir{}, nil
+				},
+				DeleteFunc: func(path string) error {
+					r.deleted = append(r.deleted, path)
+					return nil
+				},
+			},
+			SecretService: &fakeclient.SecretService{
+				WriteFunc: func(path string, data []byte) (*api.SecretVersion, error) {
+					r.written = append(r.written, path+"="+string(data))
+					return &api.SecretVersion{}, nil
+				},
+				DeleteFunc: func(path string) error {
+					r.deleted = append(r.deleted, path)
+					return nil
+				},
+				VersionService: &fakeclient.SecretVersionService{
+					GetWithDataFunc: func(path string) (*api.SecretVersion, error) {
+						version, err := r.getVersion(path)
+						if err != nil {
+							return nil, err
+						}
+						r.read = append(r.read, path)
+						return version, nil
+					},
+					GetWithoutDataFunc: func(path string) (*api.SecretVersion, error) {
+						version, err := r.getVersion(path)
+						if err != nil {
+							return nil, err
+						}
+						version.Data = nil
+						return version, nil
+					},
+					ListWithDataFunc: func(path string) ([]*api.SecretVersion, error) {
+						var res []*api.SecretVersion
+						for i := len(r.secrets[path]) - 1; i >= 0; i-- {
+							res = append(res, &api.SecretVersion{Version: i + 1, Data: []byte(r.secrets[path][i])})
+						}
+						return res, nil
+					},
+				},
+			},
+		},
+	}
+}
+
+// getVersion returns the secret version at the given path, which may have a version suffix.
+func (r *fakeRepo) getVersion(path string) (*api.SecretVersion, error) {
+	version := 0
+	if i := strings.LastIndex(path, ":"); i != -1 {
+		version, _ = strconv.Atoi(path[i+1:])
+		path = path[:i]
+	}
+	versions, ok := r.secrets[path]
+	if !ok || version > len(versions) {
+		return nil, api.ErrSecretNotFound
+	}
+	if version == 0 {
+		version = len(versions)
+	}
+	return &api.SecretVersion{Version: version, Data: []byte(versions[version-1])}, nil
+}
+
+// fakeRepoClient is a client for a fakeRepo. It implements the parts of the directory
+// and secret services that are not implemented by the fakeclient package.
+type fakeRepoClient struct {
+	fakeclient.Client
+	repo *fakeRepo
+}
+
+func (c fakeRepoClient) Dirs() secrethub.DirService {
+	return fakeRepoDirService{
+		DirService: c.DirService,
+		repo:       c.repo,
+	}
+}
+
+func (c fakeRepoClient) Secrets() secrethub.SecretService {
+	return fakeRepoSecretService{
+		SecretService: c.SecretService,
+		repo:          c.repo,
+	}
+}
+
+type fakeRepoDirService struct {
+	*fakeclient.DirService
+	repo *fakeRepo
+}
+
+// CreateAll records the created directory.
+func (s fakeRepoDirService) CreateAll(path string) error {
+	s.repo.created = append(s.repo.created, path)
+	return nil
+}
+
+type fakeRepoSecretService struct {
+	*fakeclient.SecretService
+	repo *fakeRepo
+}
+
+// Exists returns whether the secret exists in the repository.
+func (s fakeRepoSecretService) Exists(path string) (bool, error) {
+	_, ok := s.repo.secrets[path]
+	return ok, nil
+}
+
+func newFakeRepo() *fakeRepo {
+	return &fakeRepo{
+		trees: map[string]*api.Tree{
+			"namespace/staging/app": {
+				RootDir: &api.Dir{
+					Name: "app",
+					SubDirs: []*api.Dir{
+						{
+							Name:    "db",
+							Secrets: []*api.Secret{{Name: "password"}},
+						},
+					},
+					Secrets: []*api.Secret{{Name: "api_key"}},
+				},
+			},
+			"namespace/staging/app/db": {
+				RootDir: &api.Dir{
+					Name:    "db",
+					Secrets: []*api.Secret{{Name: "password"}},
+				},
+			},
+			"namespace/prod": {
+				RootDir: &api.Dir{
+					Name: "prod",
+				},
+			},
+			"namespace/prod/app": {
+				RootDir: &api.Dir{
+					Name:    "app",
+					Secrets: []*api.Secret{{Name: "api_key"}},
+				},
+			},
+		},
+		secrets: map[string][]string{
+			"namespace/staging/app/api_key":     {"key1", "key2"},
+			"namespace/staging/app/db/password": {"pass1"},
+			"namespace/prod/app/api_key":        {"prodkey"},
+		},
+	}
+}
+
+func TestCpCommand_Run(t *testing.T) {
+	cases := map[string]struct {
+		src     api.Path
+		dst     api.Path
+		options copyOptions
+		created []string
+		written []string
+		out     string
+		err     error
+	}{
+		"secret": {
+			src:     "namespace/staging/app/api_key",
+			dst:     "namespace/prod/api_key",
+			written: []string{"namespace/prod/api_key=key2"},
+			out:     "Copy complete! Copied 1 secret from namespace/staging/app/api_key to namespace/prod/api_key.\n",
+		},
+		"secret into directory": {
+			src:     "namespace/staging/app/db/password",
+			dst:     "namespace/prod",
+			written: []string{"namespace/prod/password=pass1"},
+			out:     "Copy complete! Copied 1 secret from namespace/staging/app/db/password to namespace/prod.\n",
+		},
+		"secret all versions": {
+			src:     "namespace/staging/app/api_key",
+			dst:     "namespace/prod/api_key",
+			options: copyOptions{allVersions: true},
+			written: []string{"namespace/prod/api_key=key1", "namespace/prod/api_key=key2"},
+			out:     "Copy complete! Copied 1 secret from namespace/staging/app/api_key to namespace/prod/api_key.\n",
+		},
+		"secret exists": {
+			src: "namespace/staging/app/api_key",
+			dst: "namespace/prod/app/api_key",
+			err: ErrCopyDestinationExists("the secret namespace/prod/app/api_key"),
+		},
+		"secret to itself": {
+			src: "namespace/staging/app/api_key",
+			dst: "namespace/staging/app/api_key",
+			err: ErrCopyToSelf(api.SecretPath("namespace/staging/app/api_key")),
+		},
+		"directory without recursive": {
+			src: "namespace/staging/app",
+			dst: "namespace/prod/app",
+			err: ErrCannotCopyDir,
+		},
+		"directory conflict": {
+			src:     "namespace/staging/app",
+			dst:     "namespace/prod/app",
+			options: copyOptions{recursive: true},
+			err:     ErrCopyDestinationExists("1 secret"),
+		},
+		"directory force": {
+			src:     "namespace/staging/app",
+			dst:     "namespace/prod/app",
+			options: copyOptions{recursive: true, force: true},
+			created: []string{"namespace/prod/app/db"},
+			written: []string{"namespace/prod/app/api_key=key2", "namespace/prod/app/db/password=pass1"},
+			out:     "Copy complete! Copied 2 secrets from namespace/staging/app to namespace/prod/app.\n",
+		},
+		"directory into itself": {
+			src:     "namespace/staging/app",
+			dst:     "namespace/staging/app/db",
+			options: copyOptions{recursive: true},
+			err:     ErrCopyToSelf(api.DirPath("namespace/staging/app")),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			repo := newFakeRepo()
+			io := fakeui.NewIO(t)
+			cmd := CpCommand{
+				src:     tc.src,
+				dst:     tc.dst,
+				options: tc.options,
+				io:      io,
+				newClient: func() (secrethub.ClientInterface, error) {
+					return repo.client(), nil
+				},
+			}
+
+			err := cmd.Run()
+
+			assert.Equal(t, err, tc.err)
+			assert.Equal(t, repo.created, tc.created)
+			assert.Equal(t, repo.written, tc.written)
+			assert.Equal(t, io.Out.String(), tc.out)
+		})
+	}
+}
+
+func TestMvCommand_Run(t *testing.T) {
+	cases := map[string]struct {
+		src       api.Path
+		dst       api.Path
+		recursive bool
+		created   []string
+		written   []string
+		deleted   []string
+		err       error
+	}{
+		"secret": {
+			src:     "namespace/staging/app/api_key",
+			dst:     "namespace/prod/api_key",
+			written: []string{"namespace/prod/api_key=key1", "namespace/prod/api_key=key2"},
+			deleted: []string{"namespace/staging/app/api_key"},
+		},
+		"directory": {
+			src:       "namespace/staging/app/db",
+			dst:       "namespace/prod/app/db",
+			recursive: true,
+			created:   []string{"namespace/prod/app/db"},
+			written:   []string{"namespace/prod/app/db/password=pass1"},
+			deleted:   []string{"namespace/staging/app/db"},
+		},
+		"copy fails": {
+			src:       "namespace/staging/app",
+			dst:       "namespace/prod/app",
+			recursive: true,
+			err:       ErrCopyDestinationExists("1 secret"),
+		},
+		"version": {
+			src: "namespace/staging/app/api_key:1",
+			dst: "namespace/prod/api_key",
+			err: ErrCannotMoveVersion,
+		},
+		"root directory": {
+			src:       "namespace/staging",
+			dst:       "namespace/prod",
+			recursive: true,
+			err:       ErrCannotMoveRootDir,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			repo := newFakeRepo()
+			cmd := NewMvCommand(fakeui.NewIO(t), func() (secrethub.ClientInterface, error) {
+				return repo.client(), nil
+			})
+			cmd.src = tc.src
+			cmd.dst = tc.dst
+			cmd.options.recursive = tc.recursive
+
+			err := cmd.Run()
+
+			assert.Equal(t, err, tc.err)
+			assert.Equal(t, repo.created, tc.created)
+			assert.Equal(t, repo.written, tc.written)
+			assert.Equal(t, repo.deleted, tc.deleted)
+		})
+	}
+}
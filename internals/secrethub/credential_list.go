@@ -2,6 +2,7 @@ package secrethub
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 
@@ -17,6 +18,7 @@ type CredentialListCommand struct {
 	io            ui.IO
 	newClient     newClientFunc
 	useTimestamps bool
+	format        listFormat
 }
 
 // NewAccountInitCommand creates a new CredentialListCommand.
@@ -33,6 +35,7 @@ func (cmd *CredentialListCommand) Register(r command.Registerer) {
 	clause.Alias("list")
 
 	registerTimestampFlag(clause).BoolVar(&cmd.useTimestamps)
+	cmd.format.register(clause)
 
 	command.BindAction(clause, cmd.Run)
 }
@@ -44,6 +47,10 @@ func (cmd *CredentialListCommand) Run() error {
 		return err
 	}
 
+	if !cmd.format.isTable() {
+		return cmd.runMachineReadable(client)
+	}
+
 	timeFormatter := NewTimeFormatter(cmd.useTimestamps)
 
 	w := tabwriter.NewWriter(cmd.io.Output(), 0, 2, 2, ' ', 0)
@@ -85,3 +92,37 @@ func (cmd *CredentialListCommand) Run() error {
 
 	return nil
 }
+
+// runMachineReadable lists all the currently authenticated account's credentials in the
+// configured machine readable format. Unlike the table, it contains the full fingerprints.
+func (cmd *CredentialListCommand) runMachineReadable(client secrethub.ClientInterface) error {
+	formatter, err := cmd.format.newFormatter(cmd.io.Output(), "fingerprint", "type", "enabled", "created at", "description")
+	if err != nil {
+		return err
+	}
+
+	timeFormatter := NewTimestampFormatter()
+
+	it := client.Credentials().List(&secrethub.CredentialListParams{})
+	for {
+		cred, err := it.Next()
+		if err == iterator.Done {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		err = formatter.Write([]string{
+			cred.Fingerprint,
+			string(cred.Type),
+			strconv.FormatBool(cred.Enabled),
+			timeFormatter.Format(cred.CreatedAt),
+			cred.Description,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,295 @@
+package secrethub
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/secrethub/secrethub-cli/internals/cli/ui"
+	"github.com/secrethub/secrethub-cli/internals/secrethub/command"
+
+	"github.com/secrethub/secrethub-go/internals/api"
+	"github.com/secrethub/secrethub-go/pkg/secrethub"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// Errors
+var (
+	ErrCannotDiffDirAndSecret = errMain.Code("cannot_diff_dir_and_secret").Error("cannot compare a directory with a secret. Both paths should point to a directory or both paths should point to a secret")
+)
+
+// The statuses of the secrets reported by the diff command.
+const (
+	diffStatusMissing = "missing"
+	diffStatusExtra   = "extra"
+	diffStatusDiffers = "differs"
+)
+
+// DiffCommand shows the differences between two directories, repositories or secret versions.
+type DiffCommand struct {
+	pathA         api.Path
+	pathB         api.Path
+	showValues    bool
+	format        listFormat
+	io            ui.IO
+	newClient     newClientFunc
+	terminalWidth func(int) (int, error)
+}
+
+// NewDiffCommand creates a new DiffCommand.
+func NewDiffCommand(io ui.IO, newClient newClientFunc) *DiffCommand {
+	return &DiffCommand{
+		io:        io,
+		newClient: newClient,
+		terminalWidth: func(fd int) (int, error) {
+			w, _, err := terminal.GetSize(fd)
+			return w, err
+		},
+	}
+}
+
+// Register registers the command, arguments and flags on the provided Registerer.
+func (cmd *DiffCommand) Register(r command.Registerer) {
+	clause := r.Command("diff", "Show the differences between two directories, repositories, secrets or secret versions. Secrets are reported as missing when they only exist at the first path, as extra when they only exist at the second path and as differing when their values are not equal. Values are compared without being shown, unless the --show-values flag is set.")
+	clause.Arg("path-a", "The directory, repository, secret or secret version to compare (<namespace>/<repo>[/<path>][:<version>])").Required().SetValue(&cmd.pathA)
+	clause.Arg("path-b", "The directory, repository, secret or secret version to compare it with (<namespace>/<repo>[/<path>][:<version>])").Required().SetValue(&cmd.pathB)
+	clause.Flag("show-values", "Show the values of the secrets that differ instead of their version numbers.").BoolVar(&cmd.showValues)
+	cmd.format.register(clause)
+	clause.Flag("output-format", "Deprecated: use --format instead.").Hidden().StringVar((*string)(&cmd.format))
+
+	command.BindAction(clause, cmd.Run)
+}
+
+// Run prints the differences between the secrets at both paths.
+func (cmd *DiffCommand) Run() error {
+	client, err := cmd.newClient()
+	if err != nil {
+		return err
+	}
+
+	diffs, err := diffPaths(client, cmd.pathA, cmd.pathB)
+	if err != nil {
+		return err
+	}
+
+	var formatter listFormatter
+	if !cmd.format.isTable() {
+		formatter, err = cmd.format.newFormatter(cmd.io.Output(), secretDiffHeader()...)
+		if err != nil {
+			return err
+		}
+	} else if cmd.io.IsOutputPiped() {
+		formatter = newLineFormatter(cmd.io.Output())
+	} else {
+		terminalWidth, err := cmd.terminalWidth(int(cmd.io.Stdout().Fd()))
+		if err != nil {
+			terminalWidth = defaultTerminalWidth
+		}
+		formatter = newTableFormatter(cmd.io.Output(), terminalWidth, secretDiffColumns())
+	}
+
+	if len(diffs) == 0 && cmd.format.isTable() && !cmd.io.IsOutputPiped() {
+		fmt.Fprintf(cmd.io.Output(), "No differences found between %s and %s.\n", cmd.pathA, cmd.pathB)
+		return nil
+	}
+
+	for _, diff := range diffs {
+		err = formatter.Write(diff.row(cmd.showValues))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// secretDiff is a secret that differs between the compared paths.
+// The path is relative to the compared directories. Either of the
+// versions is nil when the secret does not exist at that side.
+type secretDiff struct {
+	path   string
+	status string
+	a      *api.SecretVersion
+	b      *api.SecretVersion
+}
+
+func secretDiffColumns() []tableColumn {
+	return []tableColumn{
+		{name: "path"},
+		{name: "status", maxWidth: 7},
+		{name: "left"},
+		{name: "right"},
+	}
+}
+
+func secretDiffHeader() []string {
+	columns := secretDiffColumns()
+	res := make([]string, len(columns))
+	for i, col := range columns {
+		res[i] = col.name
+	}
+	return res
+}
+
+// row returns the table row of the diff. Secret values are only included for
+// secrets that differ when showValues is set, otherwise the version numbers are shown.
+func (d secretDiff) row(showValues bool) []string {
+	cell := func(version *api.SecretVersion) string {
+		if version == nil {
+			return ""
+		}
+		if showValues && d.status == diffStatusDiffers {
+			return string(version.Data)
+		}
+		return "version " + strconv.Itoa(version.Version)
+	}
+	return []string{d.path, d.status, cell(d.a), cell(d.b)}
+}
+
+// diffPaths compares the secrets at the given paths, which should either both be
+// directories or both be secrets. Only the latest versions of the secrets in
+// compared directories are compared. Secrets that are equal are not returned.
+func diffPaths(client secrethub.ClientInterface, a api.Path, b api.Path) ([]secretDiff, error) {
+	treeA, err := getTreeIfDir(client, a)
+	if err != nil {
+		return nil, err
+	}
+
+	treeB, err := getTreeIfDir(client, b)
+	if err != nil {
+		return nil, err
+	}
+
+	if treeA != nil && treeB != nil {
+		// Both paths are valid directory paths, as they have a tree.
+		dirA, _ := a.ToDirPath()
+		dirB, _ := b.ToDirPath()
+		return diffDirs(client, dirA, treeA, dirB, treeB)
+	}
+
+	// The paths that are not directories should point to secrets. When the other path is a directory,
+	// the secret is only retrieved to report a path that does not exist before the mismatch.
+	compareSecrets := treeA == nil && treeB == nil
+
+	var versionA, versionB *api.SecretVersion
+	if treeA == nil {
+		versionA, err = getSecretVersion(client, a, compareSecrets)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if treeB == nil {
+		versionB, err = getSecretVersion(client, b, compareSecrets)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !compareSecrets {
+		return nil, ErrCannotDiffDirAndSecret
+	}
+
+	if checksum(versionA.Data) == checksum(versionB.Data) {
+		return nil, nil
+	}
+
+	secretA, _ := a.ToSecretPath()
+	return []secretDiff{{
+		path:   secretA.GetSecret(),
+		status: diffStatusDiffers,
+		a:      versionA,
+		b:      versionB,
+	}}, nil
+}
+
+// getSecretVersion returns the secret version at the given path, which should point to a secret or to a version
+// of a secret. The data of the version is only retrieved when withData is set. ErrResourceNotFound is returned
+// when the path does not exist.
+func getSecretVersion(client secrethub.ClientInterface, path api.Path, withData bool) (*api.SecretVersion, error) {
+	secretPath, err := path.ToSecretPath()
+	if err != nil {
+		// The path is not a directory, so a path that cannot point to a secret does not exist.
+		return nil, ErrResourceNotFound(path)
+	}
+
+	var version *api.SecretVersion
+	if withData {
+		version, err = client.Secrets().Versions().GetWithData(secretPath.Value())
+	} else {
+		version, err = client.Secrets().Versions().GetWithoutData(secretPath.Value())
+	}
+	if api.IsErrNotFound(err) {
+		return nil, ErrResourceNotFound(path)
+	} else if err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+// getTreeIfDir returns the tree of the directory at the given path.
+// When the path does not point to a directory, nil is returned.
+func getTreeIfDir(client secrethub.ClientInterface, path api.Path) (*api.Tree, error) {
+	if path.HasVersion() {
+		return nil, nil
+	}
+
+	dirPath, err := path.ToDirPath()
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := client.Dirs().GetTree(dirPath.Value(), -1, false)
+	if api.IsErrNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// diffDirs compares the latest versions of the secrets in the trees of the given directories.
+// The data of a secret is only retrieved when it exists in both directories.
+func diffDirs(client secrethub.ClientInterface, dirA api.DirPath, treeA *api.Tree, dirB api.DirPath, treeB *api.Tree) ([]secretDiff, error) {
+	_, secretsA := walkDir(treeA.RootDir)
+	_, secretsB := walkDir(treeB.RootDir)
+
+	pathA := dirA.Value()
+	pathB := dirB.Value()
+
+	var diffs []secretDiff
+	i, j := 0, 0
+	for i < len(secretsA) || j < len(secretsB) {
+		switch {
+		case j == len(secretsB) || (i < len(secretsA) && secretsA[i] < secretsB[j]):
+			version, err := client.Secrets().Versions().GetWithoutData(pathA + "/" + secretsA[i])
+			if err != nil {
+				return nil, err
+			}
+			diffs = append(diffs, secretDiff{path: secretsA[i], status: diffStatusMissing, a: version})
+			i++
+		case i == len(secretsA) || secretsA[i] > secretsB[j]:
+			version, err := client.Secrets().Versions().GetWithoutData(pathB + "/" + secretsB[j])
+			if err != nil {
+				return nil, err
+			}
+			diffs = append(diffs, secretDiff{path: secretsB[j], status: diffStatusExtra, b: version})
+			j++
+		default:
+			versionA, err := client.Secrets().Versions().GetWithData(pathA + "/" + secretsA[i])
+			if err != nil {
+				return nil, err
+			}
+			versionB, err := client.Secrets().Versions().GetWithData(pathB + "/" + secretsB[j])
+			if err != nil {
+				return nil, err
+			}
+			if checksum(versionA.Data) != checksum(versionB.Data) {
+				diffs = append(diffs, secretDiff{path: secretsA[i], status: diffStatusDiffers, a: versionA, b: versionB})
+			}
+			i++
+			j++
+		}
+	}
+
+	return diffs, nil
+}
@@ -0,0 +1,147 @@
+package secrethub
+
+import (
+	"testing"
+
+	"github.com/secrethub/secrethub-cli/internals/cli/ui/fakeui"
+
+	"github.com/secrethub/secrethub-go/internals/api"
+	"github.com/secrethub/secrethub-go/internals/assert"
+	"github.com/secrethub/secrethub-go/pkg/secrethub"
+)
+
+func TestDiffCommand_Run(t *testing.T) {
+	cases := map[string]struct {
+		pathA      api.Path
+		pathB      api.Path
+		showValues bool
+		format     listFormat
+		piped      bool
+		read       []string
+		out        string
+		err        error
+	}{
+		"directories": {
+			pathA: "namespace/staging/app",
+			pathB: "namespace/prod/app",
+			piped: true,
+			read:  []string{"namespace/staging/app/api_key", "namespace/prod/app/api_key"},
+			out: "api_key\tdiffers\tversion 2\tversion 1\n" +
+				"db/password\tmissing\tversion 1\t\n",
+		},
+		"directories reversed": {
+			pathA: "namespace/prod/app",
+			pathB: "namespace/staging/app",
+			piped: true,
+			out: "api_key\tdiffers\tversion 1\tversion 2\n" +
+				"db/password\textra\t\tversion 1\n",
+		},
+		"directories show values": {
+			pathA:      "namespace/staging/app",
+			pathB:      "namespace/prod/app",
+			showValues: true,
+			piped:      true,
+			out: "api_key\tdiffers\tkey2\tprodkey\n" +
+				"db/password\tmissing\tversion 1\t\n",
+		},
+		"directories json": {
+			pathA:  "namespace/staging/app",
+			pathB:  "namespace/prod/app",
+			format: formatJSON,
+			out: `{"Left":"version 2","Path":"api_key","Right":"version 1","Status":"differs"}` + "\n" +
+				`{"Left":"version 1","Path":"db/password","Right":"","Status":"missing"}` + "\n",
+		},
+		"equal directories": {
+			pathA: "namespace/staging/app/db",
+			pathB: "namespace/staging/app/db",
+			out:   "No differences found between namespace/staging/app/db and namespace/staging/app/db.\n",
+		},
+		"secret versions": {
+			pathA: "namespace/staging/app/api_key:1",
+			pathB: "namespace/staging/app/api_key:2",
+			piped: true,
+			out:   "api_key\tdiffers\tversion 1\tversion 2\n",
+		},
+		"secrets": {
+			pathA:      "namespace/staging/app/api_key",
+			pathB:      "namespace/prod/app/api_key",
+			showValues: true,
+			piped:      true,
+			out:        "api_key\tdiffers\tkey2\tprodkey\n",
+		},
+		"directories yaml": {
+			pathA:  "namespace/staging/app/db",
+			pathB:  "namespace/prod/app",
+			format: formatYAML,
+			out: "- Path: api_key\n" +
+				"  Status: extra\n" +
+				"  Left: \"\"\n" +
+				"  Right: version 1\n" +
+				"- Path: password\n" +
+				"  Status: missing\n" +
+				"  Left: version 1\n" +
+				"  Right: \"\"\n",
+		},
+		"directory and secret": {
+			pathA: "namespace/staging/app",
+			pathB: "namespace/prod/app/api_key",
+			err:   ErrCannotDiffDirAndSecret,
+		},
+		"directory and nonexistent path": {
+			pathA: "namespace/staging/app",
+			pathB: "namespace/prod/app/db",
+			err:   ErrResourceNotFound(api.Path("namespace/prod/app/db")),
+		},
+		"nonexistent secret": {
+			pathA: "namespace/staging/app/api_key",
+			pathB: "namespace/prod/app/db_password",
+			err:   ErrResourceNotFound(api.Path("namespace/prod/app/db_password")),
+		},
+		"nonexistent repository": {
+			pathA: "namespace/prod",
+			pathB: "namespace/test",
+			err:   ErrResourceNotFound(api.Path("namespace/test")),
+		},
+		"invalid format": {
+			pathA:  "namespace/staging/app",
+			pathB:  "namespace/prod/app",
+			format: "xml",
+			err:    errNoSuchFormat("xml"),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			repo := newFakeRepo()
+			io := fakeui.NewIO(t)
+			io.Out.Piped = tc.piped
+
+			format := tc.format
+			if format == "" {
+				format = formatTable
+			}
+
+			cmd := DiffCommand{
+				pathA:      tc.pathA,
+				pathB:      tc.pathB,
+				showValues: tc.showValues,
+				format:     format,
+				io:         io,
+				newClient: func() (secrethub.ClientInterface, error) {
+					return repo.client(), nil
+				},
+				terminalWidth: func(int) (int, error) {
+					return 80, nil
+				},
+			}
+
+			err := cmd.Run()
+
+			assert.Equal(t, err, tc.err)
+			assert.Equal(t, io.Out.String(), tc.out)
+			if tc.read != nil {
+				assert.Equal(t, repo.read, tc.read)
+			}
+		})
+	}
+}
@@ -7,15 +7,17 @@ import (
 
 // EnvCommand handles operations regarding environment variables.
 type EnvCommand struct {
-	io        ui.IO
-	newClient newClientFunc
+	io              ui.IO
+	newClient       newClientFunc
+	credentialStore CredentialConfig
 }
 
 // NewEnvCommand creates a new EnvCommand.
-func NewEnvCommand(io ui.IO, newClient newClientFunc) *EnvCommand {
+func NewEnvCommand(io ui.IO, newClient newClientFunc, credentialStore CredentialConfig) *EnvCommand {
 	return &EnvCommand{
-		io:        io,
-		newClient: newClient,
+		io:              io,
+		newClient:       newClient,
+		credentialStore: credentialStore,
 	}
 }
 
@@ -24,5 +26,6 @@ func (cmd *EnvCommand) Register(r command.Registerer) {
 	clause := r.Command("env", "[BETA] Manage environment variables.").Hidden()
 	clause.HelpLong("This command is hidden because it is still in beta. Future versions may break.")
 	NewEnvReadCommand(cmd.io, cmd.newClient).Register(clause)
-	NewEnvListCommand(cmd.io).Register(clause)
+	NewEnvListCommand(cmd.io, cmd.newClient).Register(clause)
+	NewEnvExportCommand(cmd.io, cmd.newClient, cmd.credentialStore).Register(clause)
 }
@@ -0,0 +1,307 @@
+package secrethub
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/secrethub/secrethub-cli/internals/cli"
+	"github.com/secrethub/secrethub-cli/internals/cli/ui"
+	"github.com/secrethub/secrethub-cli/internals/cli/validation"
+	"github.com/secrethub/secrethub-cli/internals/secrethub/command"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Errors
+var (
+	ErrExportInvalidName    = errMain.Code("env_export_invalid_name").ErrorPref("cannot export %s in the %s format: names may only contain letters, digits and underscores and cannot start with a digit")
+	ErrExportInvalidK8sKey  = errMain.Code("env_export_invalid_k8s_key").ErrorPref("cannot export %s in a Kubernetes secret: names may only contain letters, digits, '-', '_' and '.'")
+	ErrExportMultilineValue = errMain.Code("env_export_multiline_value").ErrorPref("cannot export %s in the docker format: values cannot contain newlines")
+)
+
+const (
+	exportFormatSh        = "sh"
+	exportFormatFish      = "fish"
+	exportFormatDotEnv    = "dotenv"
+	exportFormatK8sSecret = "k8s-secret"
+	exportFormatDocker    = "docker"
+
+	defaultK8sSecretName = "secrethub-env"
+)
+
+// k8sSecretKeyPattern matches the keys that are allowed in the data of a Kubernetes secret.
+var k8sSecretKeyPattern = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+// EnvExportCommand is a command to write the environment of `secrethub run` in a format that can be used by other tools.
+type EnvExportCommand struct {
+	io            ui.IO
+	newClient     newClientFunc
+	environment   *environment
+	format        string
+	onlySecrets   bool
+	k8sSecretName string
+	cacheOptions  secretCacheOptions
+}
+
+// NewEnvExportCommand creates a new EnvExportCommand.
+func NewEnvExportCommand(io ui.IO, newClient newClientFunc, credentialStore CredentialConfig) *EnvExportCommand {
+	return &EnvExportCommand{
+		io:          io,
+		newClient:   newClient,
+		environment: newEnvironment(io, newClient),
+		cacheOptions: secretCacheOptions{
+			credentialStore: credentialStore,
+		},
+	}
+}
+
+// Register adds a CommandClause and it's args and flags to a Registerer.
+func (cmd *EnvExportCommand) Register(r command.Registerer) {
+	clause := r.Command("export", "[BETA] Write the environment variables populated by SecretHub in a format that can be used by other tools, e.g. `source <(secrethub env export --format sh)`.")
+	clause.HelpLong("The exported variables are the variables that run would set, except for the ones that are passed through unchanged from the current environment. " +
+		"Note that the exported secrets are written in plaintext. " +
+		"This command is hidden because it is still in beta. Future versions may break.")
+	clause.Flag("format", "The format in which to export the environment variables. Options are: sh, fish, dotenv, json, yaml, k8s-secret (a Kubernetes Secret manifest) and docker (for docker run --env-file).").
+		HintOptions(exportFormatSh, exportFormatFish, exportFormatDotEnv, formatJSON, formatYAML, exportFormatK8sSecret, exportFormatDocker).Default(exportFormatSh).StringVar(&cmd.format)
+	clause.Flag("only-secrets", "Only export the environment variables that contain a secret.").BoolVar(&cmd.onlySecrets)
+	clause.Flag("k8s-secret-name", "The name of the Kubernetes Secret when using the k8s-secret format.").Default(defaultK8sSecretName).StringVar(&cmd.k8sSecretName)
+
+	cmd.environment.register(clause)
+	cmd.cacheOptions.register(clause)
+
+	command.BindAction(clause, cmd.Run)
+}
+
+// Run executes the command.
+func (cmd *EnvExportCommand) Run() error {
+	write, err := cmd.writeFunc()
+	if err != nil {
+		return err
+	}
+
+	env, err := cmd.env()
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return write(cmd.io.Output(), names, env)
+}
+
+// env returns the resolved environment variables to export.
+func (cmd *EnvExportCommand) env() (map[string]string, error) {
+	env, sources, err := cmd.environment.sourcedEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]value)
+	for name, value := range env {
+		if sources[name][0].Type == envSourceOS {
+			continue
+		}
+		if cmd.onlySecrets && !value.containsSecret() {
+			continue
+		}
+		values[name] = value
+	}
+
+	paths, err := collectSecretPaths(values)
+	if err != nil {
+		return nil, err
+	}
+
+	cache, err := cmd.cacheOptions.open()
+	if err != nil {
+		return nil, err
+	}
+
+	prefetchReader := newPrefetchSecretReader(newCachedSecretReader(cmd.newClient, cache))
+	prefetchReader.Prefetch(paths)
+	secretReader := newFieldSecretReader(prefetchReader)
+
+	res := make(map[string]string, len(values))
+	for name, value := range values {
+		res[name], err = value.resolve(secretReader)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// exportWriteFunc writes the given environment variables, ordered by the given names.
+type exportWriteFunc func(w io.Writer, names []string, env map[string]string) error
+
+// writeFunc returns the function that writes the environment in the configured format.
+func (cmd *EnvExportCommand) writeFunc() (exportWriteFunc, error) {
+	switch cmd.format {
+	case exportFormatSh:
+		return writeShExport, nil
+	case exportFormatFish:
+		return writeFishExport, nil
+	case exportFormatDotEnv:
+		return writeDotEnvExport, nil
+	case formatJSON:
+		return writeJSONExport, nil
+	case formatYAML:
+		return writeYAMLExport, nil
+	case exportFormatK8sSecret:
+		return func(w io.Writer, names []string, env map[string]string) error {
+			return writeK8sSecretExport(w, cmd.k8sSecretName, env)
+		}, nil
+	case exportFormatDocker:
+		return writeDockerExport, nil
+	default:
+		return nil, errNoSuchFormat(cmd.format)
+	}
+}
+
+// writeShExport writes the environment as export statements for POSIX shells.
+// The values are single quoted, so the shell does not interpret any of their characters.
+func writeShExport(w io.Writer, names []string, env map[string]string) error {
+	for _, name := range names {
+		if !validation.IsEnvarNamePosix(name) {
+			return ErrExportInvalidName(name, exportFormatSh)
+		}
+
+		value := strings.ReplaceAll(env[name], "'", `'\''`)
+		_, err := fmt.Fprintf(w, "export %s='%s'\n", name, value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFishExport writes the environment as set statements for the fish shell.
+// In single quoted strings, fish only interprets escaped backslashes and single quotes.
+func writeFishExport(w io.Writer, names []string, env map[string]string) error {
+	replacer := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	for _, name := range names {
+		if !validation.IsEnvarNamePosix(name) {
+			return ErrExportInvalidName(name, exportFormatFish)
+		}
+
+		_, err := fmt.Fprintf(w, "set -gx %s '%s';\n", name, replacer.Replace(env[name]))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeDotEnvExport writes the environment as a .env file. Values are single quoted, so that
+// tools like docker compose and dotenv-expand do not interpolate variables in them. Values that
+// contain single quotes or newlines cannot be single quoted, so they are double quoted and escaped,
+// including the $ sign.
+func writeDotEnvExport(w io.Writer, names []string, env map[string]string) error {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "\n", `\n`, "\r", `\r`)
+	for _, name := range names {
+		if !validation.IsEnvarNamePosix(name) {
+			return ErrExportInvalidName(name, exportFormatDotEnv)
+		}
+
+		value := env[name]
+		format := "%s='%s'\n"
+		if strings.ContainsAny(value, "'\r\n") {
+			value = replacer.Replace(value)
+			format = "%s=\"%s\"\n"
+		}
+
+		_, err := fmt.Fprintf(w, format, name, value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeDockerExport writes the environment as a file for `docker run --env-file`.
+// Docker uses the remainder of the line as value without interpreting quotes,
+// so values are written as is and cannot contain newlines.
+func writeDockerExport(w io.Writer, names []string, env map[string]string) error {
+	for _, name := range names {
+		if strings.ContainsAny(env[name], "\r\n") {
+			return ErrExportMultilineValue(name)
+		}
+
+		_, err := fmt.Fprintf(w, "%s=%s\n", name, env[name])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeJSONExport writes the environment as a JSON object.
+func writeJSONExport(w io.Writer, _ []string, env map[string]string) error {
+	output, err := cli.PrettyJSON(env)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, output)
+	return err
+}
+
+// writeYAMLExport writes the environment as a YAML mapping.
+func writeYAMLExport(w io.Writer, _ []string, env map[string]string) error {
+	output, err := yaml.Marshal(env)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(output)
+	return err
+}
+
+// k8sSecret is a Kubernetes Secret manifest.
+type k8sSecret struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   k8sSecretMetadata `yaml:"metadata"`
+	Type       string            `yaml:"type"`
+	Data       map[string]string `yaml:"data"`
+}
+
+type k8sSecretMetadata struct {
+	Name string `yaml:"name"`
+}
+
+// writeK8sSecretExport writes the environment as a Kubernetes Secret manifest with the given name.
+// The values are base64 encoded, as required for the data of a Secret.
+func writeK8sSecretExport(w io.Writer, secretName string, env map[string]string) error {
+	data := make(map[string]string, len(env))
+	for name, value := range env {
+		if !k8sSecretKeyPattern.MatchString(name) {
+			return ErrExportInvalidK8sKey(name)
+		}
+		data[name] = base64.StdEncoding.EncodeToString([]byte(value))
+	}
+
+	output, err := yaml.Marshal(k8sSecret{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata: k8sSecretMetadata{
+			Name: secretName,
+		},
+		Type: "Opaque",
+		Data: data,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(output)
+	return err
+}
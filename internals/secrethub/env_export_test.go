@@ -0,0 +1,153 @@
+package secrethub
+
+import (
+	"os"
+	"testing"
+
+	"github.com/secrethub/secrethub-cli/internals/cli/ui/fakeui"
+
+	"github.com/secrethub/secrethub-go/internals/api"
+	"github.com/secrethub/secrethub-go/internals/assert"
+	"github.com/secrethub/secrethub-go/pkg/secrethub"
+	"github.com/secrethub/secrethub-go/pkg/secrethub/fakeclient"
+)
+
+func TestEnvExportCommand_Run(t *testing.T) {
+	cases := map[string]struct {
+		format      string
+		onlySecrets bool
+		offline     bool
+		envar       map[string]string
+		secret      string
+		out         string
+		err         error
+	}{
+		"sh": {
+			format: exportFormatSh,
+			out: "export DB_PASSWORD='p'\\''a\"s\\s$1'\n" +
+				"export GREETING='hello world'\n",
+		},
+		"fish": {
+			format: exportFormatFish,
+			out: "set -gx DB_PASSWORD 'p\\'a\"s\\\\s$1';\n" +
+				"set -gx GREETING 'hello world';\n",
+		},
+		"dotenv": {
+			format: exportFormatDotEnv,
+			secret: "p'a\"s\\s$1\nline 2",
+			out: "DB_PASSWORD=\"p'a\\\"s\\\\s\\$1\\nline 2\"\n" +
+				"GREETING='hello world'\n",
+		},
+		"dotenv single quoted": {
+			format: exportFormatDotEnv,
+			secret: "pa\"s\\s$1",
+			out: "DB_PASSWORD='pa\"s\\s$1'\n" +
+				"GREETING='hello world'\n",
+		},
+		"json": {
+			format: formatJSON,
+			out: "{\n" +
+				"    \"DB_PASSWORD\": \"p'a\\\"s\\\\s$1\",\n" +
+				"    \"GREETING\": \"hello world\"\n" +
+				"}\n",
+		},
+		"yaml": {
+			format: formatYAML,
+			out: "DB_PASSWORD: p'a\"s\\s$1\n" +
+				"GREETING: hello world\n",
+		},
+		"k8s-secret": {
+			format: exportFormatK8sSecret,
+			out: "apiVersion: v1\n" +
+				"kind: Secret\n" +
+				"metadata:\n" +
+				"  name: secrethub-env\n" +
+				"type: Opaque\n" +
+				"data:\n" +
+				"  DB_PASSWORD: cCdhInNccyQx\n" +
+				"  GREETING: aGVsbG8gd29ybGQ=\n",
+		},
+		"docker": {
+			format: exportFormatDocker,
+			out: "DB_PASSWORD=p'a\"s\\s$1\n" +
+				"GREETING=hello world\n",
+		},
+		"docker multiline value": {
+			format: exportFormatDocker,
+			secret: "line 1\nline 2",
+			err:    ErrExportMultilineValue("DB_PASSWORD"),
+		},
+		"only secrets": {
+			format:      exportFormatSh,
+			onlySecrets: true,
+			out:         "export DB_PASSWORD='p'\\''a\"s\\s$1'\n",
+		},
+		"invalid shell name": {
+			format: exportFormatSh,
+			envar:  map[string]string{"DB-PASSWORD": "namespace/repo/db_password"},
+			err:    ErrExportInvalidName("DB-PASSWORD", exportFormatSh),
+		},
+		"invalid dotenv name": {
+			format: exportFormatDotEnv,
+			envar:  map[string]string{"DB-PASSWORD": "namespace/repo/db_password"},
+			err:    ErrExportInvalidName("DB-PASSWORD", exportFormatDotEnv),
+		},
+		"offline without cache": {
+			format:  exportFormatSh,
+			offline: true,
+			err:     ErrOfflineWithoutCache,
+		},
+		"invalid format": {
+			format: "xml",
+			err:    errNoSuchFormat("xml"),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			io := fakeui.NewIO(t)
+
+			secret := tc.secret
+			if secret == "" {
+				secret = "p'a\"s\\s$1"
+			}
+
+			envar := tc.envar
+			if envar == nil {
+				envar = map[string]string{"DB_PASSWORD": "namespace/repo/db_password"}
+			}
+
+			cmd := EnvExportCommand{
+				io: io,
+				newClient: func() (secrethub.ClientInterface, error) {
+					return fakeclient.Client{
+						SecretService: &fakeclient.SecretService{
+							VersionService: &fakeclient.SecretVersionService{
+								GetWithDataFunc: func(path string) (*api.SecretVersion, error) {
+									return &api.SecretVersion{Data: []byte(secret)}, nil
+								},
+							},
+						},
+					}, nil
+				},
+				environment: &environment{
+					osEnv:           []string{"HOME=/root"},
+					envFiles:        []string{"app.env"},
+					readFile:        readFileFunc("app.env", "GREETING=hello world"),
+					osStat:          func(string) (os.FileInfo, error) { return nil, os.ErrNotExist },
+					templateVersion: "2",
+					envar:           envar,
+				},
+				format:        tc.format,
+				onlySecrets:   tc.onlySecrets,
+				k8sSecretName: defaultK8sSecretName,
+				cacheOptions:  secretCacheOptions{offline: tc.offline},
+			}
+
+			err := cmd.Run()
+
+			assert.Equal(t, err, tc.err)
+			assert.Equal(t, io.Out.String(), tc.out)
+		})
+	}
+}
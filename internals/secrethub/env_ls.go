@@ -2,7 +2,11 @@ package secrethub
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
 
+	"github.com/secrethub/secrethub-cli/internals/cli"
 	"github.com/secrethub/secrethub-cli/internals/cli/ui"
 	"github.com/secrethub/secrethub-cli/internals/secrethub/command"
 )
@@ -11,21 +15,26 @@ import (
 type EnvListCommand struct {
 	io          ui.IO
 	environment *environment
+	format      string
+	all         bool
 }
 
 // NewEnvListCommand creates a new EnvListCommand.
-func NewEnvListCommand(io ui.IO) *EnvListCommand {
+func NewEnvListCommand(io ui.IO, newClient newClientFunc) *EnvListCommand {
 	return &EnvListCommand{
 		io:          io,
-		environment: newEnvironment(io),
+		environment: newEnvironment(io, newClient),
 	}
 }
 
 // Register adds a CommandClause and it's args and flags to a Registerer.
 func (cmd *EnvListCommand) Register(r command.Registerer) {
-	clause := r.Command("ls", "[BETA] List environment variable names that will be populated with secrets.")
-	clause.HelpLong("This command is hidden because it is still in beta. Future versions may break.")
+	clause := r.Command("ls", "[BETA] List the environment variables that will be populated with secrets and where they are sourced from.")
+	clause.HelpLong("For every variable, the source that sets its value is shown, together with the sources it overrides and whether it contains a secret. " +
+		"This command is hidden because it is still in beta. Future versions may break.")
 	clause.Alias("list")
+	clause.Flag("format", "The format in which to output the environment variables. Options are: table and json.").HintOptions(formatTable, formatJSON).Default(formatTable).StringVar(&cmd.format)
+	clause.Flag("all", "Also list the environment variables that do not contain a secret.").BoolVar(&cmd.all)
 
 	cmd.environment.register(clause)
 
@@ -34,18 +43,69 @@ func (cmd *EnvListCommand) Register(r command.Registerer) {
 
 // Run executes the command.
 func (cmd *EnvListCommand) Run() error {
-	env, err := cmd.environment.env()
+	if cmd.format != formatTable && cmd.format != formatJSON {
+		return errNoSuchFormat(cmd.format)
+	}
+
+	env, sources, err := cmd.environment.sourcedEnv()
 	if err != nil {
 		return err
 	}
 
-	for key, value := range env {
-		// For now only environment variables in which a secret is loaded are printed.
-		// TODO: Make this behavior configurable.
-		if value.containsSecret() {
-			fmt.Fprintln(cmd.io.Output(), key)
+	vars := []envVarOutput{}
+	for name, value := range env {
+		if !cmd.all && !value.containsSecret() {
+			continue
+		}
+
+		vars = append(vars, envVarOutput{
+			Name:           name,
+			Source:         sources[name][0],
+			Overrides:      sources[name][1:],
+			ContainsSecret: value.containsSecret(),
+		})
+	}
+	sort.Slice(vars, func(i, j int) bool {
+		return vars[i].Name < vars[j].Name
+	})
+
+	if cmd.format == formatJSON {
+		output, err := cli.PrettyJSON(vars)
+		if err != nil {
+			return err
 		}
+
+		fmt.Fprintln(cmd.io.Output(), output)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(cmd.io.Output(), 0, 2, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSOURCE\tOVERRIDES\tSECRET")
+	for _, v := range vars {
+		fmt.Fprintln(w, strings.Join(v.row(), "\t"))
+	}
+	return w.Flush()
+}
+
+// envVarOutput is an environment variable as it is listed by the env ls command.
+type envVarOutput struct {
+	Name           string
+	Source         envVarSource
+	Overrides      []envVarSource
+	ContainsSecret bool
+}
+
+// row returns the columns of the table row of the environment variable.
+func (v envVarOutput) row() []string {
+	overrides := make([]string, len(v.Overrides))
+	for i, source := range v.Overrides {
+		overrides[i] = source.String()
+	}
+
+	containsSecret := "no"
+	if v.ContainsSecret {
+		containsSecret = "yes"
 	}
 
-	return nil
+	return []string{v.Name, v.Source.String(), strings.Join(overrides, ", "), containsSecret}
 }
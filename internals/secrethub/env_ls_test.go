@@ -0,0 +1,114 @@
+package secrethub
+
+import (
+	"os"
+	"testing"
+
+	"github.com/secrethub/secrethub-cli/internals/cli/ui/fakeui"
+
+	"github.com/secrethub/secrethub-go/internals/assert"
+)
+
+func TestEnvListCommand_Run(t *testing.T) {
+	files := map[string]string{
+		"base.env":    "DB={{ namespace/repo/base_db }}\nAPP=plain",
+		"overlay.env": "API_KEY={{ namespace/repo/api_key }}",
+	}
+
+	cases := map[string]struct {
+		format string
+		all    bool
+		out    string
+		err    error
+	}{
+		"table": {
+			format: formatTable,
+			out: "NAME     SOURCE                           OVERRIDES                   SECRET\n" +
+				"API_KEY  --envar namespace/repo/flag_key  overlay.env:1               yes\n" +
+				"DB       secrethub://namespace/repo/db    base.env:1, os environment  yes\n",
+		},
+		"all": {
+			format: formatTable,
+			all:    true,
+			out: "NAME     SOURCE                           OVERRIDES                   SECRET\n" +
+				"API_KEY  --envar namespace/repo/flag_key  overlay.env:1               yes\n" +
+				"APP      base.env:2                                                   no\n" +
+				"DB       secrethub://namespace/repo/db    base.env:1, os environment  yes\n" +
+				"HOME     os environment                                               no\n",
+		},
+		"json": {
+			format: formatJSON,
+			out: `[
+    {
+        "Name": "API_KEY",
+        "Source": {
+            "Type": "envar",
+            "Path": "namespace/repo/flag_key"
+        },
+        "Overrides": [
+            {
+                "Type": "env-file",
+                "File": "overlay.env",
+                "Line": 1
+            }
+        ],
+        "ContainsSecret": true
+    },
+    {
+        "Name": "DB",
+        "Source": {
+            "Type": "reference",
+            "Path": "namespace/repo/db"
+        },
+        "Overrides": [
+            {
+                "Type": "env-file",
+                "File": "base.env",
+                "Line": 1
+            },
+            {
+                "Type": "os"
+            }
+        ],
+        "ContainsSecret": true
+    }
+]
+`,
+		},
+		"invalid format": {
+			format: "yaml",
+			err:    errNoSuchFormat("yaml"),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			io := fakeui.NewIO(t)
+
+			cmd := EnvListCommand{
+				io: io,
+				environment: &environment{
+					osEnv:    []string{"HOME=/root", "DB=secrethub://namespace/repo/db"},
+					envFiles: []string{"base.env", "overlay.env"},
+					envar:    map[string]string{"API_KEY": "namespace/repo/flag_key"},
+					readFile: func(filename string) ([]byte, error) {
+						content, ok := files[filename]
+						if !ok {
+							return nil, os.ErrNotExist
+						}
+						return []byte(content), nil
+					},
+					templateVersion:              "2",
+					dontPromptMissingTemplateVar: true,
+				},
+				format: tc.format,
+				all:    tc.all,
+			}
+
+			err := cmd.Run()
+
+			assert.Equal(t, err, tc.err)
+			assert.Equal(t, io.Out.String(), tc.out)
+		})
+	}
+}
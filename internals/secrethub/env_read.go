@@ -20,7 +20,7 @@ func NewEnvReadCommand(io ui.IO, newClient newClientFunc) *EnvReadCommand {
 	return &EnvReadCommand{
 		io:          io,
 		newClient:   newClient,
-		environment: newEnvironment(io),
+		environment: newEnvironment(io, newClient),
 	}
 }
 
@@ -47,9 +47,16 @@ func (cmd *EnvReadCommand) Run() error {
 		return fmt.Errorf("no environment variable with that key is set")
 	}
 
-	secretReader := newSecretReader(cmd.newClient)
+	collector := newSecretPathCollector()
+	_, err = value.resolve(collector)
+	if err != nil {
+		return err
+	}
+
+	secretReader := newPrefetchSecretReader(newSecretReader(cmd.newClient))
+	secretReader.Prefetch(collector.Paths())
 
-	res, err := value.resolve(secretReader)
+	res, err := value.resolve(newFieldSecretReader(secretReader))
 	if err != nil {
 		return err
 	}
@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"unicode"
 
@@ -24,20 +25,24 @@ import (
 
 type environment struct {
 	io                           ui.IO
+	newClient                    newClientFunc
 	osEnv                        []string
 	readFile                     func(filename string) ([]byte, error)
 	osStat                       func(filename string) (os.FileInfo, error)
 	envar                        map[string]string
-	envFile                      string
+	envFiles                     []string
 	templateVars                 map[string]string
 	templateVersion              string
 	dontPromptMissingTemplateVar bool
 	secretsEnvDir                string
+	secretDirs                   []string
+	secretDirPrefix              string
 }
 
-func newEnvironment(io ui.IO) *environment {
+func newEnvironment(io ui.IO, newClient newClientFunc) *environment {
 	return &environment{
 		io:           io,
+		newClient:    newClient,
 		osEnv:        os.Environ(),
 		readFile:     ioutil.ReadFile,
 		osStat:       os.Stat,
@@ -47,9 +52,11 @@ func newEnvironment(io ui.IO) *environment {
 }
 
 func (env *environment) register(clause *cli.CommandClause) {
-	clause.Flag("envar", "Source an environment variable from a secret at a given path with `NAME=<path>`").Short('e').StringMapVar(&env.envar)
-	clause.Flag("env-file", "The path to a file with environment variable mappings of the form `NAME=value`. Template syntax can be used to inject secrets.").StringVar(&env.envFile)
-	clause.Flag("template", "").Hidden().StringVar(&env.envFile)
+	clause.Flag("envar", "Source an environment variable from a secret at a given path with `NAME=<path>`. A field of a JSON or YAML secret can be selected with `NAME=<path>#.<field>`, e.g. `DB_PASS=myorg/app/db#.password`.").Short('e').StringMapVar(&env.envar)
+	clause.Flag("env-file", "The path to a file with environment variable mappings of the form `NAME=value`. Template syntax can be used to inject secrets. Can be used multiple times to layer files, in which case variables in later files take precedence.").StringsVar(&env.envFiles)
+	clause.Flag("env-dir", "Source an environment variable from every secret in a directory and its subdirectories (<namespace>/<repo>[/<dir>]). The name of the variable is the path of the secret relative to the directory in uppercase, with /, - and . replaced by _. Can be used multiple times, in which case later directories take precedence.").StringsVar(&env.secretDirs)
+	clause.Flag("env-dir-prefix", "A prefix to add to the names of the environment variables sourced with --env-dir, e.g. APP_.").StringVar(&env.secretDirPrefix)
+	clause.Flag("template", "").Hidden().StringsVar(&env.envFiles)
 	clause.Flag("var", "Define the value for a template variable with `VAR=VALUE`, e.g. --var env=prod").Short('v').StringMapVar(&env.templateVars)
 	clause.Flag("template-version", "The template syntax version to be used. The options are v1, v2, latest or auto to automatically detect the version.").Default("auto").StringVar(&env.templateVersion)
 	clause.Flag("no-prompt", "Do not prompt when a template variable is missing and return an error instead.").BoolVar(&env.dontPromptMissingTemplateVar)
@@ -57,11 +64,45 @@ func (env *environment) register(clause *cli.CommandClause) {
 }
 
 func (env *environment) env() (map[string]value, error) {
+	values, _, err := env.sourcedEnv()
+	return values, err
+}
+
+// sourcedEnv returns the environment variables together with the sources of every variable,
+// starting with the source that takes precedence, followed by the sources it overrides.
+func (env *environment) sourcedEnv() (map[string]value, map[string][]envVarSource, error) {
+	layers, err := env.layers()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values := make(map[string]value)
+	sources := make(map[string][]envVarSource)
+	for _, layer := range layers {
+		layerValues, err := layer.source.env()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for name, value := range layerValues {
+			values[name] = value
+			sources[name] = append([]envVarSource{layer.describe(value)}, sources[name]...)
+		}
+	}
+
+	return values, sources, nil
+}
+
+// layers returns the sources of the environment, ordered from the lowest to the highest precedence.
+func (env *environment) layers() ([]envLayer, error) {
 	osEnvMap, _ := parseKeyValueStringsToMap(env.osEnv)
-	var sources []EnvSource
+	var layers []envLayer
 
-	sources = append(sources, &osEnv{
-		osEnv: osEnvMap,
+	layers = append(layers, envLayer{
+		source: &osEnv{
+			osEnv: osEnvMap,
+		},
+		sourceType: envSourceOS,
 	})
 
 	// .secretsenv dir (for backwards compatibility)
@@ -72,20 +113,30 @@ func (env *environment) env() (map[string]value, error) {
 		if err != nil {
 			return nil, err
 		}
-		sources = append(sources, dirSource)
+		layers = append(layers, envLayer{source: dirSource, sourceType: envSourceSecretsEnvDir, file: envDir})
+	}
+
+	// --env-dir flag
+	for _, path := range env.secretDirs {
+		secretDirSource, err := newSecretDirEnv(env.newClient, path, env.secretDirPrefix)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, envLayer{source: secretDirSource, sourceType: envSourceSecretDir})
 	}
 
 	//secrethub.env file
-	if env.envFile == "" {
+	envFiles := env.envFiles
+	if len(envFiles) == 0 {
 		_, err := env.osStat(defaultEnvFile)
 		if err == nil {
-			env.envFile = defaultEnvFile
+			envFiles = []string{defaultEnvFile}
 		} else if !os.IsNotExist(err) {
 			return nil, ErrReadDefaultEnvFile(defaultEnvFile, err)
 		}
 	}
 
-	if env.envFile != "" {
+	if len(envFiles) > 0 {
 		templateVariableReader, err := newVariableReader(osEnvMap, env.templateVars)
 		if err != nil {
 			return nil, err
@@ -95,26 +146,28 @@ func (env *environment) env() (map[string]value, error) {
 			templateVariableReader = newPromptMissingVariableReader(templateVariableReader, env.io)
 		}
 
-		raw, err := env.readFile(env.envFile)
-		if err != nil {
-			return nil, ErrCannotReadFile(env.envFile, err)
-		}
+		for _, path := range envFiles {
+			raw, err := env.readFile(path)
+			if err != nil {
+				return nil, ErrCannotReadFile(path, err)
+			}
 
-		parser, err := getTemplateParser(raw, env.templateVersion)
-		if err != nil {
-			return nil, err
-		}
+			parser, err := getTemplateParser(raw, env.templateVersion)
+			if err != nil {
+				return nil, err
+			}
 
-		envFile, err := ReadEnvFile(env.envFile, bytes.NewReader(raw), templateVariableReader, parser)
-		if err != nil {
-			return nil, err
+			envFile, err := ReadEnvFile(path, bytes.NewReader(raw), templateVariableReader, parser)
+			if err != nil {
+				return nil, err
+			}
+			layers = append(layers, envLayer{source: envFile, sourceType: envSourceFile, file: path})
 		}
-		sources = append(sources, envFile)
 	}
 
 	// secret references (secrethub://)
 	referenceEnv := newReferenceEnv(osEnvMap)
-	sources = append(sources, referenceEnv)
+	layers = append(layers, envLayer{source: referenceEnv, sourceType: envSourceReference})
 
 	// --envar flag
 	// TODO: Validate the flags when parsing by implementing the Flag interface for EnvFlags.
@@ -122,28 +175,74 @@ func (env *environment) env() (map[string]value, error) {
 	if err != nil {
 		return nil, err
 	}
-	sources = append(sources, flagEnv)
+	layers = append(layers, envLayer{source: flagEnv, sourceType: envSourceFlag})
 
-	envs := make([]map[string]value, len(sources))
-	for _, source := range sources {
-		env, err := source.env()
-		if err != nil {
-			return nil, err
-		}
-		envs = append(envs, env)
-	}
+	return layers, nil
+}
+
+// Types of sources of environment variables.
+const (
+	envSourceOS            = "os"
+	envSourceSecretsEnvDir = "secretsenv"
+	envSourceSecretDir     = "env-dir"
+	envSourceFile          = "env-file"
+	envSourceReference     = "reference"
+	envSourceFlag          = "envar"
+)
 
-	return mergeEnvs(envs...), nil
+// envLayer is a source of environment variables, together with the type
+// and location of the source to describe where its variables are defined.
+type envLayer struct {
+	source     EnvSource
+	sourceType string
+	file       string
 }
 
-func mergeEnvs(envs ...map[string]value) map[string]value {
-	result := map[string]value{}
-	for _, env := range envs {
-		for name, value := range env {
-			result[name] = value
+// describe returns the source of the given value defined by the layer.
+func (l envLayer) describe(v value) envVarSource {
+	source := envVarSource{
+		Type: l.sourceType,
+		File: l.file,
+	}
+
+	switch v := v.(type) {
+	case *secretValue:
+		source.Path = v.path
+	case *templateValue:
+		if v.lineNo > 0 {
+			source.Line = v.lineNo
 		}
 	}
-	return result
+	return source
+}
+
+// envVarSource describes where an environment variable is defined.
+type envVarSource struct {
+	Type string
+	File string `json:",omitempty"`
+	Line int    `json:",omitempty"`
+	Path string `json:",omitempty"`
+}
+
+// String returns a human readable description of the source.
+func (s envVarSource) String() string {
+	switch s.Type {
+	case envSourceOS:
+		return "os environment"
+	case envSourceSecretsEnvDir:
+		return s.File
+	case envSourceSecretDir, envSourceFlag:
+		return "--" + s.Type + " " + s.Path
+	case envSourceFile:
+		if s.Line > 0 {
+			return s.File + ":" + strconv.Itoa(s.Line)
+		}
+		return s.File
+	case envSourceReference:
+		return secretReferencePrefix + s.Path
+	default:
+		return s.Type
+	}
 }
 
 // EnvSource defines a method of reading environment variables from a source.
@@ -184,10 +283,18 @@ func NewEnvFlags(flags map[string]string) (EnvFlags, error) {
 			return nil, err
 		}
 
+		path, field := tpl.SplitSecretField(path)
 		err = api.ValidateSecretPath(path)
 		if err != nil {
 			return nil, err
 		}
+
+		if field != "" {
+			_, err = tpl.ParseSecretField(field)
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	return flags, nil
@@ -233,6 +340,70 @@ func (env *referenceEnv) env() (map[string]value, error) {
 	return envVarsWithSecrets, nil
 }
 
+// secretDirEnv is an environment with the secrets in a SecretHub directory
+// and its subdirectories.
+type secretDirEnv struct {
+	newClient newClientFunc
+	path      api.DirPath
+	prefix    string
+}
+
+// newSecretDirEnv returns an environment with the secrets in the directory at the given path,
+// with the names of the environment variables prefixed with the given prefix.
+func newSecretDirEnv(newClient newClientFunc, path string, prefix string) (*secretDirEnv, error) {
+	dirPath, err := api.NewDirPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &secretDirEnv{
+		newClient: newClient,
+		path:      dirPath,
+		prefix:    prefix,
+	}, nil
+}
+
+// Env returns a map of environment variables set to the secrets in the directory.
+func (env *secretDirEnv) env() (map[string]value, error) {
+	client, err := env.newClient()
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := client.Dirs().GetTree(env.path.Value(), -1, false)
+	if err != nil {
+		return nil, err
+	}
+
+	_, secretPaths := walkDir(tree.RootDir)
+
+	result := make(map[string]value, len(secretPaths))
+	sources := make(map[string]string, len(secretPaths))
+	for _, secretPath := range secretPaths {
+		name := env.prefix + secretDirEnvarName(secretPath)
+		err = validation.ValidateEnvarName(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if other, exists := sources[name]; exists {
+			return nil, ErrEnvDirNameConflict(other, secretPath, env.path, name)
+		}
+		sources[name] = secretPath
+
+		result[name] = newSecretValue(api.JoinPaths(env.path.Value(), secretPath))
+	}
+	return result, nil
+}
+
+// secretDirEnvarName returns the name of the environment variable for the secret
+// at the given path relative to the directory it is sourced from.
+func secretDirEnvarName(secretPath string) string {
+	return strings.ToUpper(secretDirEnvarNameReplacer.Replace(secretPath))
+}
+
+var secretDirEnvarNameReplacer = strings.NewReplacer("/", "_", "-", "_", ".", "_")
+
 type envDirSecretValue struct {
 	value string
 }
@@ -283,6 +454,7 @@ func (dir EnvDir) env() (map[string]value, error) {
 
 type templateValue struct {
 	filepath  string
+	lineNo    int
 	template  tpl.Template
 	varReader tpl.VariableReader
 }
@@ -299,9 +471,10 @@ func (v *templateValue) containsSecret() bool {
 	return v.template.ContainsSecrets()
 }
 
-func newTemplateValue(filepath string, template tpl.Template, varReader tpl.VariableReader) value {
+func newTemplateValue(filepath string, lineNo int, template tpl.Template, varReader tpl.VariableReader) value {
 	return &templateValue{
 		filepath:  filepath,
+		lineNo:    lineNo,
 		template:  template,
 		varReader: varReader,
 	}
@@ -334,7 +507,7 @@ func (t envTemplate) env() (map[string]value, error) {
 			return nil, templateError(tpls.lineNo, err)
 		}
 
-		value := newTemplateValue(t.filepath, tpls.value, t.templateVarReader)
+		value := newTemplateValue(t.filepath, tpls.lineNo, tpls.value, t.templateVarReader)
 
 		result[key] = value
 	}
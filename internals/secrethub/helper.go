@@ -2,6 +2,7 @@ package secrethub
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/fatih/color"
 	"github.com/secrethub/secrethub-go/internals/api"
@@ -28,3 +29,23 @@ func colorizeByStatus(status string, msg interface{}) interface{} {
 		return msg
 	}
 }
+
+// walkDir returns the paths of all directories and secrets in the given directory
+// and its subdirectories, relative to the given directory and sorted by name.
+func walkDir(dir *api.Dir) (dirPaths []string, secretPaths []string) {
+	walkDirRecursively(dir, "", &dirPaths, &secretPaths)
+	sort.Strings(dirPaths)
+	sort.Strings(secretPaths)
+	return dirPaths, secretPaths
+}
+
+func walkDirRecursively(dir *api.Dir, prefix string, dirPaths *[]string, secretPaths *[]string) {
+	for _, secret := range dir.Secrets {
+		*secretPaths = append(*secretPaths, prefix+secret.Name)
+	}
+
+	for _, sub := range dir.SubDirs {
+		*dirPaths = append(*dirPaths, prefix+sub.Name)
+		walkDirRecursively(sub, prefix+sub.Name+"/", dirPaths, secretPaths)
+	}
+}
@@ -39,10 +39,11 @@ type InjectCommand struct {
 	templateVars                  map[string]string
 	templateVersion               string
 	dontPromptMissingTemplateVars bool
+	cacheOptions                  secretCacheOptions
 }
 
 // NewInjectCommand creates a new InjectCommand.
-func NewInjectCommand(io ui.IO, newClient newClientFunc) *InjectCommand {
+func NewInjectCommand(io ui.IO, newClient newClientFunc, credentialStore CredentialConfig) *InjectCommand {
 	return &InjectCommand{
 		clipper:             clip.NewClipboard(),
 		osEnv:               os.Environ(),
@@ -50,6 +51,9 @@ func NewInjectCommand(io ui.IO, newClient newClientFunc) *InjectCommand {
 		io:                  io,
 		newClient:           newClient,
 		templateVars:        make(map[string]string),
+		cacheOptions: secretCacheOptions{
+			credentialStore: credentialStore,
+		},
 	}
 }
 
@@ -72,6 +76,7 @@ func (cmd *InjectCommand) Register(r command.Registerer) {
 	clause.Flag("template-version", "The template syntax version to be used. The options are v1, v2, latest or auto to automatically detect the version.").Default("auto").StringVar(&cmd.templateVersion)
 	clause.Flag("no-prompt", "Do not prompt when a template variable is missing and return an error instead.").BoolVar(&cmd.dontPromptMissingTemplateVars)
 	clause.Flag("force", "Overwrite the output file if it already exists, without prompting for confirmation. This flag is ignored if no --out-file is supplied.").Short('f').BoolVar(&cmd.force)
+	cmd.cacheOptions.register(clause)
 
 	command.BindAction(clause, cmd.Run)
 }
@@ -123,7 +128,21 @@ func (cmd *InjectCommand) Run() error {
 		return err
 	}
 
-	injected, err := template.Evaluate(templateVariableReader, newSecretReader(cmd.newClient))
+	collector := newSecretPathCollector()
+	_, err = template.Evaluate(templateVariableReader, collector)
+	if err != nil {
+		return err
+	}
+
+	cache, err := cmd.cacheOptions.open()
+	if err != nil {
+		return err
+	}
+
+	secretReader := newPrefetchSecretReader(newCachedSecretReader(cmd.newClient, cache))
+	secretReader.Prefetch(collector.Paths())
+
+	injected, err := template.Evaluate(templateVariableReader, newFieldSecretReader(secretReader))
 	if err != nil {
 		return err
 	}
@@ -19,6 +19,7 @@ type LsCommand struct {
 	path          api.Path
 	quiet         bool
 	useTimestamps bool
+	format        listFormat
 	io            ui.IO
 	newClient     newClientFunc
 }
@@ -38,18 +39,20 @@ func (cmd *LsCommand) Register(r command.Registerer) {
 	clause.Arg("path", "The path to list contents of").SetValue(&cmd.path)
 	clause.Flag("quiet", "Only print paths.").Short('q').BoolVar(&cmd.quiet)
 	registerTimestampFlag(clause).BoolVar(&cmd.useTimestamps)
+	cmd.format.registerWithTemplate(clause)
 
 	command.BindAction(clause, cmd.Run)
 }
 
 // Run lists a repo, secret or namespace.
 func (cmd *LsCommand) Run() error {
-	timeFormatter := NewTimeFormatter(cmd.useTimestamps)
+	timeFormatter := NewTimeFormatter(cmd.useTimestamps || !cmd.format.isTable())
 
 	if cmd.path == "" {
 		repoLSCommand := NewRepoLSCommand(cmd.io, cmd.newClient)
 		repoLSCommand.quiet = cmd.quiet
 		repoLSCommand.useTimestamps = cmd.useTimestamps
+		repoLSCommand.format = cmd.format
 		return repoLSCommand.Run()
 	}
 
@@ -71,7 +74,7 @@ func (cmd *LsCommand) Run() error {
 			return err
 		}
 
-		err = printVersions(cmd.io.Output(), cmd.quiet, timeFormatter, version)
+		err = printVersions(cmd.io.Output(), cmd.quiet, cmd.format, timeFormatter, version)
 		if err != nil {
 			return err
 		}
@@ -88,7 +91,7 @@ func (cmd *LsCommand) Run() error {
 		} else if err != nil && !api.IsErrNotFound(err) {
 			return err
 		} else if err == nil {
-			err = printDir(cmd.io.Output(), cmd.quiet, dirFS.RootDir, timeFormatter)
+			err = printDir(cmd.io.Output(), cmd.quiet, cmd.format, dirFS.RootDir, timeFormatter)
 			if err != nil {
 				return err
 			}
@@ -106,7 +109,7 @@ func (cmd *LsCommand) Run() error {
 			return err
 		}
 
-		err = printVersions(cmd.io.Output(), cmd.quiet, timeFormatter, versions...)
+		err = printVersions(cmd.io.Output(), cmd.quiet, cmd.format, timeFormatter, versions...)
 		if err != nil {
 			return err
 		}
@@ -120,6 +123,7 @@ func (cmd *LsCommand) Run() error {
 			workspace:     workspace,
 			useTimestamps: cmd.useTimestamps,
 			quiet:         cmd.quiet,
+			format:        cmd.format,
 			io:            cmd.io,
 			newClient:     cmd.newClient,
 		}
@@ -132,12 +136,23 @@ func (cmd *LsCommand) Run() error {
 	return errio.UnexpectedError(errors.New("invalid path argument"))
 }
 
-// printVersions prints out secret versions in long or short format.
-func printVersions(w io.Writer, quiet bool, timeFormatter TimeFormatter, versions ...*api.SecretVersion) error {
+// printVersions prints out secret versions in long, short or the given machine readable format.
+func printVersions(w io.Writer, quiet bool, format listFormat, timeFormatter TimeFormatter, versions ...*api.SecretVersion) error {
 	if quiet {
 		for _, version := range versions {
 			fmt.Fprintf(w, "%s\n", version.Name())
 		}
+	} else if !format.isTable() {
+		formatter, err := format.newItemFormatter(w, "name", "status", "created at")
+		if err != nil {
+			return err
+		}
+		for _, version := range versions {
+			err = formatter.Write(version, []string{version.Name(), version.Status, timeFormatter.Format(version.CreatedAt.Local())})
+			if err != nil {
+				return err
+			}
+		}
 	} else {
 		w := tabwriter.NewWriter(w, 0, 2, 2, ' ', 0)
 		fmt.Fprintf(w, "%s\t%s\t%s\n", "NAME", "STATUS", "CREATED")
@@ -152,8 +167,8 @@ func printVersions(w io.Writer, quiet bool, timeFormatter TimeFormatter, version
 	return nil
 }
 
-// printDir prints out directory contents in long or short format.
-func printDir(w io.Writer, quiet bool, dir *api.Dir, timeFormatter TimeFormatter) error {
+// printDir prints out directory contents in long, short or the given machine readable format.
+func printDir(w io.Writer, quiet bool, format listFormat, dir *api.Dir, timeFormatter TimeFormatter) error {
 	sort.Sort(api.SortDirByName(dir.SubDirs))
 	sort.Sort(api.SortSecretByName(dir.Secrets))
 
@@ -164,6 +179,23 @@ func printDir(w io.Writer, quiet bool, dir *api.Dir, timeFormatter TimeFormatter
 		for _, secret := range dir.Secrets {
 			fmt.Fprintf(w, "%s\n", secret.Name)
 		}
+	} else if !format.isTable() {
+		formatter, err := format.newItemFormatter(w, "name", "type", "status", "created at")
+		if err != nil {
+			return err
+		}
+		for _, dir := range dir.SubDirs {
+			err = formatter.Write(dir, []string{dir.Name, "dir", dir.Status, timeFormatter.Format(dir.CreatedAt.Local())})
+			if err != nil {
+				return err
+			}
+		}
+		for _, secret := range dir.Secrets {
+			err = formatter.Write(secret, []string{secret.Name, "secret", secret.Status, timeFormatter.Format(secret.CreatedAt.Local())})
+			if err != nil {
+				return err
+			}
+		}
 	} else {
 		tw := tabwriter.NewWriter(w, 0, 2, 2, ' ', 0)
 		fmt.Fprintf(tw, "%s\t%s\t%s\n", "NAME", "STATUS", "CREATED")
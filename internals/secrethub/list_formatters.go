@@ -1,16 +1,154 @@
 package secrethub
 
 import (
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
 	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Errors
+var (
+	ErrInvalidFormatTemplate = errMain.Code("invalid_format_template").ErrorPref("invalid format template: %s")
+)
+
+const (
+	formatYAML = "yaml"
+	formatCSV  = "csv"
 )
 
 type listFormatter interface {
 	Write([]string) error
 }
 
+// listFormat is the format in which a list command writes its output, as set with the --format flag.
+// The zero value is the table format.
+type listFormat string
+
+func (f *listFormat) register(r FlagRegisterer) {
+	r.Flag("format", "The format in which to output the list. Options are: table, json, yaml and csv. The json, yaml and csv formats have stable field names, always show timestamps and are meant to be parsed by scripts.").HintOptions(formatTable, formatJSON, formatYAML, formatCSV).Default(formatTable).StringVar((*string)(f))
+}
+
+// registerWithTemplate registers the --format flag for list commands that also support Go templates.
+func (f *listFormat) registerWithTemplate(r FlagRegisterer) {
+	r.Flag("format", "The format in which to output the list. Options are: table, json, yaml, csv or a Go template that is executed for every item, e.g. '{{.Name}}'. Besides the built-in template functions, the json, upper, lower, time (RFC3339 timestamp) and ago (human readable duration) functions can be used. The json, yaml and csv formats have stable field names, always show timestamps and are meant to be parsed by scripts.").HintOptions(formatTable, formatJSON, formatYAML, formatCSV).Default(formatTable).StringVar((*string)(f))
+}
+
+// isTable returns whether the list should be written as a table meant to be read by humans.
+func (f listFormat) isTable() bool {
+	return f == "" || f == formatTable
+}
+
+// isTemplate returns whether the format is a Go template.
+func (f listFormat) isTemplate() bool {
+	return strings.Contains(string(f), "{{")
+}
+
+// templateFuncs are the functions that can be used in format templates, next to the built-in functions.
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		out, err := json.Marshal(v)
+		return string(out), err
+	},
+	// upper and lower accept any value, so they can also be used on named string types, e.g. api.AuditAction.
+	"upper": func(v interface{}) string {
+		return strings.ToUpper(fmt.Sprint(v))
+	},
+	"lower": func(v interface{}) string {
+		return strings.ToLower(fmt.Sprint(v))
+	},
+	"time": func(t time.Time) string {
+		return NewTimestampFormatter().Format(t.Local())
+	},
+	"ago": func(t time.Time) string {
+		return NewTimeFormatter(false).Format(t)
+	},
+}
+
+// newTemplateFormatter returns a formatter that writes every item of the list
+// by executing the format as a Go template with the item as data.
+func (f listFormat) newTemplateFormatter(writer io.Writer) (*templateFormatter, error) {
+	tmpl, err := template.New("format").Funcs(templateFuncs).Parse(string(f))
+	if err != nil {
+		return nil, ErrInvalidFormatTemplate(err)
+	}
+	return &templateFormatter{
+		writer:   writer,
+		template: tmpl,
+	}, nil
+}
+
+type templateFormatter struct {
+	writer   io.Writer
+	template *template.Template
+}
+
+// Write executes the template with the given item as data and writes the result, followed by a newline.
+func (f *templateFormatter) Write(item interface{}) error {
+	var buf bytes.Buffer
+	err := f.template.Execute(&buf, item)
+	if err != nil {
+		return ErrInvalidFormatTemplate(err)
+	}
+
+	buf.WriteByte('\n')
+	_, err = f.writer.Write(buf.Bytes())
+	return err
+}
+
+// itemFormatter writes the items of a list in a format other than the table format.
+type itemFormatter struct {
+	template *templateFormatter
+	list     listFormatter
+}
+
+// newItemFormatter returns a formatter that writes every item of the list by executing the format when it is
+// a Go template, or as a record with the given field names when it is one of the machine readable formats.
+func (f listFormat) newItemFormatter(writer io.Writer, fieldNames ...string) (*itemFormatter, error) {
+	if f.isTemplate() {
+		formatter, err := f.newTemplateFormatter(writer)
+		if err != nil {
+			return nil, err
+		}
+		return &itemFormatter{template: formatter}, nil
+	}
+
+	formatter, err := f.newFormatter(writer, fieldNames...)
+	if err != nil {
+		return nil, err
+	}
+	return &itemFormatter{list: formatter}, nil
+}
+
+// Write writes the given item. The values of its fields are written when the format is not a Go template.
+func (f *itemFormatter) Write(item interface{}, values []string) error {
+	if f.template != nil {
+		return f.template.Write(item)
+	}
+	return f.list.Write(values)
+}
+
+// newFormatter returns a formatter that writes the list in the machine readable format,
+// using the given field names.
+func (f listFormat) newFormatter(writer io.Writer, fieldNames ...string) (listFormatter, error) {
+	switch f {
+	case formatJSON:
+		return newJSONFormatter(writer, fieldNames), nil
+	case formatYAML:
+		return newYAMLFormatter(writer, fieldNames), nil
+	case formatCSV:
+		return newCSVFormatter(writer, fieldNames), nil
+	default:
+		return nil, errNoSuchFormat(string(f))
+	}
+}
+
 func newLineFormatter(writer io.Writer) lineFormatter {
 	return lineFormatter{writer: writer}
 }
@@ -61,6 +199,84 @@ func (f *jsonFormatter) Write(values []string) error {
 	return f.encoder.Encode(jsonMap)
 }
 
+// newYAMLFormatter returns a list formatter that formats the given rows as the items of a yaml list.
+func newYAMLFormatter(writer io.Writer, fieldNames []string) *yamlFormatter {
+	for i := range fieldNames {
+		fieldNames[i] = toPascalCase(fieldNames[i])
+	}
+	return &yamlFormatter{
+		writer: writer,
+		fields: fieldNames,
+	}
+}
+
+type yamlFormatter struct {
+	writer io.Writer
+	fields []string
+}
+
+// Write writes the given row as a yaml list item
+// with the configured field names as keys and the provided values.
+func (f *yamlFormatter) Write(values []string) error {
+	if len(f.fields) != len(values) {
+		return fmt.Errorf("unexpected number of yaml fields")
+	}
+
+	item := make(yaml.MapSlice, len(values))
+	for i, element := range values {
+		item[i] = yaml.MapItem{Key: f.fields[i], Value: element}
+	}
+
+	out, err := yaml.Marshal([]yaml.MapSlice{item})
+	if err != nil {
+		return err
+	}
+
+	_, err = f.writer.Write(out)
+	return err
+}
+
+// newCSVFormatter returns a list formatter that formats the given rows as csv records.
+func newCSVFormatter(writer io.Writer, fieldNames []string) *csvFormatter {
+	for i := range fieldNames {
+		fieldNames[i] = toPascalCase(fieldNames[i])
+	}
+	return &csvFormatter{
+		writer: csv.NewWriter(writer),
+		fields: fieldNames,
+	}
+}
+
+type csvFormatter struct {
+	writer        *csv.Writer
+	fields        []string
+	headerWritten bool
+}
+
+// Write writes the given row as a csv record.
+// A header record with the configured field names is written on the first call, before any other record.
+func (f *csvFormatter) Write(values []string) error {
+	if len(f.fields) != len(values) {
+		return fmt.Errorf("unexpected number of csv fields")
+	}
+
+	if !f.headerWritten {
+		err := f.writer.Write(f.fields)
+		if err != nil {
+			return err
+		}
+		f.headerWritten = true
+	}
+
+	err := f.writer.Write(values)
+	if err != nil {
+		return err
+	}
+
+	f.writer.Flush()
+	return f.writer.Error()
+}
+
 // newTableFormatter returns a list formatter that formats entries in a table.
 func newTableFormatter(writer io.Writer, tableWidth int, columns []tableColumn) *tableFormatter {
 	return &tableFormatter{
@@ -1,10 +1,13 @@
 package secrethub
 
 import (
+	"bytes"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/secrethub/secrethub-go/internals/assert"
+	"github.com/secrethub/secrethub-go/internals/errio"
 )
 
 func Test_columnFormatter_columnWidths(t *testing.T) {
@@ -128,3 +131,115 @@ func Test_columnFormatter_formatRow(t *testing.T) {
 		})
 	}
 }
+
+func TestListFormat_newFormatter(t *testing.T) {
+	rows := [][]string{
+		{"dev1/repository", "ok"},
+		{"dev2/app, v2", "flagged"},
+	}
+
+	cases := map[string]struct {
+		format   listFormat
+		expected string
+		err      error
+	}{
+		"json": {
+			format: formatJSON,
+			expected: `{"Name":"dev1/repository","Status":"ok"}` + "\n" +
+				`{"Name":"dev2/app, v2","Status":"flagged"}` + "\n",
+		},
+		"yaml": {
+			format: formatYAML,
+			expected: "- Name: dev1/repository\n" +
+				"  Status: ok\n" +
+				"- Name: dev2/app, v2\n" +
+				"  Status: flagged\n",
+		},
+		"csv": {
+			format: formatCSV,
+			expected: "Name,Status\n" +
+				"dev1/repository,ok\n" +
+				"\"dev2/app, v2\",flagged\n",
+		},
+		"invalid format": {
+			format: "xml",
+			err:    errNoSuchFormat("xml"),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			buf := bytes.Buffer{}
+
+			formatter, err := tc.format.newFormatter(&buf, "name", "status")
+			assert.Equal(t, err, tc.err)
+
+			if err == nil {
+				for _, row := range rows {
+					err = formatter.Write(row)
+					assert.OK(t, err)
+				}
+			}
+
+			assert.Equal(t, buf.String(), tc.expected)
+		})
+	}
+}
+
+func TestListFormat_newTemplateFormatter(t *testing.T) {
+	type item struct {
+		Name      string
+		CreatedAt time.Time
+	}
+
+	createdAt := time.Date(2018, 1, 1, 1, 1, 1, 0, time.UTC)
+
+	cases := map[string]struct {
+		format   listFormat
+		expected string
+		err      bool
+	}{
+		"field": {
+			format:   "{{.Name}}",
+			expected: "foo\nbar\n",
+		},
+		"funcs": {
+			format:   "{{upper .Name}} {{json .Name}} {{time .CreatedAt}}",
+			expected: "FOO \"foo\" " + createdAt.Local().Format(time.RFC3339) + "\nBAR \"bar\" " + createdAt.Local().Format(time.RFC3339) + "\n",
+		},
+		"unknown field": {
+			format: "{{.Description}}",
+			err:    true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			buf := bytes.Buffer{}
+
+			formatter, err := tc.format.newTemplateFormatter(&buf)
+			assert.OK(t, err)
+
+			for _, name := range []string{"foo", "bar"} {
+				err = formatter.Write(item{Name: name, CreatedAt: createdAt})
+				if err != nil {
+					break
+				}
+			}
+
+			if tc.err {
+				publicErr, ok := err.(errio.PublicError)
+				assert.Equal(t, ok, true)
+				assert.Equal(t, publicErr.Code, "invalid_format_template")
+			} else {
+				assert.OK(t, err)
+			}
+			assert.Equal(t, buf.String(), tc.expected)
+		})
+	}
+}
+
+func TestListFormat_isTemplate(t *testing.T) {
+	assert.Equal(t, listFormat(formatJSON).isTemplate(), false)
+	assert.Equal(t, listFormat("{{.Name}}").isTemplate(), true)
+}
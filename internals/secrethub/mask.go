@@ -0,0 +1,151 @@
+package secrethub
+
+import (
+	"io"
+
+	"github.com/secrethub/secrethub-cli/internals/cli/masker"
+	"github.com/secrethub/secrethub-cli/internals/cli/ui"
+	"github.com/secrethub/secrethub-cli/internals/secrethub/command"
+
+	"github.com/secrethub/secrethub-go/internals/api"
+	"github.com/secrethub/secrethub-go/pkg/secrethub"
+)
+
+// MaskCommand masks secrets in its input and writes the result to its output.
+type MaskCommand struct {
+	io            ui.IO
+	newClient     newClientFunc
+	environment   *environment
+	paths         []string
+	maskerOptions masker.Options
+}
+
+// NewMaskCommand creates a new MaskCommand.
+func NewMaskCommand(io ui.IO, newClient newClientFunc) *MaskCommand {
+	return &MaskCommand{
+		io:          io,
+		newClient:   newClient,
+		environment: newEnvironment(io, newClient),
+	}
+}
+
+// Register registers the command, arguments and flags on the provided Registerer.
+func (cmd *MaskCommand) Register(r command.Registerer) {
+	const helpLong = "The secrets to mask are the secrets in the environment that run would pass to a process, " +
+		"which are sourced with the --envar and --env-file flags, and the secrets at the given paths. " +
+		"For paths of directories, all secrets in the directory and its subdirectories are masked. " +
+		"Detected secrets are replaced by \"" + maskString + "\", which can be changed with the mask-format flag. " +
+		"You should regard the masking as a best effort attempt and should always prevent secrets ending up in output in the first place."
+
+	clause := r.Command("mask", "Mask secrets in the output of another process, e.g. `kubectl logs my-pod | secrethub mask`.")
+	clause.HelpLong(helpLong)
+	clause.Arg("path", "The path to a secret or directory containing secrets to mask (<namespace>/<repo>[/<path>])").StringsVar(&cmd.paths)
+	clause.Flag("no-output-buffering", "Disable output buffering. This increases output responsiveness, but decreases the probability that secrets get masked.").BoolVar(&cmd.maskerOptions.DisableBuffer)
+	clause.Flag("masking-buffer-period", "The time period for which output is buffered. A higher value increases the probability that secrets get masked but decreases output responsiveness.").Default("50ms").DurationVar(&cmd.maskerOptions.BufferDelay)
+	clause.Flag("mask-encodings", "Also mask secrets that are written base64 encoded, URL encoded, JSON escaped or hex encoded.").BoolVar(&cmd.maskerOptions.MaskEncodings)
+	clause.Flag("mask-format", "The text that replaces masked secrets. "+masker.NamePlaceholder+" is replaced by the name of the environment variable or the path of the secret and "+masker.HashPlaceholder+" by a short hash of that name, e.g. \"<redacted:"+masker.NamePlaceholder+">\".").Default(maskString).StringVar(&cmd.maskerOptions.Format)
+	cmd.environment.register(clause)
+
+	command.BindAction(clause, cmd.Run)
+}
+
+// Run reads the input, masks all secrets in it and writes the result to the output.
+func (cmd *MaskCommand) Run() error {
+	secrets, err := cmd.secrets()
+	if err != nil {
+		return err
+	}
+
+	m := masker.New(newMaskSequences(secrets, make(map[string]struct{})), &cmd.maskerOptions)
+	output := m.AddStream(cmd.io.Output())
+	go m.Start()
+
+	_, copyErr := io.Copy(output, cmd.io.Input())
+
+	err = m.Stop()
+	if err != nil {
+		return err
+	}
+	return copyErr
+}
+
+// secrets returns the secrets in the environment, named by the environment variable they are used in,
+// and the secrets at the given paths, named by their path.
+func (cmd *MaskCommand) secrets() ([]masker.Sequence, error) {
+	envValues, err := cmd.environment.env()
+	if err != nil {
+		return nil, err
+	}
+
+	envPaths, err := collectSecretPaths(envValues)
+	if err != nil {
+		return nil, err
+	}
+
+	var secretPaths []string
+	if len(cmd.paths) > 0 {
+		client, err := cmd.newClient()
+		if err != nil {
+			return nil, err
+		}
+
+		secretPaths, err = expandSecretPaths(client, cmd.paths)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	prefetchReader := newPrefetchSecretReader(newSecretReader(cmd.newClient))
+	prefetchReader.Prefetch(append(envPaths, secretPaths...))
+
+	fieldReader := newFieldSecretReader(prefetchReader)
+
+	var secrets []masker.Sequence
+	for name, value := range envValues {
+		secretReader := newBufferedSecretReader(fieldReader)
+		_, err = value.resolve(secretReader)
+		if err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, resolvedSecrets(secretReader, name)...)
+	}
+
+	for _, path := range secretPaths {
+		secret, err := prefetchReader.ReadSecret(path)
+		if err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, masker.Sequence{Value: []byte(secret), Name: path})
+	}
+
+	return secrets, nil
+}
+
+// expandSecretPaths returns the paths of the secrets at the given paths. For paths of directories,
+// the paths of all secrets in the directory and its subdirectories are returned.
+func expandSecretPaths(client secrethub.ClientInterface, paths []string) ([]string, error) {
+	var res []string
+	for _, raw := range paths {
+		var path api.Path
+		err := path.Set(raw)
+		if err != nil {
+			return nil, err
+		}
+
+		tree, err := getTreeIfDir(client, path)
+		if err != nil {
+			return nil, err
+		}
+
+		if tree == nil {
+			res = append(res, path.String())
+			continue
+		}
+
+		_, secrets := walkDir(tree.RootDir)
+		for _, secret := range secrets {
+			res = append(res, api.JoinPaths(path.String(), secret))
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,124 @@
+package secrethub
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/secrethub/secrethub-cli/internals/cli/masker"
+	"github.com/secrethub/secrethub-cli/internals/cli/ui/fakeui"
+
+	"github.com/secrethub/secrethub-go/internals/api"
+	"github.com/secrethub/secrethub-go/internals/assert"
+	"github.com/secrethub/secrethub-go/pkg/secrethub"
+	"github.com/secrethub/secrethub-go/pkg/secrethub/fakeclient"
+)
+
+func TestMaskCommand_Run(t *testing.T) {
+	testError := errors.New("test error")
+
+	osStatNotExist := func(_ string) (info os.FileInfo, err error) {
+		return nil, os.ErrNotExist
+	}
+
+	secrets := map[string]string{
+		"namespace/repo/db_password":  "foo",
+		"namespace/repo/dir/api_key":  "bar",
+		"namespace/repo/dir/sub/cert": "baz",
+	}
+
+	cases := map[string]struct {
+		paths  []string
+		envar  map[string]string
+		format string
+		in     string
+		out    string
+		getErr error
+		err    error
+	}{
+		"secret": {
+			paths: []string{"namespace/repo/db_password"},
+			in:    "password=foo key=bar",
+			out:   "password=" + maskString + " key=bar",
+		},
+		"directory": {
+			paths: []string{"namespace/repo/dir"},
+			in:    "password=foo key=bar cert=baz",
+			out:   "password=foo key=" + maskString + " cert=" + maskString,
+		},
+		"envar": {
+			envar: map[string]string{"DB_PASSWORD": "namespace/repo/db_password"},
+			in:    "password=foo key=bar",
+			out:   "password=" + maskString + " key=bar",
+		},
+		"format": {
+			paths:  []string{"namespace/repo/db_password"},
+			envar:  map[string]string{"API_KEY": "namespace/repo/dir/api_key"},
+			format: "<redacted:{{name}}>",
+			in:     "password=foo key=bar",
+			out:    "password=<redacted:namespace/repo/db_password> key=<redacted:API_KEY>",
+		},
+		"no secrets": {
+			in:  "password=foo",
+			out: "password=foo",
+		},
+		"read error": {
+			paths:  []string{"namespace/repo/db_password"},
+			in:     "password=foo",
+			getErr: testError,
+			err:    testError,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			io := fakeui.NewIO(t)
+			io.In.Buffer.WriteString(tc.in)
+
+			cmd := MaskCommand{
+				io: io,
+				newClient: func() (secrethub.ClientInterface, error) {
+					return fakeclient.Client{
+						DirService: &fakeclient.DirService{
+							GetTreeFunc: func(path string, depth int, ancestors bool) (*api.Tree, error) {
+								if path != "namespace/repo/dir" {
+									return nil, api.ErrDirNotFound
+								}
+								return &api.Tree{
+									RootDir: &api.Dir{
+										Name:    "dir",
+										Secrets: []*api.Secret{{Name: "api_key"}},
+										SubDirs: []*api.Dir{
+											{Name: "sub", Secrets: []*api.Secret{{Name: "cert"}}},
+										},
+									},
+								}, nil
+							},
+						},
+						SecretService: &fakeclient.SecretService{
+							VersionService: &fakeclient.SecretVersionService{
+								GetWithDataFunc: func(path string) (*api.SecretVersion, error) {
+									if tc.getErr != nil {
+										return nil, tc.getErr
+									}
+									return &api.SecretVersion{Data: []byte(secrets[path])}, nil
+								},
+							},
+						},
+					}, nil
+				},
+				environment: &environment{
+					osStat: osStatNotExist,
+					envar:  tc.envar,
+				},
+				paths:         tc.paths,
+				maskerOptions: masker.Options{DisableBuffer: true, Format: tc.format},
+			}
+
+			err := cmd.Run()
+
+			assert.Equal(t, err, tc.err)
+			assert.Equal(t, io.Out.String(), tc.out)
+		})
+	}
+}
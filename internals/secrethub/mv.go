@@ -0,0 +1,98 @@
+package secrethub
+
+import (
+	"fmt"
+
+	"github.com/secrethub/secrethub-cli/internals/cli/ui"
+	"github.com/secrethub/secrethub-cli/internals/secrethub/command"
+
+	"github.com/secrethub/secrethub-go/internals/api"
+)
+
+// Errors
+var (
+	ErrCannotMoveVersion = errMain.Code("cannot_move_version").Error("cannot move a specific secret version. Use the cp command to copy it instead")
+	ErrCannotMoveRootDir = errMain.Code("cannot_move_root_dir").Error("cannot move the root directory of a repository. Use the cp command to copy its contents instead")
+)
+
+// MvCommand moves secrets and directories.
+type MvCommand struct {
+	src       api.Path
+	dst       api.Path
+	options   copyOptions
+	io        ui.IO
+	newClient newClientFunc
+}
+
+// NewMvCommand creates a new MvCommand.
+func NewMvCommand(io ui.IO, newClient newClientFunc) *MvCommand {
+	return &MvCommand{
+		io:        io,
+		newClient: newClient,
+		// The source is deleted with its entire history, so all versions are moved to preserve it.
+		options: copyOptions{
+			allVersions: true,
+		},
+	}
+}
+
+// Register registers the command, arguments and flags on the provided Registerer.
+func (cmd *MvCommand) Register(r command.Registerer) {
+	clause := r.Command("mv", "Move a secret or directory, also to another repository. All versions of the secrets are moved, preserving their history. The source is only removed after everything has been copied.")
+	clause.Alias("move")
+	clause.Arg("src-path", "The path to the secret or directory to move (<namespace>/<repo>[/<path>])").Required().SetValue(&cmd.src)
+	clause.Arg("dst-path", "The path to move to (<namespace>/<repo>[/<path>]). A secret moved to an existing directory is placed in that directory. The contents of a moved directory are merged into the destination directory.").Required().SetValue(&cmd.dst)
+	cmd.options.registerShared(clause)
+
+	command.BindAction(clause, cmd.Run)
+}
+
+// Run copies the resource at the source path to the destination path
+// and removes the source when copying has succeeded.
+func (cmd *MvCommand) Run() error {
+	if cmd.src.HasVersion() {
+		return ErrCannotMoveVersion
+	}
+
+	srcDir, err := cmd.src.ToDirPath()
+	if err == nil && srcDir.IsRepoPath() {
+		return ErrCannotMoveRootDir
+	}
+
+	client, err := cmd.newClient()
+	if err != nil {
+		return err
+	}
+
+	moved, isDir, err := copyPath(client, cmd.src, cmd.dst, cmd.options)
+	if err != nil {
+		return err
+	}
+
+	if isDir {
+		err = client.Dirs().Delete(srcDir.Value())
+		if err != nil {
+			return err
+		}
+	} else {
+		srcSecret, err := cmd.src.ToSecretPath()
+		if err != nil {
+			return err
+		}
+
+		err = client.Secrets().Delete(srcSecret.Value())
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(
+		cmd.io.Output(),
+		"Move complete! Moved %s from %s to %s.\n",
+		pluralize("secret", "secrets", moved),
+		cmd.src,
+		cmd.dst,
+	)
+
+	return nil
+}
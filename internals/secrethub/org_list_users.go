@@ -15,6 +15,7 @@ import (
 type OrgListUsersCommand struct {
 	orgName       api.OrgName
 	useTimestamps bool
+	format        listFormat
 	io            ui.IO
 	newClient     newClientFunc
 	timeFormatter TimeFormatter
@@ -34,6 +35,7 @@ func (cmd *OrgListUsersCommand) Register(r command.Registerer) {
 	clause.Alias("list-members")
 	clause.Arg("org-name", "The organization name").Required().SetValue(&cmd.orgName)
 	registerTimestampFlag(clause).BoolVar(&cmd.useTimestamps)
+	cmd.format.registerWithTemplate(clause)
 
 	command.BindAction(clause, cmd.Run)
 }
@@ -46,7 +48,7 @@ func (cmd *OrgListUsersCommand) Run() error {
 
 // beforeRun configures the command using the flag values.
 func (cmd *OrgListUsersCommand) beforeRun() {
-	cmd.timeFormatter = NewTimeFormatter(cmd.useTimestamps)
+	cmd.timeFormatter = NewTimeFormatter(cmd.useTimestamps || !cmd.format.isTable())
 }
 
 // run lists the users of an organization.
@@ -63,6 +65,20 @@ func (cmd *OrgListUsersCommand) run() error {
 
 	sort.Sort(api.SortOrgMemberByUsername(resp))
 
+	if !cmd.format.isTable() {
+		formatter, err := cmd.format.newItemFormatter(cmd.io.Output(), "user", "role", "last changed at")
+		if err != nil {
+			return err
+		}
+		for _, member := range resp {
+			err = formatter.Write(member, []string{member.User.Username, string(member.Role), cmd.timeFormatter.Format(member.LastChangedAt.Local())})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
 	w := tabwriter.NewWriter(cmd.io.Output(), 0, 2, 2, ' ', 0)
 
 	fmt.Fprintf(w, "%s\t%s\t%s\n", "USER", "ROLE", "LAST CHANGED")
@@ -3,6 +3,8 @@ package secrethub
 import (
 	"fmt"
 	"sort"
+	"strconv"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/secrethub/secrethub-cli/internals/cli/ui"
@@ -15,6 +17,7 @@ import (
 type OrgLsCommand struct {
 	quiet         bool
 	useTimestamps bool
+	format        listFormat
 	io            ui.IO
 	newClient     newClientFunc
 	timeFormatter TimeFormatter
@@ -34,6 +37,7 @@ func (cmd *OrgLsCommand) Register(r command.Registerer) {
 	clause.Alias("list")
 	clause.Flag("quiet", "Only print organization names.").Short('q').BoolVar(&cmd.quiet)
 	registerTimestampFlag(clause).BoolVar(&cmd.useTimestamps)
+	cmd.format.register(clause)
 
 	command.BindAction(clause, cmd.Run)
 }
@@ -46,7 +50,7 @@ func (cmd *OrgLsCommand) Run() error {
 
 // beforeRun configures the command using the flag values.
 func (cmd *OrgLsCommand) beforeRun() {
-	cmd.timeFormatter = NewTimeFormatter(cmd.useTimestamps)
+	cmd.timeFormatter = NewTimeFormatter(cmd.useTimestamps || !cmd.format.isTable())
 }
 
 // Run lists all organizations a user is a member of.
@@ -67,26 +71,45 @@ func (cmd *OrgLsCommand) run() error {
 		for _, org := range resp {
 			fmt.Fprintf(cmd.io.Output(), "%s\n", org.Name)
 		}
-	} else {
-		w := tabwriter.NewWriter(cmd.io.Output(), 0, 2, 2, ' ', 0)
+		return nil
+	}
 
+	var formatter listFormatter
+	var w *tabwriter.Writer
+	if cmd.format.isTable() {
+		w = tabwriter.NewWriter(cmd.io.Output(), 0, 2, 2, ' ', 0)
 		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", "NAME", "REPOS", "USERS", "CREATED")
+	} else {
+		formatter, err = cmd.format.newFormatter(cmd.io.Output(), "name", "repos", "users", "created at")
+		if err != nil {
+			return err
+		}
+	}
 
-		for _, org := range resp {
-			// TODO SHDEV-724: refactor these two calls to include the counts in the api.Org response by default.
-			members, err := client.Orgs().Members().List(org.Name)
-			if err != nil {
-				return err
-			}
+	for _, org := range resp {
+		// TODO SHDEV-724: refactor these two calls to include the counts in the api.Org response by default.
+		members, err := client.Orgs().Members().List(org.Name)
+		if err != nil {
+			return err
+		}
+
+		repos, err := client.Repos().List(org.Name)
+		if err != nil {
+			return err
+		}
 
-			repos, err := client.Repos().List(org.Name)
+		row := []string{org.Name, strconv.Itoa(len(repos)), strconv.Itoa(len(members)), cmd.timeFormatter.Format(org.CreatedAt.Local())}
+		if formatter != nil {
+			err = formatter.Write(row)
 			if err != nil {
 				return err
 			}
-
-			fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", org.Name, len(repos), len(members), cmd.timeFormatter.Format(org.CreatedAt.Local()))
+		} else {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
 		}
+	}
 
+	if w != nil {
 		err = w.Flush()
 		if err != nil {
 			return err
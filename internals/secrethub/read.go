@@ -10,6 +10,7 @@ import (
 	"github.com/secrethub/secrethub-cli/internals/cli/posix"
 	"github.com/secrethub/secrethub-cli/internals/cli/ui"
 	"github.com/secrethub/secrethub-cli/internals/secrethub/command"
+	"github.com/secrethub/secrethub-cli/internals/secrethub/tpl"
 
 	"github.com/secrethub/secrethub-go/internals/api"
 
@@ -26,6 +27,7 @@ type ReadCommand struct {
 	outFile             string
 	fileMode            filemode.FileMode
 	noNewLine           bool
+	field               string
 	newClient           newClientFunc
 }
 
@@ -53,12 +55,20 @@ func (cmd *ReadCommand) Register(r command.Registerer) {
 	clause.Flag("out-file", "Write the secret value to this file.").Short('o').StringVar(&cmd.outFile)
 	clause.Flag("file-mode", "Set filemode for the output file. Defaults to 0600 (read and write for current user) and is ignored without the --out-file flag.").Default("0600").SetValue(&cmd.fileMode)
 	clause.Flag("no-newline", "Do not print a new line after the secret.").Short('n').BoolVar(&cmd.noNewLine)
+	clause.Flag("field", "Read a field of a secret that contains a JSON or YAML object, e.g. .credentials.password. Objects and lists are written as JSON.").StringVar(&cmd.field)
 
 	command.BindAction(clause, cmd.Run)
 }
 
 // Run handles the command with the options as specified in the command.
 func (cmd *ReadCommand) Run() error {
+	if cmd.field != "" {
+		_, err := tpl.ParseSecretField(cmd.field)
+		if err != nil {
+			return err
+		}
+	}
+
 	client, err := cmd.newClient()
 	if err != nil {
 		return err
@@ -69,8 +79,17 @@ func (cmd *ReadCommand) Run() error {
 		return err
 	}
 
+	secretData := secret.Data
+	if cmd.field != "" {
+		value, err := selectSecretField(cmd.path.Value(), string(secret.Data), cmd.field)
+		if err != nil {
+			return err
+		}
+		secretData = []byte(value)
+	}
+
 	if cmd.useClipboard {
-		err = WriteClipboardAutoClear(secret.Data, cmd.clearClipboardAfter, cmd.clipper)
+		err = WriteClipboardAutoClear(secretData, cmd.clearClipboardAfter, cmd.clipper)
 		if err != nil {
 			return err
 		}
@@ -83,7 +102,6 @@ func (cmd *ReadCommand) Run() error {
 		)
 	}
 
-	secretData := secret.Data
 	if !cmd.noNewLine {
 		secretData = posix.AddNewLine(secretData)
 	}
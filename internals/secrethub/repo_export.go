@@ -2,8 +2,12 @@ package secrethub
 
 import (
 	"archive/zip"
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -23,6 +27,7 @@ var (
 type RepoExportCommand struct {
 	path      api.RepoPath
 	zipName   string
+	encrypt   bool
 	io        ui.IO
 	newClient newClientFunc
 }
@@ -40,6 +45,7 @@ func (cmd *RepoExportCommand) Register(r command.Registerer) {
 	clause := r.Command("export", "Export the repository to a zip file.")
 	clause.Arg("repo-path", "The repository to export").Required().PlaceHolder(repoPathPlaceHolder).SetValue(&cmd.path)
 	clause.Arg("zip-file-name", "The file name to assign to the exported .zip file. Defaults to secrethub_export_<namespace>_<repo>_<timestamp>.zip with the timestamp formatted as YYYYMMDD_HHMMSS").StringVar(&cmd.zipName)
+	clause.Flag("encrypt", "Encrypt the export with a passphrase. The passphrase is asked for when exporting and when importing the export with the import command.").BoolVar(&cmd.encrypt)
 
 	command.BindAction(clause, cmd.Run)
 }
@@ -56,23 +62,35 @@ func (cmd *RepoExportCommand) Run() error {
 		return ErrExportAlreadyExists
 	}
 
-	confirmed, err := ui.ConfirmCaseInsensitive(
-		cmd.io,
-		fmt.Sprintf(
-			"[DANGER ZONE] This will export all the secrets unencrypted in the %s repository. "+
-				"You are responsible for the protection of these secrets. "+
-				"Please type in the full path of the repository to confirm",
+	var passphrase string
+	if cmd.encrypt {
+		passphrase, err = ui.AskPassphrase(cmd.io, "Please enter a passphrase to encrypt the export: ", "Enter the same passphrase again: ", 3)
+		if err != nil {
+			return err
+		}
+
+		if passphrase == "" {
+			return ErrEmptyExportPassphrase
+		}
+	} else {
+		confirmed, err := ui.ConfirmCaseInsensitive(
+			cmd.io,
+			fmt.Sprintf(
+				"[DANGER ZONE] This will export all the secrets unencrypted in the %s repository. "+
+					"You are responsible for the protection of these secrets. "+
+					"Please type in the full path of the repository to confirm",
+				cmd.path.String(),
+			),
 			cmd.path.String(),
-		),
-		cmd.path.String(),
-	)
-	if err != nil {
-		return err
-	}
+		)
+		if err != nil {
+			return err
+		}
 
-	if !confirmed {
-		fmt.Fprintln(cmd.io.Output(), "Name does not match. Aborting.")
-		return nil
+		if !confirmed {
+			fmt.Fprintln(cmd.io.Output(), "Name does not match. Aborting.")
+			return nil
+		}
 	}
 
 	client, err := cmd.newClient()
@@ -85,23 +103,14 @@ func (cmd *RepoExportCommand) Run() error {
 		return err
 	}
 
-	zipFile, err := os.Create(cmd.zipName)
-	if err != nil {
-		return err
-	}
-
-	writer := zip.NewWriter(zipFile)
-	defer func() {
-		err := writer.Close()
-		if err != nil {
-			panic(fmt.Errorf("could not close zip file: %s", err))
-		}
+	buf := &bytes.Buffer{}
+	writer := zip.NewWriter(buf)
 
-		err = zipFile.Close()
-		if err != nil {
-			panic(fmt.Errorf("could not close zip file: %s", err))
-		}
-	}()
+	manifest := exportManifest{
+		FormatVersion: exportFormatVersion,
+		Repo:          cmd.path.String(),
+		ExportedAt:    time.Now().UTC(),
+	}
 
 	for _, secret := range rootDir.Secrets {
 		secretPath, err := rootDir.AbsSecretPath(secret.SecretID)
@@ -114,6 +123,11 @@ func (cmd *RepoExportCommand) Run() error {
 			return err
 		}
 
+		manifestSecret := exportManifestSecret{
+			// Remove the repo path from the secret path.
+			Path: strings.TrimPrefix(secretPath.String(), secretPath.GetRepoPath().String()+"/"),
+		}
+
 		for _, version := range versions {
 			versionPath, err := secretPath.AddVersion(version.Version)
 			if err != nil {
@@ -134,7 +148,50 @@ func (cmd *RepoExportCommand) Run() error {
 			if err != nil {
 				return err
 			}
+
+			manifestSecret.Versions = append(manifestSecret.Versions, exportManifestVersion{
+				Version:   version.Version,
+				Status:    version.Status,
+				CreatedAt: version.CreatedAt,
+				SHA256:    checksum(version.Data),
+			})
 		}
+
+		manifest.Secrets = append(manifest.Secrets, manifestSecret)
+	}
+
+	sort.Slice(manifest.Secrets, func(i, j int) bool {
+		return manifest.Secrets[i].Path < manifest.Secrets[j].Path
+	})
+
+	manifestNode, err := writer.Create(exportManifestName)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(manifestNode)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(manifest)
+	if err != nil {
+		return err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+
+	export := buf.Bytes()
+	if cmd.encrypt {
+		export, err = encryptExport(export, passphrase)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = ioutil.WriteFile(cmd.zipName, export, 0600)
+	if err != nil {
+		return ErrCannotWrite(cmd.zipName, err)
 	}
 
 	return nil
@@ -0,0 +1,223 @@
+package secrethub
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Errors
+var (
+	ErrEmptyExportPassphrase     = errMain.Code("empty_export_passphrase").Error("the passphrase to encrypt the export cannot be empty")
+	ErrIncorrectExportPassphrase = errMain.Code("incorrect_export_passphrase").Error("cannot decrypt the export: the passphrase is incorrect or the file has been modified")
+	ErrInvalidExportManifest     = errMain.Code("invalid_export_manifest").ErrorPref("the export does not match its manifest: %s")
+)
+
+const (
+	// exportManifestName is the name of the file in an export that contains its manifest.
+	exportManifestName  = "manifest.json"
+	exportFormatVersion = 1
+
+	// encryptedExportHeader is the start of every encrypted export, followed by
+	// the scrypt salt, the AES-GCM nonce and the encrypted zip file.
+	encryptedExportHeader = "secrethub-encrypted-export-v1\n"
+	exportSaltLength      = 32
+
+	// The scrypt parameters used to derive the encryption key of an export from its passphrase.
+	exportScryptN   = 1 << 15
+	exportScryptR   = 8
+	exportScryptP   = 1
+	exportKeyLength = 32
+)
+
+// exportManifest describes the contents of a repository export,
+// so the integrity of the export can be verified when it is imported.
+type exportManifest struct {
+	FormatVersion int                    `json:"format_version"`
+	Repo          string                 `json:"repo"`
+	ExportedAt    time.Time              `json:"exported_at"`
+	Secrets       []exportManifestSecret `json:"secrets"`
+}
+
+// exportManifestSecret describes an exported secret.
+// The path of the secret is relative to the repository.
+type exportManifestSecret struct {
+	Path     string                  `json:"path"`
+	Versions []exportManifestVersion `json:"versions"`
+}
+
+// exportManifestVersion describes an exported secret version.
+// The checksum is the hex encoded SHA-256 hash of the secret value.
+type exportManifestVersion struct {
+	Version   int       `json:"version"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	SHA256    string    `json:"sha256"`
+}
+
+// checksum returns the hex encoded SHA-256 hash of the given data.
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// readExportManifest reads the manifest from the given file in an export.
+func readExportManifest(file *zip.File) (*exportManifest, error) {
+	raw, err := readZipFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest exportManifest
+	err = json.Unmarshal(raw, &manifest)
+	if err != nil {
+		return nil, ErrInvalidExportManifest(err)
+	}
+
+	if manifest.FormatVersion != exportFormatVersion {
+		return nil, ErrInvalidExportManifest(fmt.Sprintf("unsupported format version %d", manifest.FormatVersion))
+	}
+
+	return &manifest, nil
+}
+
+// verify checks that the given secret versions read from an export are exactly the ones
+// described by the manifest and that their checksums match. As the export adds a newline
+// to every secret value, the checksum is used to determine whether the original secret value
+// ended with a newline. The verified versions are returned with their original values.
+func (m exportManifest) verify(secrets map[string][]exportedSecretVersion) (map[string][]exportedSecretVersion, error) {
+	verified := make(map[string][]exportedSecretVersion, len(m.Secrets))
+	for _, secret := range m.Secrets {
+		exported := make(map[int][]byte, len(secrets[secret.Path]))
+		for _, version := range secrets[secret.Path] {
+			exported[version.version] = version.data
+		}
+
+		if len(exported) != len(secret.Versions) {
+			return nil, ErrInvalidExportManifest(fmt.Sprintf("expected %s of %s, found %d", pluralize("version", "versions", len(secret.Versions)), secret.Path, len(exported)))
+		}
+
+		for _, version := range secret.Versions {
+			data, ok := exported[version.Version]
+			if !ok {
+				return nil, ErrInvalidExportManifest(fmt.Sprintf("%s:%d is missing", secret.Path, version.Version))
+			}
+
+			trimmed := bytes.TrimSuffix(data, []byte("\n"))
+			if checksum(trimmed) == version.SHA256 {
+				data = trimmed
+			} else if checksum(data) != version.SHA256 {
+				return nil, ErrInvalidExportManifest(fmt.Sprintf("checksum of %s:%d does not match", secret.Path, version.Version))
+			}
+
+			verified[secret.Path] = append(verified[secret.Path], exportedSecretVersion{
+				version: version.Version,
+				data:    data,
+			})
+		}
+	}
+
+	for path := range secrets {
+		if _, ok := verified[path]; !ok {
+			return nil, ErrInvalidExportManifest(fmt.Sprintf("%s is not in the manifest", path))
+		}
+	}
+
+	return verified, nil
+}
+
+// isEncryptedExport returns whether the given export is encrypted with encryptExport.
+func isEncryptedExport(export []byte) bool {
+	return bytes.HasPrefix(export, []byte(encryptedExportHeader))
+}
+
+// encryptExport encrypts an export with AES-256-GCM, using a key that is
+// derived from the given passphrase with scrypt.
+func encryptExport(export []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, exportSaltLength)
+	_, err := io.ReadFull(rand.Reader, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := newExportGCM(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	header := append([]byte(encryptedExportHeader), salt...)
+
+	encrypted := make([]byte, 0, len(header)+len(nonce)+len(export)+gcm.Overhead())
+	encrypted = append(encrypted, header...)
+	encrypted = append(encrypted, nonce...)
+	// The header and salt are authenticated, so they cannot be modified unnoticed.
+	return gcm.Seal(encrypted, nonce, export, header), nil
+}
+
+// decryptExport decrypts an export that has been encrypted with encryptExport.
+func decryptExport(encrypted []byte, passphrase string) ([]byte, error) {
+	headerLength := len(encryptedExportHeader) + exportSaltLength
+	if !isEncryptedExport(encrypted) || len(encrypted) < headerLength {
+		return nil, ErrIncorrectExportPassphrase
+	}
+
+	header := encrypted[:headerLength]
+	salt := header[len(encryptedExportHeader):]
+
+	gcm, err := newExportGCM(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(encrypted) < headerLength+gcm.NonceSize() {
+		return nil, ErrIncorrectExportPassphrase
+	}
+
+	nonce := encrypted[headerLength : headerLength+gcm.NonceSize()]
+	export, err := gcm.Open(nil, nonce, encrypted[headerLength+gcm.NonceSize():], header)
+	if err != nil {
+		return nil, ErrIncorrectExportPassphrase
+	}
+	return export, nil
+}
+
+func newExportGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, exportScryptN, exportScryptR, exportScryptP, exportKeyLength)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// readZipFile returns the contents of a file in a zip archive.
+func readZipFile(file *zip.File) ([]byte, error) {
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ioutil.ReadAll(f)
+}
@@ -0,0 +1,141 @@
+package secrethub
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/secrethub/secrethub-go/internals/assert"
+)
+
+func TestEncryptExport(t *testing.T) {
+	export := []byte("export contents")
+
+	encrypted, err := encryptExport(export, "passphrase")
+	assert.OK(t, err)
+	assert.Equal(t, isEncryptedExport(encrypted), true)
+	assert.Equal(t, isEncryptedExport(export), false)
+
+	t.Run("correct passphrase", func(t *testing.T) {
+		decrypted, err := decryptExport(encrypted, "passphrase")
+		assert.OK(t, err)
+		assert.Equal(t, decrypted, export)
+	})
+
+	t.Run("incorrect passphrase", func(t *testing.T) {
+		_, err := decryptExport(encrypted, "incorrect")
+		assert.Equal(t, err, ErrIncorrectExportPassphrase)
+	})
+
+	t.Run("modified salt", func(t *testing.T) {
+		modified := append([]byte{}, encrypted...)
+		modified[len(encryptedExportHeader)] ^= 1
+
+		_, err := decryptExport(modified, "passphrase")
+		assert.Equal(t, err, ErrIncorrectExportPassphrase)
+	})
+
+	t.Run("modified ciphertext", func(t *testing.T) {
+		modified := append([]byte{}, encrypted...)
+		modified[len(modified)-1] ^= 1
+
+		_, err := decryptExport(modified, "passphrase")
+		assert.Equal(t, err, ErrIncorrectExportPassphrase)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		_, err := decryptExport(encrypted[:len(encryptedExportHeader)+1], "passphrase")
+		assert.Equal(t, err, ErrIncorrectExportPassphrase)
+	})
+}
+
+func TestReadRepoExport_Manifest(t *testing.T) {
+	manifest := func(secrets ...exportManifestSecret) string {
+		raw, err := json.Marshal(exportManifest{
+			FormatVersion: exportFormatVersion,
+			Repo:          "namespace/repo",
+			Secrets:       secrets,
+		})
+		assert.OK(t, err)
+		return string(raw)
+	}
+
+	cases := map[string]struct {
+		files    map[string]string
+		expected map[string][]exportedSecretVersion
+		err      error
+	}{
+		"valid": {
+			files: map[string]string{
+				"dir/foo/1": "foo\n",
+				"dir/foo/2": "multiline\nfoo\n",
+				exportManifestName: manifest(exportManifestSecret{
+					Path: "dir/foo",
+					Versions: []exportManifestVersion{
+						{Version: 1, SHA256: checksum([]byte("foo"))},
+						{Version: 2, SHA256: checksum([]byte("multiline\nfoo\n"))},
+					},
+				}),
+			},
+			expected: map[string][]exportedSecretVersion{
+				"dir/foo": {
+					{version: 1, data: []byte("foo")},
+					{version: 2, data: []byte("multiline\nfoo\n")},
+				},
+			},
+		},
+		"checksum mismatch": {
+			files: map[string]string{
+				"foo/1": "modified\n",
+				exportManifestName: manifest(exportManifestSecret{
+					Path:     "foo",
+					Versions: []exportManifestVersion{{Version: 1, SHA256: checksum([]byte("foo"))}},
+				}),
+			},
+			err: ErrInvalidExportManifest("checksum of foo:1 does not match"),
+		},
+		"missing version": {
+			files: map[string]string{
+				"foo/1": "foo\n",
+				exportManifestName: manifest(exportManifestSecret{
+					Path:     "foo",
+					Versions: []exportManifestVersion{{Version: 2, SHA256: checksum([]byte("foo"))}},
+				}),
+			},
+			err: ErrInvalidExportManifest("foo:2 is missing"),
+		},
+		"missing secret": {
+			files: map[string]string{
+				exportManifestName: manifest(exportManifestSecret{
+					Path:     "foo",
+					Versions: []exportManifestVersion{{Version: 1, SHA256: checksum([]byte("foo"))}},
+				}),
+			},
+			err: ErrInvalidExportManifest("expected 1 version of foo, found 0"),
+		},
+		"secret not in manifest": {
+			files: map[string]string{
+				"foo/1":            "foo\n",
+				exportManifestName: manifest(),
+			},
+			err: ErrInvalidExportManifest("foo is not in the manifest"),
+		},
+		"unsupported format version": {
+			files: map[string]string{
+				exportManifestName: `{"format_version": 2}`,
+			},
+			err: ErrInvalidExportManifest("unsupported format version 2"),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			actual, verified, err := readRepoExport(newTestZip(t, tc.files))
+
+			assert.Equal(t, err, tc.err)
+			if tc.err == nil {
+				assert.Equal(t, actual, tc.expected)
+				assert.Equal(t, verified, true)
+			}
+		})
+	}
+}
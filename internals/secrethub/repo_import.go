@@ -0,0 +1,402 @@
+package secrethub
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/secrethub/secrethub-cli/internals/cli/ui"
+	"github.com/secrethub/secrethub-cli/internals/secrethub/command"
+
+	"github.com/secrethub/secrethub-go/internals/api"
+	"github.com/secrethub/secrethub-go/pkg/secrethub"
+)
+
+// Errors
+var (
+	ErrInvalidImportFile = errMain.Code("invalid_import_file").ErrorPref("%s is not a valid repository export: %s")
+	ErrImportConflict    = errMain.Code("import_conflict").ErrorPref("%s already exist in the repository. Use --skip-existing to skip them or --overwrite to write the imported values as new versions. Use --dry-run to see which secrets already exist")
+)
+
+// RepoImportCommand imports the secrets of a zip file created by RepoExportCommand into a repo.
+type RepoImportCommand struct {
+	path         api.RepoPath
+	zipName      string
+	dryRun       bool
+	skipExisting bool
+	overwrite    bool
+	allVersions  bool
+	io           ui.IO
+	newClient    newClientFunc
+}
+
+// NewRepoImportCommand creates a new RepoImportCommand.
+func NewRepoImportCommand(io ui.IO, newClient newClientFunc) *RepoImportCommand {
+	return &RepoImportCommand{
+		io:        io,
+		newClient: newClient,
+	}
+}
+
+// Register registers the command, arguments and flags on the provided Registerer.
+func (cmd *RepoImportCommand) Register(r command.Registerer) {
+	clause := r.Command("import", "Import the secrets of a zip file created with the export command into a repository. The repository is created when it does not exist yet.")
+	clause.Arg("repo-path", "The repository to import the secrets into").Required().PlaceHolder(repoPathPlaceHolder).SetValue(&cmd.path)
+	clause.Arg("zip-file-name", "The path to the .zip file to import").Required().StringVar(&cmd.zipName)
+	clause.Flag("all-versions", "Import all versions of every secret in order, instead of only the latest version.").BoolVar(&cmd.allVersions)
+	clause.Flag("dry-run", "Show the changes the import would make to the repository, without making them.").BoolVar(&cmd.dryRun)
+	clause.Flag("skip-existing", "Skip secrets that already exist in the repository.").BoolVar(&cmd.skipExisting)
+	clause.Flag("overwrite", "Write the imported values of secrets that already exist in the repository as new versions.").BoolVar(&cmd.overwrite)
+
+	command.BindAction(clause, cmd.Run)
+}
+
+// Run imports the secrets of a zip file into a repo.
+func (cmd *RepoImportCommand) Run() error {
+	if cmd.skipExisting && cmd.overwrite {
+		return ErrFlagsConflict("--skip-existing and --overwrite")
+	}
+
+	secrets, err := cmd.readExportFile()
+	if err != nil {
+		return err
+	}
+
+	client, err := cmd.newClient()
+	if err != nil {
+		return err
+	}
+
+	repoExists := true
+	var existingDirs, existingSecrets []string
+	tree, err := client.Dirs().GetTree(cmd.path.GetDirPath().Value(), -1, false)
+	if api.IsErrNotFound(err) {
+		repoExists = false
+	} else if err != nil {
+		return err
+	} else {
+		existingDirs, existingSecrets = walkDir(tree.RootDir)
+	}
+
+	plan := newRepoImportPlan(secrets, existingDirs, existingSecrets, cmd.allVersions)
+
+	if cmd.dryRun {
+		plan.print(cmd.io.Output(), cmd.path, repoExists, cmd.conflictPolicy())
+		return nil
+	}
+
+	conflicts := plan.conflicts()
+	if conflicts > 0 && cmd.conflictPolicy() == importConflictError {
+		return ErrImportConflict(pluralize("secret", "secrets", conflicts))
+	}
+
+	fmt.Fprintf(cmd.io.Output(), "Importing secrets into %s...\n", cmd.path)
+
+	if !repoExists {
+		_, err = client.Repos().Create(cmd.path.Value())
+		if err != nil {
+			return err
+		}
+	}
+
+	created, overwritten, err := plan.apply(client, cmd.path, cmd.conflictPolicy())
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(
+		cmd.io.Output(),
+		"Import complete! Created %s and overwrote %s in %s.\n",
+		pluralize("secret", "secrets", created),
+		pluralize("secret", "secrets", overwritten),
+		cmd.path,
+	)
+
+	return nil
+}
+
+// importConflictPolicy defines what to do with imported secrets that already exist.
+type importConflictPolicy int
+
+const (
+	importConflictError importConflictPolicy = iota
+	importConflictSkip
+	importConflictOverwrite
+)
+
+func (cmd *RepoImportCommand) conflictPolicy() importConflictPolicy {
+	if cmd.skipExisting {
+		return importConflictSkip
+	}
+	if cmd.overwrite {
+		return importConflictOverwrite
+	}
+	return importConflictError
+}
+
+// exportedSecretVersion is a version of a secret as it is stored in a repository export.
+type exportedSecretVersion struct {
+	version int
+	data    []byte
+}
+
+// readExportFile reads the secrets from the export file to import.
+// When the export is encrypted, the passphrase to decrypt it is asked for.
+func (cmd *RepoImportCommand) readExportFile() (map[string][]exportedSecretVersion, error) {
+	raw, err := ioutil.ReadFile(cmd.zipName)
+	if err != nil {
+		return nil, ErrReadFile(cmd.zipName, err)
+	}
+
+	if isEncryptedExport(raw) {
+		passphrase, err := ui.AskSecret(cmd.io, "Please enter the passphrase to decrypt the export:")
+		if err != nil {
+			return nil, err
+		}
+
+		raw, err = decryptExport(raw, passphrase)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(raw), int64(len(raw)))
+	if err != nil {
+		return nil, ErrInvalidImportFile(cmd.zipName, err)
+	}
+
+	secrets, verified, err := readRepoExport(reader)
+	if err != nil {
+		return nil, ErrInvalidImportFile(cmd.zipName, err)
+	}
+
+	if !verified {
+		fmt.Fprintf(cmd.io.Output(),
+			"Warning: %s was exported by an older version of the CLI and contains no manifest. "+
+				"The newline that was added to the end of every secret on export is removed, "+
+				"so secrets that originally ended with a newline are imported without it.\n",
+			cmd.zipName,
+		)
+	}
+	return secrets, nil
+}
+
+// readRepoExport reads the secrets from a repository export. An export contains a file for every
+// secret version, of which the path is the path of the secret relative to the repository,
+// followed by the version number, e.g. dir/secret/1. The returned versions of every secret
+// are sorted by version number. When the export contains a manifest, the secrets are verified
+// against it and true is returned.
+//
+// Exports without a manifest do not record whether a newline was added to a secret on export,
+// so the trailing newline is removed from every secret, including secrets that ended with one.
+func readRepoExport(reader *zip.Reader) (map[string][]exportedSecretVersion, bool, error) {
+	var manifest *exportManifest
+	secrets := make(map[string][]exportedSecretVersion)
+	for _, file := range reader.File {
+		if strings.HasSuffix(file.Name, "/") {
+			continue
+		}
+
+		if file.Name == exportManifestName {
+			var err error
+			manifest, err = readExportManifest(file)
+			if err != nil {
+				return nil, false, err
+			}
+			continue
+		}
+
+		secretPath, versionNumber := path.Split(file.Name)
+		secretPath = strings.TrimSuffix(secretPath, "/")
+		version, err := strconv.Atoi(versionNumber)
+		if err != nil || secretPath == "" {
+			return nil, false, fmt.Errorf("unexpected file %s", file.Name)
+		}
+
+		data, err := readZipFile(file)
+		if err != nil {
+			return nil, false, err
+		}
+
+		secrets[secretPath] = append(secrets[secretPath], exportedSecretVersion{
+			version: version,
+			data:    data,
+		})
+	}
+
+	if manifest != nil {
+		var err error
+		secrets, err = manifest.verify(secrets)
+		if err != nil {
+			return nil, false, err
+		}
+	} else {
+		// Without a manifest, the newline the export adds to every secret is always removed.
+		for _, versions := range secrets {
+			for i := range versions {
+				versions[i].data = bytes.TrimSuffix(versions[i].data, []byte("\n"))
+			}
+		}
+	}
+
+	for _, versions := range secrets {
+		sort.Slice(versions, func(i, j int) bool {
+			return versions[i].version < versions[j].version
+		})
+	}
+
+	return secrets, manifest != nil, nil
+}
+
+// importedSecret is a secret that is imported into a repository.
+type importedSecret struct {
+	path     string
+	versions []exportedSecretVersion
+	exists   bool
+}
+
+// repoImportPlan contains the changes an import makes to a repository.
+// All paths are relative to the repository.
+type repoImportPlan struct {
+	dirs    []string
+	secrets []importedSecret
+}
+
+// newRepoImportPlan determines the directories that need to be created and the secrets
+// that need to be written to import the given secrets into a repository that contains
+// the given directories and secrets. When allVersions is false, only the latest version
+// of every secret is imported.
+func newRepoImportPlan(secrets map[string][]exportedSecretVersion, existingDirs, existingSecrets []string, allVersions bool) repoImportPlan {
+	dirExists := make(map[string]bool, len(existingDirs))
+	for _, dir := range existingDirs {
+		dirExists[dir] = true
+	}
+
+	secretExists := make(map[string]bool, len(existingSecrets))
+	for _, secret := range existingSecrets {
+		secretExists[secret] = true
+	}
+
+	var plan repoImportPlan
+	for secretPath, versions := range secrets {
+		for dir := path.Dir(secretPath); dir != "."; dir = path.Dir(dir) {
+			if !dirExists[dir] {
+				dirExists[dir] = true
+				plan.dirs = append(plan.dirs, dir)
+			}
+		}
+
+		if !allVersions {
+			versions = versions[len(versions)-1:]
+		}
+
+		plan.secrets = append(plan.secrets, importedSecret{
+			path:     secretPath,
+			versions: versions,
+			exists:   secretExists[secretPath],
+		})
+	}
+
+	// Sorting makes sure parent directories are created before their subdirectories.
+	sort.Strings(plan.dirs)
+	sort.Slice(plan.secrets, func(i, j int) bool {
+		return plan.secrets[i].path < plan.secrets[j].path
+	})
+
+	return plan
+}
+
+// conflicts returns the number of imported secrets that already exist.
+func (p repoImportPlan) conflicts() int {
+	conflicts := 0
+	for _, secret := range p.secrets {
+		if secret.exists {
+			conflicts++
+		}
+	}
+	return conflicts
+}
+
+// print writes the changes of the plan to the given writer as a diff against the repository.
+func (p repoImportPlan) print(w io.Writer, repoPath api.RepoPath, repoExists bool, policy importConflictPolicy) {
+	fmt.Fprintf(w, "Importing into %s would make the following changes:\n\n", repoPath)
+
+	if !repoExists {
+		fmt.Fprintf(w, "+ %s (new repository)\n", repoPath)
+	}
+
+	for _, dir := range p.dirs {
+		fmt.Fprintf(w, "+ %s/\n", dir)
+	}
+
+	created, overwritten, skipped, conflicts := 0, 0, 0, 0
+	for _, secret := range p.secrets {
+		versions := pluralize("version", "versions", len(secret.versions))
+		switch {
+		case !secret.exists:
+			fmt.Fprintf(w, "+ %s (%s)\n", secret.path, versions)
+			created++
+		case policy == importConflictOverwrite:
+			fmt.Fprintf(w, "~ %s (%s, overwrite)\n", secret.path, versions)
+			overwritten++
+		case policy == importConflictSkip:
+			fmt.Fprintf(w, "= %s (already exists, skip)\n", secret.path)
+			skipped++
+		default:
+			fmt.Fprintf(w, "! %s (already exists)\n", secret.path)
+			conflicts++
+		}
+	}
+
+	fmt.Fprintf(w,
+		"\n%s to create, %d to overwrite, %d to skip and %d conflicting.\n",
+		pluralize("secret", "secrets", created),
+		overwritten,
+		skipped,
+		conflicts,
+	)
+
+	if conflicts > 0 {
+		fmt.Fprintln(w, "Use --skip-existing or --overwrite to resolve the conflicts.")
+	}
+}
+
+// apply creates the directories and writes the secrets of the plan in the given repository.
+// It returns the number of secrets that have been created and the number of existing secrets
+// that have been overwritten.
+func (p repoImportPlan) apply(client secrethub.ClientInterface, repoPath api.RepoPath, policy importConflictPolicy) (int, int, error) {
+	for _, dir := range p.dirs {
+		_, err := client.Dirs().Create(repoPath.Value() + "/" + dir)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	created, overwritten := 0, 0
+	for _, secret := range p.secrets {
+		if secret.exists && policy != importConflictOverwrite {
+			continue
+		}
+
+		for _, version := range secret.versions {
+			_, err := client.Secrets().Write(repoPath.Value()+"/"+secret.path, version.data)
+			if err != nil {
+				return created, overwritten, err
+			}
+		}
+
+		if secret.exists {
+			overwritten++
+		} else {
+			created++
+		}
+	}
+
+	return created, overwritten, nil
+}
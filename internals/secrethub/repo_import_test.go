@@ -0,0 +1,185 @@
+package secrethub
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/secrethub/secrethub-go/internals/api"
+	"github.com/secrethub/secrethub-go/internals/assert"
+)
+
+func newTestZip(t *testing.T, files map[string]string) *zip.Reader {
+	buf := &bytes.Buffer{}
+	writer := zip.NewWriter(buf)
+	for name, data := range files {
+		f, err := writer.Create(name)
+		assert.OK(t, err)
+		_, err = f.Write([]byte(data))
+		assert.OK(t, err)
+	}
+	assert.OK(t, writer.Close())
+
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.OK(t, err)
+	return reader
+}
+
+func TestReadRepoExport(t *testing.T) {
+	cases := map[string]struct {
+		files    map[string]string
+		expected map[string][]exportedSecretVersion
+		err      bool
+	}{
+		"secrets": {
+			files: map[string]string{
+				"foo/2":     "foo v2\n",
+				"foo/1":     "foo v1\n",
+				"dir/bar/1": "bar\n",
+			},
+			expected: map[string][]exportedSecretVersion{
+				"foo": {
+					{version: 1, data: []byte("foo v1")},
+					{version: 2, data: []byte("foo v2")},
+				},
+				"dir/bar": {
+					{version: 1, data: []byte("bar")},
+				},
+			},
+		},
+		"directory entries": {
+			files: map[string]string{
+				"dir/":      "",
+				"dir/bar/1": "bar\n",
+			},
+			expected: map[string][]exportedSecretVersion{
+				"dir/bar": {
+					{version: 1, data: []byte("bar")},
+				},
+			},
+		},
+		"missing version": {
+			files: map[string]string{
+				"dir/bar": "bar\n",
+			},
+			err: true,
+		},
+		"missing secret": {
+			files: map[string]string{
+				"1": "bar\n",
+			},
+			err: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			actual, verified, err := readRepoExport(newTestZip(t, tc.files))
+			if tc.err {
+				assert.Equal(t, err != nil, true)
+				return
+			}
+
+			assert.OK(t, err)
+			assert.Equal(t, actual, tc.expected)
+			assert.Equal(t, verified, false)
+		})
+	}
+}
+
+func TestRepoImportPlan(t *testing.T) {
+	secrets := map[string][]exportedSecretVersion{
+		"foo": {
+			{version: 1, data: []byte("foo v1")},
+			{version: 2, data: []byte("foo v2")},
+		},
+		"dir/sub/bar": {
+			{version: 1, data: []byte("bar")},
+		},
+		"dir/baz": {
+			{version: 1, data: []byte("baz")},
+		},
+	}
+	existingDirs := []string{"dir"}
+	existingSecrets := []string{"dir/baz"}
+
+	cases := map[string]struct {
+		allVersions bool
+		policy      importConflictPolicy
+		expected    repoImportPlan
+		out         string
+	}{
+		"latest versions": {
+			policy: importConflictSkip,
+			expected: repoImportPlan{
+				dirs: []string{"dir/sub"},
+				secrets: []importedSecret{
+					{path: "dir/baz", versions: []exportedSecretVersion{{version: 1, data: []byte("baz")}}, exists: true},
+					{path: "dir/sub/bar", versions: []exportedSecretVersion{{version: 1, data: []byte("bar")}}},
+					{path: "foo", versions: []exportedSecretVersion{{version: 2, data: []byte("foo v2")}}},
+				},
+			},
+			out: "Importing into namespace/repo would make the following changes:\n\n" +
+				"+ dir/sub/\n" +
+				"= dir/baz (already exists, skip)\n" +
+				"+ dir/sub/bar (1 version)\n" +
+				"+ foo (1 version)\n" +
+				"\n2 secrets to create, 0 to overwrite, 1 to skip and 0 conflicting.\n",
+		},
+		"all versions": {
+			allVersions: true,
+			policy:      importConflictError,
+			expected: repoImportPlan{
+				dirs: []string{"dir/sub"},
+				secrets: []importedSecret{
+					{path: "dir/baz", versions: []exportedSecretVersion{{version: 1, data: []byte("baz")}}, exists: true},
+					{path: "dir/sub/bar", versions: []exportedSecretVersion{{version: 1, data: []byte("bar")}}},
+					{path: "foo", versions: []exportedSecretVersion{{version: 1, data: []byte("foo v1")}, {version: 2, data: []byte("foo v2")}}},
+				},
+			},
+			out: "Importing into namespace/repo would make the following changes:\n\n" +
+				"+ dir/sub/\n" +
+				"! dir/baz (already exists)\n" +
+				"+ dir/sub/bar (1 version)\n" +
+				"+ foo (2 versions)\n" +
+				"\n2 secrets to create, 0 to overwrite, 0 to skip and 1 conflicting.\n" +
+				"Use --skip-existing or --overwrite to resolve the conflicts.\n",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			plan := newRepoImportPlan(secrets, existingDirs, existingSecrets, tc.allVersions)
+			assert.Equal(t, plan, tc.expected)
+			assert.Equal(t, plan.conflicts(), 1)
+
+			out := &bytes.Buffer{}
+			plan.print(out, api.RepoPath("namespace/repo"), true, tc.policy)
+			assert.Equal(t, out.String(), tc.out)
+		})
+	}
+}
+
+func TestWalkDir(t *testing.T) {
+	dir := &api.Dir{
+		Name: "repo",
+		SubDirs: []*api.Dir{
+			{
+				Name: "dir",
+				SubDirs: []*api.Dir{
+					{Name: "sub"},
+				},
+				Secrets: []*api.Secret{
+					{Name: "bar"},
+				},
+			},
+		},
+		Secrets: []*api.Secret{
+			{Name: "foo"},
+		},
+	}
+
+	dirs, secrets := walkDir(dir)
+	assert.Equal(t, dirs, []string{"dir", "dir/sub"})
+	assert.Equal(t, secrets, []string{"dir/bar", "foo"})
+}
@@ -15,6 +15,7 @@ import (
 type RepoLSCommand struct {
 	useTimestamps bool
 	quiet         bool
+	format        listFormat
 	workspace     api.Namespace
 	io            ui.IO
 	timeFormatter TimeFormatter
@@ -36,6 +37,7 @@ func (cmd *RepoLSCommand) Register(r command.Registerer) {
 	clause.Flag("quiet", "Only print paths.").Short('q').BoolVar(&cmd.quiet)
 	clause.Arg("workspace", "When supplied, results are limited to repositories in this workspace.").SetValue(&cmd.workspace)
 	registerTimestampFlag(clause).BoolVar(&cmd.useTimestamps)
+	cmd.format.registerWithTemplate(clause)
 
 	command.BindAction(clause, cmd.Run)
 }
@@ -48,7 +50,7 @@ func (cmd *RepoLSCommand) Run() error {
 
 // beforeRun configures the command using the flag values.
 func (cmd *RepoLSCommand) beforeRun() {
-	cmd.timeFormatter = NewTimeFormatter(cmd.useTimestamps)
+	cmd.timeFormatter = NewTimeFormatter(cmd.useTimestamps || !cmd.format.isTable())
 }
 
 // run lists the repositories a user has access to.
@@ -77,6 +79,17 @@ func (cmd *RepoLSCommand) run() error {
 		for _, repo := range list {
 			fmt.Fprintf(cmd.io.Output(), "%s\n", repo.Path())
 		}
+	} else if !cmd.format.isTable() {
+		formatter, err := cmd.format.newItemFormatter(cmd.io.Output(), "name", "status", "created at")
+		if err != nil {
+			return err
+		}
+		for _, repo := range list {
+			err = formatter.Write(repo, []string{repo.Path().String(), repo.Status, cmd.timeFormatter.Format(repo.CreatedAt.Local())})
+			if err != nil {
+				return err
+			}
+		}
 	} else {
 		w := tabwriter.NewWriter(cmd.io.Output(), 0, 2, 2, ' ', 0)
 		fmt.Fprintf(w, "%s\t%s\t%s\n", "NAME", "STATUS", "CREATED")
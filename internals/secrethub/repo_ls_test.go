@@ -103,6 +103,27 @@ func TestRepoLSCommand_run(t *testing.T) {
 			out: "NAME             STATUS  CREATED\n" +
 				"dev1/repository  ok      2018-01-01T01:01:01+01:00\n",
 		},
+		"success json": {
+			cmd: RepoLSCommand{
+				timeFormatter: &fakes.TimeFormatter{
+					Response: "2018-01-01T01:01:01+01:00",
+				},
+				format: formatJSON,
+			},
+			repoService: fakeclient.RepoService{
+				ListMineFunc: func() ([]*api.Repo, error) {
+					return []*api.Repo{
+						{
+							Owner:     "dev1",
+							Name:      "repository",
+							Status:    api.StatusOK,
+							CreatedAt: testTime,
+						},
+					}, nil
+				},
+			},
+			out: `{"CreatedAt":"2018-01-01T01:01:01+01:00","Name":"dev1/repository","Status":"ok"}` + "\n",
+		},
 		"new client error": {
 			newClientErr: testErr,
 			err:          testErr,
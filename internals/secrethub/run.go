@@ -2,13 +2,18 @@ package secrethub
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"os/signal"
+	"sort"
+	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/secrethub/secrethub-cli/internals/cli/masker"
+	"github.com/secrethub/secrethub-cli/internals/cli/pty"
 	"github.com/secrethub/secrethub-cli/internals/secrethub/tpl"
 
 	"github.com/secrethub/secrethub-cli/internals/cli/ui"
@@ -24,13 +29,18 @@ var (
 	errRun                    = errio.Namespace("run")
 	ErrStartFailed            = errRun.Code("start_failed").ErrorPref("error while starting process: %s")
 	ErrSignalFailed           = errRun.Code("signal_failed").ErrorPref("error while propagating signal to process: %s")
+	ErrTakeForegroundFailed   = errRun.Code("take_foreground_failed").ErrorPref("could not move the run command back to the foreground of the terminal: %s")
 	ErrReadEnvDir             = errRun.Code("env_dir_read_error").ErrorPref("could not read the environment directory: %s")
 	ErrReadEnvFile            = errRun.Code("env_file_read_error").ErrorPref("could not read the environment file %s: %s")
 	ErrReadDefaultEnvFile     = errRun.Code("default_env_file_read_error").ErrorPref("could not read default run env-file %s: %s")
+	ErrEnvDirNameConflict     = errRun.Code("env_dir_name_conflict").ErrorPref("secrets %s and %s in directory %s both map to environment variable %s")
 	ErrTemplate               = errRun.Code("invalid_template").ErrorPref("could not parse template at line %d: %s")
 	ErrParsingTemplate        = errRun.Code("template_parsing_failed").ErrorPref("error while processing template file '%s': %s")
 	ErrInvalidTemplateVar     = errRun.Code("invalid_template_var").ErrorPref("template variable '%s' is invalid: template variables may only contain uppercase letters, digits, and the '_' (underscore) and are not allowed to start with a number")
 	ErrSecretsNotAllowedInKey = errRun.Code("secret_in_key").Error("secrets are not allowed in run template keys")
+	ErrUnknownSignal          = errRun.Code("unknown_signal").ErrorPref("unknown signal: %s")
+	ErrInvalidWatchInterval   = errRun.Code("invalid_watch_interval").Error("the watch interval must be a positive duration")
+	ErrInvalidRestartPolicy   = errRun.Code("invalid_restart_policy").ErrorPref("invalid restart policy %s: the options are no and on-failure")
 )
 
 const (
@@ -42,6 +52,9 @@ const (
 	// prefix of the values of environment variables that will be
 	// substituted with secrets
 	secretReferencePrefix = "secrethub://"
+	// stopGracePeriod is the default time a process gets to exit after it has been
+	// requested to stop, before it is killed.
+	stopGracePeriod = 10 * time.Second
 )
 
 // RunCommand runs a program and passes environment variables to it that are
@@ -53,44 +66,104 @@ type RunCommand struct {
 	command              []string
 	environment          *environment
 	noMasking            bool
+	noPTY                bool
+	terminal             *runTerminal
 	maskerOptions        masker.Options
 	newClient            newClientFunc
 	ignoreMissingSecrets bool
+	watch                bool
+	watchInterval        time.Duration
+	reloadSignal         string
+	cacheOptions         secretCacheOptions
+	secretCache          *secretCache
+	asFiles              bool
+	secretsDirParent     string
+	secretsDir           *secretsDir
+	gracePeriod          time.Duration
+	restart              string
+	timeout              time.Duration
 }
 
 // NewRunCommand creates a new RunCommand.
-func NewRunCommand(io ui.IO, newClient newClientFunc) *RunCommand {
+func NewRunCommand(io ui.IO, newClient newClientFunc, credentialStore CredentialConfig) *RunCommand {
 	return &RunCommand{
 		io:          io,
 		osEnv:       os.Environ(),
-		environment: newEnvironment(io),
+		environment: newEnvironment(io, newClient),
 		newClient:   newClient,
+		cacheOptions: secretCacheOptions{
+			credentialStore: credentialStore,
+		},
 	}
 }
 
 // Register registers the command, arguments and flags on the provided Registerer.
 func (cmd *RunCommand) Register(r command.Registerer) {
 	const helpShort = "Pass secrets as environment variables to a process."
-	const helpLong = "To protect against secrets leaking via stdout and stderr, those output streams are monitored for secrets. Detected secrets are automatically masked by replacing them with \"" + maskString + "\". " +
+	const helpLong = "To protect against secrets leaking via stdout and stderr, those output streams are monitored for secrets. Detected secrets are automatically masked by replacing them with \"" + maskString + "\", which can be changed with the mask-format flag. " +
 		"The output is buffered to scan for secrets and can be adjusted using the masking-buffer-period flag. " +
-		"You should regard the masking as a best effort attempt and should always prevent secrets ending up on stdout and stderr in the first place."
+		"You should regard the masking as a best effort attempt and should always prevent secrets ending up on stdout and stderr in the first place. " +
+		"When masking is enabled and stdout is a terminal, the process is run in a pseudo-terminal, so that it can still be used interactively. Its stdout and stderr are then both written to stdout. " +
+		"Otherwise, the process is started in its own process group when stdin is not a terminal, so that received signals are passed on to the process and all processes it started. " +
+		"The exit code of the process is returned, or 128 plus the number of the signal when the process is terminated by a signal."
 
 	clause := r.Command("run", helpShort)
 	clause.HelpLong(helpLong)
 	clause.Alias("exec")
 	clause.Arg("command", "The command to execute").Required().StringsVar(&cmd.command)
 	clause.Flag("no-masking", "Disable masking of secrets on stdout and stderr").BoolVar(&cmd.noMasking)
+	clause.Flag("no-pty", "Do not run the process in a pseudo-terminal when masking output to a terminal. Its stdout and stderr are then masked separately, but the process cannot detect that its output is a terminal.").BoolVar(&cmd.noPTY)
 	clause.Flag("no-output-buffering", "Disable output buffering. This increases output responsiveness, but decreases the probability that secrets get masked.").BoolVar(&cmd.maskerOptions.DisableBuffer)
 	clause.Flag("masking-buffer-period", "The time period for which output is buffered. A higher value increases the probability that secrets get masked but decreases output responsiveness.").Default("50ms").DurationVar(&cmd.maskerOptions.BufferDelay)
+	clause.Flag("mask-encodings", "Also mask secrets that are written base64 encoded, URL encoded, JSON escaped or hex encoded.").BoolVar(&cmd.maskerOptions.MaskEncodings)
+	clause.Flag("mask-format", "The text that replaces masked secrets. "+masker.NamePlaceholder+" is replaced by the name of the environment variable containing the secret and "+masker.HashPlaceholder+" by a short hash of that name, e.g. \"<redacted:"+masker.NamePlaceholder+">\".").Default(maskString).StringVar(&cmd.maskerOptions.Format)
 	clause.Flag("ignore-missing-secrets", "Do not return an error when a secret does not exist and use an empty value instead.").BoolVar(&cmd.ignoreMissingSecrets)
+	clause.Flag("watch", "Periodically check the secrets for changes. When the value of a secret changes, the process is restarted with the new environment.").BoolVar(&cmd.watch)
+	clause.Flag("watch-interval", "The time between two checks for changed secrets. Only used in combination with --watch.").Default("1m").DurationVar(&cmd.watchInterval)
+	clause.Flag("reload-signal", "Send this signal to the process instead of restarting it when a secret changes, e.g. SIGHUP. Only used in combination with --watch.").StringVar(&cmd.reloadSignal)
+	clause.Flag("as-files", "Pass secrets to the process as files instead of environment variables. Every environment variable containing a secret is written to a read-only file in a private directory on "+defaultSecretsDirParent+" and the process receives a NAME"+secretFileEnvVarSuffix+" variable with the path to that file instead. The directory is shredded when the process exits.").BoolVar(&cmd.asFiles)
+	clause.Flag("secrets-dir", "The memory backed (tmpfs or ramfs) directory in which the private directory with secret files is created. Implies --as-files.").PlaceHolder(defaultSecretsDirParent).StringVar(&cmd.secretsDirParent)
+	clause.Flag("grace-period", "The time the process gets to exit after it has been requested to stop, before it is killed.").Default(stopGracePeriod.String()).DurationVar(&cmd.gracePeriod)
+	clause.Flag("restart", "Restart the process when it exits. The options are no and on-failure, which restarts the process when it exits with a non-zero exit code. Consecutive restarts are delayed with an exponential backoff from "+minRestartDelay.String()+" up to "+maxRestartDelay.String()+".").HintOptions(restartNever, restartOnFailure).Default(restartNever).StringVar(&cmd.restart)
+	clause.Flag("timeout", "Stop the process when it has not finished within the given duration, e.g. 30m. The exit code is then "+strconv.Itoa(timeoutExitCode)+".").DurationVar(&cmd.timeout)
 	cmd.environment.register(clause)
+	cmd.cacheOptions.register(clause)
 	command.BindAction(clause, cmd.Run)
 }
 
 // Run reads files from the .secretsenv/<env-name> directory, sets them as environment variables and runs the given command.
 // Note that the environment variables are only passed to the child process and not exported globally, which is nice.
 func (cmd *RunCommand) Run() error {
-	environment, secrets, err := cmd.sourceEnvironment()
+	if cmd.restart != "" && cmd.restart != restartNever && cmd.restart != restartOnFailure {
+		return ErrInvalidRestartPolicy(cmd.restart)
+	}
+
+	var reloadSignal os.Signal
+	if cmd.watch {
+		if cmd.watchInterval <= 0 {
+			return ErrInvalidWatchInterval
+		}
+
+		if cmd.reloadSignal != "" {
+			var err error
+			reloadSignal, err = parseSignal(cmd.reloadSignal)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	envValues, err := cmd.environment.env()
+	if err != nil {
+		return err
+	}
+
+	cmd.secretCache, err = cmd.cacheOptions.open()
+	if err != nil {
+		return err
+	}
+
+	environment, secrets, err := cmd.resolveEnvironment(envValues)
 	if err != nil {
 		return err
 	}
@@ -100,53 +173,209 @@ func (cmd *RunCommand) Run() error {
 		cmd.command = strings.Split(cmd.command[0], " ")
 	}
 
-	sequences := make([][]byte, 0, len(secrets))
-	for _, val := range secrets {
-		if val != "" {
-			sequences = append(sequences, []byte(val))
+	if cmd.asFiles || cmd.secretsDirParent != "" {
+		cmd.secretsDir, err = newSecretsDir(cmd.secretsDirParent)
+		if err != nil {
+			return err
 		}
+		defer func() {
+			_ = cmd.secretsDir.shred()
+		}()
 	}
-	m := masker.New(sequences, &cmd.maskerOptions)
+	secretNames := secretEnvNames(envValues)
 
-	command := exec.Command(cmd.command[0], cmd.command[1:]...)
-	command.Env = environment
-	command.Stdin = os.Stdin
-	if cmd.noMasking {
-		command.Stdout = cmd.io.Stdout()
-		command.Stderr = os.Stderr
-	} else {
-		command.Stdout = m.AddStream(cmd.io.Stdout())
-		command.Stderr = m.AddStream(os.Stderr)
+	masked := make(map[string]struct{})
+	m := masker.New(newMaskSequences(secrets, masked), &cmd.maskerOptions)
+
+	var stdout, stderr io.Writer = cmd.io.Stdout(), os.Stderr
+	if !cmd.noMasking {
+		if !cmd.noPTY && pty.Supported() && !cmd.io.IsOutputPiped() {
+			cmd.terminal, err = newRunTerminal(cmd.io.Stdin(), cmd.io.Stdout())
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = cmd.terminal.restore()
+			}()
+			stdout = cmd.terminal.output(stdout)
+		}
+
+		stdout = m.AddStream(stdout)
+		stderr = m.AddStream(stderr)
 
 		go m.Start()
 	}
 
-	err = command.Start()
+	childEnv, err := cmd.childEnvironment(environment, secretNames)
 	if err != nil {
-		return ErrStartFailed(err)
+		return err
 	}
 
-	done := make(chan bool, 1)
-
 	// Pass all signals to child process
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals)
+	// The process is moved to the foreground of the terminal, so the run command must not be stopped
+	// when it writes the output of the process or takes back the terminal from the background.
+	ignoreBackgroundSignals()
+
+	process, err := cmd.startCommand(childEnv, stdout, stderr)
+	if err != nil {
+		signal.Stop(signals)
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	var changes <-chan resolvedEnvironment
+	if cmd.watch {
+		changes = cmd.watchEnvironment(envValues, environment, done)
+	}
+
+	var timeout <-chan time.Time
+	if cmd.timeout > 0 {
+		timer := time.NewTimer(cmd.timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	backoff := newRestartBackoff()
+	// restart fires when a failed process should be restarted, while process is nil.
+	var restart <-chan time.Time
+	// kill fires when the process has not exited within the grace period after it has been requested to stop.
+	var kill <-chan time.Time
+	stopping := false
+	// interrupted is set when the process has received an interrupt signal. As interactive
+	// processes can handle interrupts without exiting, only a second interrupt stops the process.
+	interrupted := false
+	timedOut := false
+
+	var commandErr error
+	running := true
+	for running {
+		var exited <-chan error
+		if process != nil {
+			exited = process.exited
+		}
 
-	go func() {
 		select {
 		case s := <-signals:
-			err := command.Process.Signal(s)
+			// The process is notified of window size changes by its pseudo-terminal.
+			if cmd.terminal != nil && s == windowChangeSignal {
+				cmd.terminal.resize()
+				continue
+			}
+
+			if process == nil {
+				// Do not restart the process when the run command is requested to stop.
+				if isStopSignal(s) {
+					running = false
+				}
+				continue
+			}
+
+			err := process.signal(s)
 			if err != nil && !strings.Contains(err.Error(), "process already finished") {
 				fmt.Fprintln(os.Stderr, ErrSignalFailed(err))
 			}
-		case <-done:
-			signal.Stop(signals)
-			return
-		}
-	}()
 
-	commandErr := command.Wait()
-	done <- true
+			if s == syscall.SIGTERM || (s == os.Interrupt && interrupted) {
+				if !stopping {
+					stopping = true
+					kill = time.After(cmd.gracePeriod)
+				}
+			}
+			if s == os.Interrupt {
+				interrupted = true
+			}
+		case <-kill:
+			kill = nil
+			err := process.kill()
+			if err != nil && !strings.Contains(err.Error(), "process already finished") {
+				fmt.Fprintln(os.Stderr, ErrSignalFailed(err))
+			}
+		case <-timeout:
+			fmt.Fprintf(os.Stderr, "The process did not finish within %s and is stopped.\n", cmd.timeout)
+			timedOut = true
+			if process == nil {
+				running = false
+				break
+			}
+
+			err := process.signal(syscall.SIGTERM)
+			if err != nil {
+				_ = process.kill()
+			}
+			if !stopping {
+				stopping = true
+				kill = time.After(cmd.gracePeriod)
+			}
+		case change := <-changes:
+			m.AddSequences(newMaskSequences(change.secrets, masked))
+
+			if reloadSignal != nil {
+				env, err := cmd.childEnvironment(change.environment, secretNames)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					continue
+				}
+				childEnv = env
+
+				if process == nil {
+					continue
+				}
+
+				err = process.signal(reloadSignal)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, ErrSignalFailed(err))
+				}
+				continue
+			}
+
+			if stopping {
+				continue
+			}
+
+			fmt.Fprintln(os.Stderr, "A secret has changed, restarting the process.")
+			if process != nil {
+				_ = process.stop(cmd.gracePeriod)
+			}
+			restart = nil
+
+			var err error
+			childEnv, err = cmd.childEnvironment(change.environment, secretNames)
+			if err == nil {
+				process, err = cmd.startCommand(childEnv, stdout, stderr)
+				interrupted = false
+			}
+			if err != nil {
+				commandErr = err
+				running = false
+				break
+			}
+		case commandErr = <-exited:
+			kill = nil
+
+			// A process that exits after it has been interrupted is not restarted.
+			if !stopping && !interrupted && cmd.restart == restartOnFailure && commandErr != nil {
+				delay := backoff.next(time.Since(process.started))
+				fmt.Fprintf(os.Stderr, "The process failed: %s. Restarting it in %s.\n", commandErr, delay)
+				process = nil
+				restart = time.After(delay)
+				continue
+			}
+			running = false
+		case <-restart:
+			restart = nil
+
+			process, commandErr = cmd.startCommand(childEnv, stdout, stderr)
+			interrupted = false
+			if commandErr != nil {
+				running = false
+			}
+		}
+	}
+	signal.Stop(signals)
 
 	if !cmd.noMasking {
 		err := m.Stop()
@@ -155,17 +384,31 @@ func (cmd *RunCommand) Run() error {
 		}
 	}
 
+	// The terminal is restored and the secrets directory is shredded explicitly,
+	// so that errors are returned.
+	if cmd.terminal != nil {
+		err := cmd.terminal.restore()
+		if err != nil {
+			return err
+		}
+	}
+
+	if cmd.secretsDir != nil {
+		err := cmd.secretsDir.shred()
+		if err != nil {
+			return err
+		}
+	}
+
+	if timedOut {
+		return ExitError{Code: timeoutExitCode}
+	}
+
 	if commandErr != nil {
-		// Check if the program exited with an error
-		exitErr, ok := commandErr.(*exec.ExitError)
+		// Return the exit code of the process if it exited with an error.
+		code, ok := exitCode(commandErr)
 		if ok {
-			waitStatus, ok := exitErr.Sys().(syscall.WaitStatus)
-			if ok {
-				// Return the status code returned by the process
-				os.Exit(waitStatus.ExitStatus())
-				return nil
-			}
-
+			return ExitError{Code: code}
 		}
 		return commandErr
 	}
@@ -173,34 +416,226 @@ func (cmd *RunCommand) Run() error {
 	return nil
 }
 
+// childEnvironment returns the environment to start the command with. When secrets are passed as files,
+// the environment variables with the given names are written to the secrets directory and replaced by
+// variables containing the paths to these files.
+func (cmd *RunCommand) childEnvironment(environment []string, secretNames map[string]struct{}) ([]string, error) {
+	if cmd.secretsDir == nil {
+		return environment, nil
+	}
+	return cmd.secretsDir.write(environment, secretNames)
+}
+
+// secretEnvNames returns the names of the environment values that contain a secret.
+func secretEnvNames(envValues map[string]value) map[string]struct{} {
+	names := make(map[string]struct{})
+	for name, value := range envValues {
+		if value.containsSecret() {
+			names[name] = struct{}{}
+		}
+	}
+	return names
+}
+
+// startCommand starts the command to run with the given environment and output streams.
+// When the command is run in a pseudo-terminal, both its stdout and stderr are written to stdout.
+// It returns a channel on which the result of the command is sent when it exits.
+func (cmd *RunCommand) startCommand(environment []string, stdout, stderr io.Writer) (*runProcess, error) {
+	command := exec.Command(cmd.command[0], cmd.command[1:]...)
+	command.Env = environment
+
+	if cmd.terminal != nil {
+		// The process is started in a new session, so it leads its own process group.
+		exited, err := cmd.terminal.start(command, stdout)
+		if err != nil {
+			return nil, ErrStartFailed(err)
+		}
+		return &runProcess{command: command, exited: exited, group: true, started: time.Now()}, nil
+	}
+
+	command.Stdin = os.Stdin
+	command.Stdout = stdout
+	command.Stderr = stderr
+
+	// The process is started in its own process group, so signals are sent to all processes it starts.
+	// Processes that are not in the foreground process group of the terminal are stopped when they read
+	// from it, so the group is moved to the foreground when the run command is in the foreground itself.
+	foreground := isForeground(os.Stdin)
+	if foreground {
+		setForegroundProcessGroup(command, os.Stdin)
+	} else {
+		setProcessGroup(command)
+	}
+
+	err := command.Start()
+	if err != nil {
+		return nil, ErrStartFailed(err)
+	}
+
+	exited := waitForExit(command)
+	if foreground {
+		exited = takeForegroundOnExit(exited, os.Stdin)
+	}
+	return &runProcess{command: command, exited: exited, group: true, started: time.Now()}, nil
+}
+
+// waitForExit waits for the command to exit in the background and
+// sends the result on the returned channel when it does.
+func waitForExit(command *exec.Cmd) <-chan error {
+	exited := make(chan error, 1)
+	go func() {
+		exited <- command.Wait()
+	}()
+	return exited
+}
+
+// takeForegroundOnExit moves the process group of the run command back to the foreground of the terminal
+// when the process has exited, before passing on its result. The run command then receives the input and
+// signals from the terminal again, e.g. while it waits to restart the process.
+func takeForegroundOnExit(exited <-chan error, tty *os.File) <-chan error {
+	res := make(chan error, 1)
+	go func() {
+		err := <-exited
+		takeErr := takeForeground(tty)
+		if takeErr != nil {
+			fmt.Fprintln(os.Stderr, ErrTakeForegroundFailed(takeErr))
+		}
+		res <- err
+	}()
+	return res
+}
+
+// resolvedEnvironment is the environment of the subcommand together with
+// the secret values it contains.
+type resolvedEnvironment struct {
+	environment []string
+	secrets     []masker.Sequence
+}
+
+// watchEnvironment resolves the given environment values every watch interval and sends
+// the result on the returned channel whenever it differs from the previously resolved environment.
+// The environment is watched until done is closed.
+func (cmd *RunCommand) watchEnvironment(envValues map[string]value, current []string, done <-chan struct{}) <-chan resolvedEnvironment {
+	changes := make(chan resolvedEnvironment)
+	go func() {
+		ticker := time.NewTicker(cmd.watchInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+
+			environment, secrets, err := cmd.resolveEnvironment(envValues)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Could not check the secrets for changes: %s\n", err)
+				continue
+			}
+
+			if equalEnvironments(environment, current) {
+				continue
+			}
+			current = environment
+
+			select {
+			case changes <- resolvedEnvironment{environment: environment, secrets: secrets}:
+			case <-done:
+				return
+			}
+		}
+	}()
+	return changes
+}
+
+// equalEnvironments returns whether the given environments contain the same key=value pairs.
+func equalEnvironments(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	sortedA := append([]string{}, a...)
+	sortedB := append([]string{}, b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+
+	for i := range sortedA {
+		if sortedA[i] != sortedB[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// newMaskSequences returns the sequences to mask for the given secrets
+// that are not empty and not yet in the set of masked values.
+// The returned values are added to the set.
+func newMaskSequences(secrets []masker.Sequence, masked map[string]struct{}) []masker.Sequence {
+	sequences := make([]masker.Sequence, 0, len(secrets))
+	for _, secret := range secrets {
+		if _, ok := masked[string(secret.Value)]; ok || len(secret.Value) == 0 {
+			continue
+		}
+		masked[string(secret.Value)] = struct{}{}
+		sequences = append(sequences, secret)
+	}
+	return sequences
+}
+
+// resolvedSecrets returns the secrets read by the given secret reader as sequences to mask with the given name.
+func resolvedSecrets(secretReader *bufferedSecretReader, name string) []masker.Sequence {
+	values := secretReader.Values()
+	secrets := make([]masker.Sequence, len(values))
+	for i, value := range values {
+		secrets[i] = masker.Sequence{Value: []byte(value), Name: name}
+	}
+	return secrets
+}
+
 // sourceEnvironment returns the environment of the subcommand, with all the secrets sourced
-// and the secret values that need to be masked.
-func (cmd *RunCommand) sourceEnvironment() ([]string, []string, error) {
+// and the secrets that need to be masked.
+func (cmd *RunCommand) sourceEnvironment() ([]string, []masker.Sequence, error) {
+	envValues, err := cmd.environment.env()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cmd.resolveEnvironment(envValues)
+}
+
+// resolveEnvironment resolves the given environment values and returns the environment
+// of the subcommand and the secrets that need to be masked, named by the environment variable they are used in.
+func (cmd *RunCommand) resolveEnvironment(envValues map[string]value) ([]string, []masker.Sequence, error) {
 	_, passthroughEnv := parseKeyValueStringsToMap(cmd.osEnv)
 	newEnv := map[string]string{}
 
-	envValues, err := cmd.environment.env()
+	paths, err := collectSecretPaths(envValues)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	var sr tpl.SecretReader = newSecretReader(cmd.newClient)
+	prefetchReader := newPrefetchSecretReader(newCachedSecretReader(cmd.newClient, cmd.secretCache))
+	prefetchReader.Prefetch(paths)
+
+	var sr tpl.SecretReader = newFieldSecretReader(prefetchReader)
 	if cmd.ignoreMissingSecrets {
 		sr = newIgnoreMissingSecretReader(sr)
 	}
-	secretReader := newBufferedSecretReader(sr)
-
+	secrets := []masker.Sequence{}
 	for name, value := range envValues {
+		secretReader := newBufferedSecretReader(sr)
 		newEnv[name], err = value.resolve(secretReader)
 		if err != nil {
 			return nil, nil, err
 		}
+		secrets = append(secrets, resolvedSecrets(secretReader, name)...)
 	}
 
 	// Finally add the unparsed variables
 	processedOsEnv := append(passthroughEnv, mapToKeyValueStrings(newEnv)...)
 
-	return processedOsEnv, secretReader.Values(), nil
+	return processedOsEnv, secrets, nil
 }
 
 // mapToKeyValueStrings converts a map to a slice of key=value pairs.
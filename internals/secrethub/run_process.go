@@ -0,0 +1,127 @@
+package secrethub
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+const (
+	// restartNever disables restarting the process when it exits.
+	restartNever = "no"
+	// restartOnFailure restarts the process when it exits with a non-zero exit code or is terminated by a signal.
+	restartOnFailure = "on-failure"
+
+	// minRestartDelay is the time to wait before the first restart of a failed process.
+	// The delay is doubled for every consecutive failure, up to maxRestartDelay.
+	minRestartDelay = time.Second
+	maxRestartDelay = time.Minute
+
+	// timeoutExitCode is the exit code when the process is stopped because it did not finish within the timeout.
+	timeoutExitCode = 124
+)
+
+// runProcess is a process started by the run command.
+type runProcess struct {
+	command *exec.Cmd
+	exited  <-chan error
+	// group is true when the process leads its own process group,
+	// in which case signals are sent to all processes in the group.
+	group   bool
+	started time.Time
+}
+
+// signal sends the given signal to the process or its process group.
+func (p *runProcess) signal(sig os.Signal) error {
+	if p.group {
+		return signalProcessGroup(p.command.Process, sig)
+	}
+	return p.command.Process.Signal(sig)
+}
+
+// kill kills the process or its process group.
+func (p *runProcess) kill() error {
+	if p.group {
+		return killProcessGroup(p.command.Process)
+	}
+	return p.command.Process.Kill()
+}
+
+// stop requests the process to terminate and waits for it to exit.
+// When the process has not exited after the grace period, it is killed.
+// The result of waiting for the process is returned.
+func (p *runProcess) stop(gracePeriod time.Duration) error {
+	err := p.signal(syscall.SIGTERM)
+	if err != nil {
+		_ = p.kill()
+	}
+
+	select {
+	case err = <-p.exited:
+		return err
+	case <-time.After(gracePeriod):
+		_ = p.kill()
+		return <-p.exited
+	}
+}
+
+// exitCode returns the exit code of a process for the given result of waiting for it and
+// whether the result is an exit status at all. As is common for shells, the exit code
+// of a process that is terminated by a signal is 128 plus the number of the signal.
+func exitCode(err error) (int, bool) {
+	if err == nil {
+		return 0, true
+	}
+
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		return 0, false
+	}
+
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	if !ok {
+		return exitErr.ExitCode(), true
+	}
+
+	if status.Signaled() {
+		return 128 + int(status.Signal()), true
+	}
+	return status.ExitStatus(), true
+}
+
+// isStopSignal returns whether the given signal requests the process to stop.
+func isStopSignal(sig os.Signal) bool {
+	return sig == os.Interrupt || sig == syscall.SIGTERM
+}
+
+// restartBackoff determines the time to wait before restarting a failed process.
+type restartBackoff struct {
+	min   time.Duration
+	max   time.Duration
+	delay time.Duration
+}
+
+// newRestartBackoff returns a backoff that starts at the minimum restart delay.
+func newRestartBackoff() *restartBackoff {
+	return &restartBackoff{
+		min: minRestartDelay,
+		max: maxRestartDelay,
+	}
+}
+
+// next returns the delay before the next restart of a process that failed after running for the given duration.
+// The delay doubles for every consecutive failure, up to the maximum delay. A process that ran for at least
+// the maximum delay is considered to have started successfully, so the delay is reset to the minimum.
+func (b *restartBackoff) next(uptime time.Duration) time.Duration {
+	if b.delay == 0 || uptime >= b.max {
+		b.delay = b.min
+		return b.delay
+	}
+
+	b.delay *= 2
+	if b.delay > b.max {
+		b.delay = b.max
+	}
+	return b.delay
+}
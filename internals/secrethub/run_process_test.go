@@ -0,0 +1,126 @@
+package secrethub
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/secrethub/secrethub-go/internals/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	cases := map[string]struct {
+		command  []string
+		expected int
+	}{
+		"success": {
+			command:  []string{"sh", "-c", "exit 0"},
+			expected: 0,
+		},
+		"exit code": {
+			command:  []string{"sh", "-c", "exit 3"},
+			expected: 3,
+		},
+		"killed": {
+			command:  []string{"sh", "-c", "kill -KILL $$"},
+			expected: 128 + 9,
+		},
+		"terminated": {
+			command:  []string{"sh", "-c", "kill -TERM $$"},
+			expected: 128 + 15,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := exec.Command(tc.command[0], tc.command[1:]...).Run()
+
+			actual, ok := exitCode(err)
+			assert.Equal(t, ok, true)
+			assert.Equal(t, actual, tc.expected)
+		})
+	}
+}
+
+func TestRunProcess_stop(t *testing.T) {
+	cases := map[string]struct {
+		command  []string
+		group    bool
+		expected int
+	}{
+		"terminated": {
+			command:  []string{"sleep", "10"},
+			expected: 128 + 15,
+		},
+		"killed after grace period": {
+			command:  []string{"sh", "-c", "trap '' TERM; sleep 10"},
+			expected: 128 + 9,
+		},
+		"process group killed after grace period": {
+			command:  []string{"sh", "-c", "trap '' TERM; sleep 10 & wait"},
+			group:    true,
+			expected: 128 + 9,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			command := exec.Command(tc.command[0], tc.command[1:]...)
+			if tc.group {
+				setProcessGroup(command)
+			}
+			err := command.Start()
+			assert.OK(t, err)
+
+			process := &runProcess{
+				command: command,
+				exited:  waitForExit(command),
+				group:   tc.group,
+				started: time.Now(),
+			}
+			// Give the shell time to install its signal handlers.
+			time.Sleep(100 * time.Millisecond)
+
+			actual, ok := exitCode(process.stop(100 * time.Millisecond))
+			assert.Equal(t, ok, true)
+			assert.Equal(t, actual, tc.expected)
+		})
+	}
+}
+
+func TestRestartBackoff(t *testing.T) {
+	cases := map[string]struct {
+		uptimes  []time.Duration
+		expected []time.Duration
+	}{
+		"first restart": {
+			uptimes:  []time.Duration{0},
+			expected: []time.Duration{time.Second},
+		},
+		"consecutive failures": {
+			uptimes:  []time.Duration{0, 0, 0, 0},
+			expected: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second},
+		},
+		"maximum delay": {
+			uptimes:  []time.Duration{0, 0, 0, 0, 0, 0, 0, 0},
+			expected: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second, 32 * time.Second, time.Minute, time.Minute},
+		},
+		"reset after running long enough": {
+			uptimes:  []time.Duration{0, 0, 0, time.Minute, 0},
+			expected: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, time.Second, 2 * time.Second},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			backoff := newRestartBackoff()
+
+			actual := make([]time.Duration, len(tc.uptimes))
+			for i, uptime := range tc.uptimes {
+				actual[i] = backoff.next(uptime)
+			}
+
+			assert.Equal(t, actual, tc.expected)
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package secrethub
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh/terminal"
+
+	"github.com/secrethub/secrethub-cli/internals/cli/pty"
+)
+
+// ptyDrainTimeout is the time that is waited for the remaining output of the
+// pseudo-terminal after the command has exited. Processes started in the
+// background by the command can keep the pseudo-terminal open after it exited.
+const ptyDrainTimeout = time.Second
+
+// runTerminal runs commands in a pseudo-terminal, so that they can be interactive
+// while their output is masked. Input from the terminal of the CLI is passed on to
+// the pseudo-terminal of the running command and its window size is kept in sync.
+type runTerminal struct {
+	stdin    *os.File
+	stdout   *os.File
+	rawInput bool
+	state    *terminal.State
+
+	mutex sync.Mutex
+	pty   *os.File
+}
+
+// newRunTerminal creates a runTerminal for the given stdin and stdout of the CLI.
+// When stdin is a terminal, it is put in raw mode and its input is passed on to the
+// pseudo-terminal of the running command. Otherwise, stdin is passed on to the command as is.
+func newRunTerminal(stdin *os.File, stdout *os.File) (*runTerminal, error) {
+	t := &runTerminal{
+		stdin:    stdin,
+		stdout:   stdout,
+		rawInput: terminal.IsTerminal(int(stdin.Fd())),
+	}
+
+	if t.rawInput {
+		state, err := terminal.MakeRaw(int(stdin.Fd()))
+		if err != nil {
+			return nil, err
+		}
+		t.state = state
+
+		go func() {
+			_, _ = io.Copy(t, stdin)
+		}()
+	}
+
+	return t, nil
+}
+
+// start starts the command in a new pseudo-terminal and copies its output to the given writer.
+// It returns a channel on which the result of the command is sent when it has exited and its
+// output has been copied.
+func (t *runTerminal) start(command *exec.Cmd, output io.Writer) (<-chan error, error) {
+	if !t.rawInput {
+		command.Stdin = t.stdin
+	}
+
+	master, err := pty.Start(command)
+	if err != nil {
+		return nil, err
+	}
+	_ = pty.InheritSize(t.stdout, master)
+
+	t.mutex.Lock()
+	t.pty = master
+	t.mutex.Unlock()
+
+	copied := make(chan struct{})
+	go func() {
+		// Reading from the pseudo-terminal returns an error instead of
+		// io.EOF on some platforms when the command has exited.
+		_, _ = io.Copy(output, master)
+		close(copied)
+	}()
+
+	exited := make(chan error, 1)
+	go func() {
+		err := command.Wait()
+
+		select {
+		case <-copied:
+		case <-time.After(ptyDrainTimeout):
+		}
+
+		t.mutex.Lock()
+		if t.pty == master {
+			t.pty = nil
+		}
+		t.mutex.Unlock()
+
+		_ = master.Close()
+		exited <- err
+	}()
+	return exited, nil
+}
+
+// Write passes input on to the pseudo-terminal of the running command.
+// Input is discarded when no command is running.
+func (t *runTerminal) Write(p []byte) (int, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.pty == nil {
+		return len(p), nil
+	}
+	return t.pty.Write(p)
+}
+
+// resize sets the window size of the pseudo-terminal of the running command to the window size of the terminal.
+func (t *runTerminal) resize() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.pty != nil {
+		_ = pty.InheritSize(t.stdout, t.pty)
+	}
+}
+
+// output returns the writer to which the masked output of the pseudo-terminal is written.
+// Output processing is disabled on the pseudo-terminal, so that the masker sees newlines as they are
+// written by the command. When the terminal of the CLI is in raw mode, it does not translate newlines
+// either, so the returned writer translates them into carriage return and newline pairs instead.
+func (t *runTerminal) output(w io.Writer) io.Writer {
+	if !t.rawInput {
+		return w
+	}
+	return &newlineTranslator{writer: w}
+}
+
+// newlineTranslator translates newlines that are not preceded by a carriage return
+// into carriage return and newline pairs, like a terminal with output processing enabled.
+type newlineTranslator struct {
+	writer io.Writer
+	// cr is set when the last byte written was a carriage return.
+	cr bool
+}
+
+// Write translates the newlines in p and writes the result to the underlying writer.
+func (t *newlineTranslator) Write(p []byte) (int, error) {
+	translated := make([]byte, 0, len(p))
+	for _, b := range p {
+		if b == '\n' && !t.cr {
+			translated = append(translated, '\r')
+		}
+		translated = append(translated, b)
+		t.cr = b == '\r'
+	}
+
+	_, err := t.writer.Write(translated)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// restore restores the terminal of the CLI to the state it was in before it was put in raw mode.
+func (t *runTerminal) restore() error {
+	if t.state == nil {
+		return nil
+	}
+	return terminal.Restore(int(t.stdin.Fd()), t.state)
+}
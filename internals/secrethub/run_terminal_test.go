@@ -0,0 +1,47 @@
+package secrethub
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/secrethub/secrethub-go/internals/assert"
+)
+
+func TestNewlineTranslator(t *testing.T) {
+	cases := map[string]struct {
+		writes   []string
+		expected string
+	}{
+		"newline": {
+			writes:   []string{"foo\nbar\n"},
+			expected: "foo\r\nbar\r\n",
+		},
+		"carriage return and newline": {
+			writes:   []string{"foo\r\nbar"},
+			expected: "foo\r\nbar",
+		},
+		"carriage return and newline across writes": {
+			writes:   []string{"foo\r", "\nbar\n"},
+			expected: "foo\r\nbar\r\n",
+		},
+		"no newline": {
+			writes:   []string{"foo", "bar"},
+			expected: "foobar",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			translator := &newlineTranslator{writer: &buf}
+
+			for _, write := range tc.writes {
+				n, err := translator.Write([]byte(write))
+				assert.OK(t, err)
+				assert.Equal(t, n, len(write))
+			}
+
+			assert.Equal(t, buf.String(), tc.expected)
+		})
+	}
+}
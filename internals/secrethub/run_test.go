@@ -8,8 +8,11 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/secrethub/secrethub-cli/internals/cli/masker"
 	"github.com/secrethub/secrethub-cli/internals/cli/ui/fakeui"
 
 	"github.com/secrethub/secrethub-cli/internals/secrethub/tpl"
@@ -561,8 +564,8 @@ func TestRunCommand_Run(t *testing.T) {
 		"invalid template var: start with a number": {
 			command: RunCommand{
 				environment: &environment{
-					osStat:  osStatNotExist,
-					envFile: "secrethub.env",
+					osStat:   osStatNotExist,
+					envFiles: []string{"secrethub.env"},
 					templateVars: map[string]string{
 						"0foo": "value",
 					},
@@ -574,8 +577,8 @@ func TestRunCommand_Run(t *testing.T) {
 		"invalid template var: illegal character": {
 			command: RunCommand{
 				environment: &environment{
-					osStat:  osStatNotExist,
-					envFile: "secrethub.env",
+					osStat:   osStatNotExist,
+					envFiles: []string{"secrethub.env"},
 					templateVars: map[string]string{
 						"foo@bar": "value",
 					},
@@ -628,6 +631,34 @@ func TestRunCommand_Run(t *testing.T) {
 				},
 			},
 		},
+		"exit code of process": {
+			command: RunCommand{
+				io: fakeui.NewIO(t),
+				environment: &environment{
+					osStat: osStatNotExist,
+				},
+				command: []string{"sh", "-c", "exit 3"},
+			},
+			err: ExitError{Code: 3},
+		},
+		"timeout": {
+			command: RunCommand{
+				io: fakeui.NewIO(t),
+				environment: &environment{
+					osStat: osStatNotExist,
+				},
+				command: []string{"sleep", "10"},
+				timeout: 100 * time.Millisecond,
+			},
+			err: ExitError{Code: timeoutExitCode},
+		},
+		"invalid restart policy": {
+			command: RunCommand{
+				command: []string{"echo", "test"},
+				restart: "always",
+			},
+			err: ErrInvalidRestartPolicy("always"),
+		},
 	}
 
 	for name, tc := range cases {
@@ -660,7 +691,7 @@ func TestRunCommand_environment(t *testing.T) {
 	cases := map[string]struct {
 		command         RunCommand
 		expectedEnv     []string
-		expectedSecrets []string
+		expectedSecrets []masker.Sequence
 		err             error
 	}{
 		"invalid template syntax": {
@@ -669,7 +700,7 @@ func TestRunCommand_environment(t *testing.T) {
 				environment: &environment{
 					osStat:          osStatFunc("secrethub.env", nil),
 					readFile:        readFileFunc("secrethub.env", "TEST={{path/to/secret}"),
-					envFile:         "secrethub.env",
+					envFiles:        []string{"secrethub.env"},
 					templateVersion: "2",
 				},
 			},
@@ -693,7 +724,7 @@ func TestRunCommand_environment(t *testing.T) {
 		"custom env file does not exist": {
 			command: RunCommand{
 				environment: &environment{
-					envFile: "foo.env",
+					envFiles: []string{"foo.env"},
 					readFile: func(filename string) ([]byte, error) {
 						if filename == "foo.env" {
 							return nil, &os.PathError{Op: "open", Path: "foo.env", Err: os.ErrNotExist}
@@ -708,20 +739,38 @@ func TestRunCommand_environment(t *testing.T) {
 			command: RunCommand{
 				environment: &environment{
 					osStat:          osStatFunc("foo.env", nil),
-					envFile:         "foo.env",
+					envFiles:        []string{"foo.env"},
 					templateVersion: "2",
 					readFile:        readFileFunc("foo.env", "TEST=test"),
 				},
 			},
 			expectedEnv: []string{"TEST=test"},
 		},
+		"layered env files": {
+			command: RunCommand{
+				environment: &environment{
+					envFiles:        []string{"base.env", "overlay.env"},
+					templateVersion: "2",
+					readFile: func(filename string) ([]byte, error) {
+						switch filename {
+						case "base.env":
+							return []byte("FOO=base\nBAR=base"), nil
+						case "overlay.env":
+							return []byte("BAR=overlay\nBAZ=overlay"), nil
+						}
+						return nil, os.ErrNotExist
+					},
+				},
+			},
+			expectedEnv: []string{"FOO=base", "BAR=overlay", "BAZ=overlay"},
+		},
 		"env file secret does not exist": {
 			command: RunCommand{
 				command: []string{"echo", "test"},
 				environment: &environment{
 					osStat:          osStatFunc("secrethub.env", nil),
 					readFile:        readFileFunc("secrethub.env", "TEST= {{ unexistent/secret/path }}"),
-					envFile:         "secrethub.env",
+					envFiles:        []string{"secrethub.env"},
 					templateVersion: "2",
 				},
 				newClient: func() (secrethub.ClientInterface, error) {
@@ -743,7 +792,7 @@ func TestRunCommand_environment(t *testing.T) {
 				environment: &environment{
 					osStat:   osStatFunc("secrethub.env", nil),
 					readFile: readFileFunc("secrethub.env", "TEST=aaa"),
-					envFile:  "secrethub.env",
+					envFiles: []string{"secrethub.env"},
 					envar: map[string]string{
 						"TEST": "test/test/test",
 					},
@@ -761,9 +810,44 @@ func TestRunCommand_environment(t *testing.T) {
 					}, nil
 				},
 			},
-			expectedSecrets: []string{"bbb"},
+			expectedSecrets: []masker.Sequence{{Value: []byte("bbb"), Name: "TEST"}},
 			expectedEnv:     []string{"TEST=bbb"},
 		},
+		"envar flag with field": {
+			command: RunCommand{
+				environment: &environment{
+					osStat: osStatFunc("secrethub.env", os.ErrNotExist),
+					envar: map[string]string{
+						"DB_PASS": "test/test/db#.password",
+					},
+				},
+				newClient: func() (secrethub.ClientInterface, error) {
+					return fakeclient.Client{
+						SecretService: &fakeclient.SecretService{
+							VersionService: &fakeclient.SecretVersionService{
+								GetWithDataFunc: func(path string) (*api.SecretVersion, error) {
+									assert.Equal(t, path, "test/test/db")
+									return &api.SecretVersion{Data: []byte(`{"user": "admin", "password": "bbb"}`)}, nil
+								},
+							},
+						},
+					}, nil
+				},
+			},
+			expectedSecrets: []masker.Sequence{{Value: []byte("bbb"), Name: "DB_PASS"}},
+			expectedEnv:     []string{"DB_PASS=bbb"},
+		},
+		"envar flag with invalid field": {
+			command: RunCommand{
+				environment: &environment{
+					osStat: osStatFunc("secrethub.env", os.ErrNotExist),
+					envar: map[string]string{
+						"DB_PASS": "test/test/db#password",
+					},
+				},
+			},
+			err: tpl.ErrInvalidSecretField("password"),
+		},
 		// TODO Add test case for: envar flag has precedence over secret reference - requires refactoring of fakeclient
 		"secret reference has precedence over .env file": {
 			command: RunCommand{
@@ -786,7 +870,7 @@ func TestRunCommand_environment(t *testing.T) {
 					}, nil
 				},
 			},
-			expectedSecrets: []string{"bbb"},
+			expectedSecrets: []masker.Sequence{{Value: []byte("bbb"), Name: "TEST"}},
 			expectedEnv:     []string{"TEST=bbb"},
 		},
 		".env file has precedence over other os variables": {
@@ -799,7 +883,7 @@ func TestRunCommand_environment(t *testing.T) {
 				},
 				osEnv: []string{"TEST=bbb"},
 			},
-			expectedSecrets: []string{},
+			expectedSecrets: []masker.Sequence{},
 			expectedEnv:     []string{"TEST=aaa"},
 		},
 		".env file secret has precedence over other os variables": {
@@ -823,7 +907,7 @@ func TestRunCommand_environment(t *testing.T) {
 					}, nil
 				},
 			},
-			expectedSecrets: []string{"aaa"},
+			expectedSecrets: []masker.Sequence{{Value: []byte("aaa"), Name: "TEST"}},
 			expectedEnv:     []string{"TEST=aaa"},
 		},
 		"ignore missing secrets": {
@@ -831,7 +915,7 @@ func TestRunCommand_environment(t *testing.T) {
 				ignoreMissingSecrets: true,
 				environment: &environment{
 					osStat:   osStatFunc("secrethub.env", nil),
-					envFile:  "secrethub.env",
+					envFiles: []string{"secrethub.env"},
 					readFile: readFileFunc("secrethub.env", ""),
 					envar: map[string]string{
 						"TEST": "test/test/test",
@@ -851,7 +935,7 @@ func TestRunCommand_environment(t *testing.T) {
 				},
 			},
 			expectedEnv:     []string{"TEST="},
-			expectedSecrets: []string{""},
+			expectedSecrets: []masker.Sequence{{Value: []byte(""), Name: "TEST"}},
 		},
 		"--no-prompt": {
 			command: RunCommand{
@@ -860,7 +944,7 @@ func TestRunCommand_environment(t *testing.T) {
 					osStat:                       osStatFunc("secrethub.env", nil),
 					readFile:                     readFileFunc("secrethub.env", "TEST = {{ test/$variable/test }}"),
 					dontPromptMissingTemplateVar: true,
-					envFile:                      "secrethub.env",
+					envFiles:                     []string{"secrethub.env"},
 					templateVersion:              "2",
 				},
 				newClient: func() (secrethub.ClientInterface, error) {
@@ -971,9 +1055,58 @@ func TestRunCommand_environment(t *testing.T) {
 					}, nil
 				},
 			},
-			expectedSecrets: []string{"bbb"},
+			expectedSecrets: []masker.Sequence{{Value: []byte("bbb"), Name: "TEST"}},
 			expectedEnv:     []string{"TEST=bbb"},
 		},
+		"env dir": {
+			command: RunCommand{
+				command: []string{"/bin/sh", "./test.sh"},
+				environment: &environment{
+					osStat:          osStatFunc("foo", nil),
+					secretDirs:      []string{"namespace/repo/app"},
+					secretDirPrefix: "APP_",
+					envar:           map[string]string{"APP_API_KEY": "namespace/repo/api_key"},
+					newClient: func() (secrethub.ClientInterface, error) {
+						return fakeclient.Client{
+							DirService: &fakeclient.DirService{
+								GetTreeFunc: func(path string, depth int, ancestors bool) (*api.Tree, error) {
+									return &api.Tree{
+										RootDir: &api.Dir{
+											Name:    "app",
+											Secrets: []*api.Secret{{Name: "db-password"}, {Name: "api_key"}},
+											SubDirs: []*api.Dir{
+												{Name: "tls", Secrets: []*api.Secret{{Name: "cert.pem"}}},
+											},
+										},
+									}, nil
+								},
+							},
+						}, nil
+					},
+				},
+				newClient: func() (secrethub.ClientInterface, error) {
+					return fakeclient.Client{
+						SecretService: &fakeclient.SecretService{
+							VersionService: &fakeclient.SecretVersionService{
+								GetWithDataFunc: func(path string) (*api.SecretVersion, error) {
+									return &api.SecretVersion{Data: []byte(path)}, nil
+								},
+							},
+						},
+					}, nil
+				},
+			},
+			expectedSecrets: []masker.Sequence{
+				{Value: []byte("namespace/repo/api_key"), Name: "APP_API_KEY"},
+				{Value: []byte("namespace/repo/app/db-password"), Name: "APP_DB_PASSWORD"},
+				{Value: []byte("namespace/repo/app/tls/cert.pem"), Name: "APP_TLS_CERT_PEM"},
+			},
+			expectedEnv: []string{
+				"APP_API_KEY=namespace/repo/api_key",
+				"APP_DB_PASSWORD=namespace/repo/app/db-password",
+				"APP_TLS_CERT_PEM=namespace/repo/app/tls/cert.pem",
+			},
+		},
 	}
 
 	for name, tc := range cases {
@@ -982,8 +1115,9 @@ func TestRunCommand_environment(t *testing.T) {
 
 			sort.Strings(env)
 			sort.Strings(tc.expectedEnv)
-			sort.Strings(secrets)
-			sort.Strings(tc.expectedSecrets)
+			sort.Slice(secrets, func(i, j int) bool {
+				return secrets[i].Name < secrets[j].Name
+			})
 
 			assert.Equal(t, env, tc.expectedEnv)
 			assert.Equal(t, secrets, tc.expectedSecrets)
@@ -992,6 +1126,98 @@ func TestRunCommand_environment(t *testing.T) {
 	}
 }
 
+func TestSecretDirEnv(t *testing.T) {
+	testError := errors.New("test error")
+
+	cases := map[string]struct {
+		prefix   string
+		secrets  []*api.Secret
+		subDirs  []*api.Dir
+		getErr   error
+		expected map[string]string
+		err      error
+	}{
+		"secrets": {
+			secrets: []*api.Secret{{Name: "db_password"}, {Name: "api-key"}, {Name: "tls.crt"}},
+			expected: map[string]string{
+				"DB_PASSWORD": "namespace/repo/dir/db_password",
+				"API_KEY":     "namespace/repo/dir/api-key",
+				"TLS_CRT":     "namespace/repo/dir/tls.crt",
+			},
+		},
+		"nested": {
+			secrets: []*api.Secret{{Name: "host"}},
+			subDirs: []*api.Dir{
+				{Name: "db", Secrets: []*api.Secret{{Name: "password"}}, SubDirs: []*api.Dir{
+					{Name: "replica", Secrets: []*api.Secret{{Name: "password"}}},
+				}},
+			},
+			expected: map[string]string{
+				"HOST":                "namespace/repo/dir/host",
+				"DB_PASSWORD":         "namespace/repo/dir/db/password",
+				"DB_REPLICA_PASSWORD": "namespace/repo/dir/db/replica/password",
+			},
+		},
+		"prefix": {
+			prefix:  "APP_",
+			secrets: []*api.Secret{{Name: "password"}},
+			expected: map[string]string{
+				"APP_PASSWORD": "namespace/repo/dir/password",
+			},
+		},
+		"empty": {
+			expected: map[string]string{},
+		},
+		"name conflict": {
+			secrets: []*api.Secret{{Name: "api-key"}, {Name: "api_key"}},
+			err:     ErrEnvDirNameConflict("api-key", "api_key", "namespace/repo/dir", "API_KEY"),
+		},
+		"get tree error": {
+			getErr: testError,
+			err:    testError,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			newClient := func() (secrethub.ClientInterface, error) {
+				return fakeclient.Client{
+					DirService: &fakeclient.DirService{
+						GetTreeFunc: func(path string, depth int, ancestors bool) (*api.Tree, error) {
+							if tc.getErr != nil {
+								return nil, tc.getErr
+							}
+							assert.Equal(t, path, "namespace/repo/dir")
+							return &api.Tree{
+								RootDir: &api.Dir{
+									Name:    "dir",
+									Secrets: tc.secrets,
+									SubDirs: tc.subDirs,
+								},
+							}, nil
+						},
+					},
+				}, nil
+			}
+
+			source, err := newSecretDirEnv(newClient, "namespace/repo/dir", tc.prefix)
+			assert.OK(t, err)
+
+			env, err := source.env()
+			assert.Equal(t, err, tc.err)
+			if tc.err != nil {
+				return
+			}
+
+			actual := make(map[string]string, len(env))
+			for name, value := range env {
+				actual[name] = value.(*secretValue).path
+			}
+			assert.Equal(t, actual, tc.expected)
+		})
+	}
+}
+
 func TestRunCommand_RunWithFile(t *testing.T) {
 	readFileWithContent := func(content string) func(string) ([]byte, error) {
 		return func(_ string) ([]byte, error) {
@@ -1020,7 +1246,7 @@ func TestRunCommand_RunWithFile(t *testing.T) {
 				environment: &environment{
 					osStat:   osStatOnlySecretHubEnv,
 					readFile: readFileWithContent(""),
-					envFile:  "secrethub.env",
+					envFiles: []string{"secrethub.env"},
 					envar: map[string]string{
 						"TEST": "test/test/test",
 					},
@@ -1046,7 +1272,7 @@ func TestRunCommand_RunWithFile(t *testing.T) {
 				command: []string{"/bin/sh", "./test.sh"},
 				environment: &environment{
 					osStat:   osStatOnlySecretHubEnv,
-					envFile:  "secrethub.env",
+					envFiles: []string{"secrethub.env"},
 					readFile: readFileWithContent(""),
 					envar: map[string]string{
 						"TEST": "test/test/test",
@@ -1221,3 +1447,144 @@ func Test_parseKeyValueStringsToMap(t *testing.T) {
 		"=::=::\\",
 	})
 }
+
+func TestRunCommand_watchEnvironment(t *testing.T) {
+	values := []string{"foo", "foo", "bar"}
+	i := 0
+
+	cmd := RunCommand{
+		watchInterval: time.Millisecond,
+		newClient: func() (secrethub.ClientInterface, error) {
+			return fakeclient.Client{
+				SecretService: &fakeclient.SecretService{
+					VersionService: &fakeclient.SecretVersionService{
+						GetWithDataFunc: func(path string) (*api.SecretVersion, error) {
+							data := values[i]
+							if i < len(values)-1 {
+								i++
+							}
+							return &api.SecretVersion{Data: []byte(data)}, nil
+						},
+					},
+				},
+			}, nil
+		},
+	}
+
+	envValues := map[string]value{
+		"TEST": newSecretValue("path/to/secret"),
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	changes := cmd.watchEnvironment(envValues, []string{"TEST=foo"}, done)
+
+	select {
+	case change := <-changes:
+		assert.Equal(t, change.environment, []string{"TEST=bar"})
+		assert.Equal(t, change.secrets, []masker.Sequence{{Value: []byte("bar"), Name: "TEST"}})
+	case <-time.After(time.Second):
+		t.Fatal("expected the environment to change")
+	}
+}
+
+func TestRunCommand_restartWithChangedSecrets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrethub-run")
+	assert.OK(t, err)
+	defer os.RemoveAll(dir)
+
+	var mutex sync.Mutex
+	reads := 0
+
+	// The process fails when it is started with the new value of the secret for the first time,
+	// so it must be restarted with the new value again to succeed.
+	cmd := RunCommand{
+		io:            fakeui.NewIO(t),
+		command:       []string{"sh", "-c", `[ "$TEST" = bar ] && [ -f "$MARKER" ] && exit 0; [ "$TEST" = bar ] && touch "$MARKER"; exit 1`},
+		watch:         true,
+		watchInterval: 10 * time.Millisecond,
+		restart:       restartOnFailure,
+		timeout:       10 * time.Second,
+		environment: &environment{
+			osEnv:  []string{"TEST=secrethub://path/to/secret", "MARKER=" + filepath.Join(dir, "marker")},
+			osStat: osStatFunc("secrethub.env", os.ErrNotExist),
+		},
+		newClient: func() (secrethub.ClientInterface, error) {
+			return fakeclient.Client{
+				SecretService: &fakeclient.SecretService{
+					VersionService: &fakeclient.SecretVersionService{
+						GetWithDataFunc: func(path string) (*api.SecretVersion, error) {
+							mutex.Lock()
+							defer mutex.Unlock()
+
+							reads++
+							if reads == 1 {
+								return &api.SecretVersion{Data: []byte("foo")}, nil
+							}
+							return &api.SecretVersion{Data: []byte("bar")}, nil
+						},
+					},
+				},
+			}, nil
+		},
+	}
+
+	err = cmd.Run()
+	assert.OK(t, err)
+}
+
+func TestEqualEnvironments(t *testing.T) {
+	cases := map[string]struct {
+		a        []string
+		b        []string
+		expected bool
+	}{
+		"equal": {
+			a:        []string{"FOO=foo", "BAR=bar"},
+			b:        []string{"FOO=foo", "BAR=bar"},
+			expected: true,
+		},
+		"different order": {
+			a:        []string{"FOO=foo", "BAR=bar"},
+			b:        []string{"BAR=bar", "FOO=foo"},
+			expected: true,
+		},
+		"different value": {
+			a:        []string{"FOO=foo", "BAR=bar"},
+			b:        []string{"FOO=foo", "BAR=baz"},
+			expected: false,
+		},
+		"different length": {
+			a:        []string{"FOO=foo", "BAR=bar"},
+			b:        []string{"FOO=foo"},
+			expected: false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, equalEnvironments(tc.a, tc.b), tc.expected)
+		})
+	}
+}
+
+func TestNewMaskSequences(t *testing.T) {
+	masked := map[string]struct{}{}
+
+	sequences := newMaskSequences([]masker.Sequence{
+		{Value: []byte("foo"), Name: "FOO"},
+		{Value: []byte(""), Name: "EMPTY"},
+		{Value: []byte("bar"), Name: "BAR"},
+	}, masked)
+	assert.Equal(t, sequences, []masker.Sequence{
+		{Value: []byte("foo"), Name: "FOO"},
+		{Value: []byte("bar"), Name: "BAR"},
+	})
+
+	sequences = newMaskSequences([]masker.Sequence{
+		{Value: []byte("foo"), Name: "OTHER_FOO"},
+		{Value: []byte("baz"), Name: "BAZ"},
+	}, masked)
+	assert.Equal(t, sequences, []masker.Sequence{{Value: []byte("baz"), Name: "BAZ"}})
+}
@@ -0,0 +1,279 @@
+package secrethub
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/secrethub/secrethub-cli/internals/cli"
+
+	"github.com/secrethub/secrethub-go/internals/errio"
+)
+
+// cacheLog logs problems with the offline cache that do not prevent secrets from being read.
+var cacheLog = cli.NewLogger()
+
+// Errors
+var (
+	ErrOfflineWithoutCache  = errMain.Code("offline_without_cache").Error("the --offline flag can only be used when the offline cache is enabled with the --cache-ttl flag")
+	ErrInvalidCacheTTL      = errMain.Code("invalid_cache_ttl").Error("the cache TTL cannot be negative")
+	ErrCannotDeriveCacheKey = errMain.Code("cannot_derive_cache_key").ErrorPref("cannot derive the offline cache key from the account credential: %s. The offline cache is only supported for key credentials")
+	ErrSecretNotCached      = errMain.Code("secret_not_cached").ErrorPref("the secret %s is not available in the offline cache or its cached version has expired")
+	ErrCannotWriteCache     = errMain.Code("cannot_write_cache").ErrorPref("cannot write the secret to the offline cache: %s")
+)
+
+const (
+	// cacheDirName is the name of the directory in the configuration directory
+	// in which the offline secret caches are stored.
+	cacheDirName = "cache"
+
+	cacheDirPerm  os.FileMode = 0700
+	cacheFilePerm os.FileMode = 0600
+)
+
+// Labels used to derive the separate keys of a cache from the master key.
+const (
+	cacheKeyLabelEncryption = "secrethub-cli offline cache encryption key"
+	cacheKeyLabelFilename   = "secrethub-cli offline cache filename key"
+	cacheKeyLabelDirectory  = "secrethub-cli offline cache directory key"
+)
+
+// secretCacheOptions configures the offline secret cache of a command.
+type secretCacheOptions struct {
+	offline         bool
+	ttl             time.Duration
+	credentialStore CredentialConfig
+}
+
+func (o *secretCacheOptions) register(clause *cli.CommandClause) {
+	clause.Flag("cache-ttl", "Cache the secrets that are read in an encrypted offline cache for this duration, e.g. 24h. The cached secrets are used when the SecretHub API cannot be reached. The cache is encrypted with a key derived from your account credential. Defaults to 0, which disables the cache.").Default("0").DurationVar(&o.ttl)
+	clause.Flag("offline", "Do not connect to the SecretHub API and only read secrets from the offline cache. Requires the --cache-ttl flag to be set.").BoolVar(&o.offline)
+}
+
+// open returns the offline secret cache of the configured account credential.
+// When the cache is disabled, nil is returned.
+func (o *secretCacheOptions) open() (*secretCache, error) {
+	if o.ttl < 0 {
+		return nil, ErrInvalidCacheTTL
+	}
+
+	if o.ttl == 0 {
+		if o.offline {
+			return nil, ErrOfflineWithoutCache
+		}
+		return nil, nil
+	}
+
+	credential, err := o.credentialStore.Import()
+	if err != nil {
+		return nil, ErrCannotDeriveCacheKey(err)
+	}
+
+	exported, err := credential.Export()
+	if err != nil {
+		return nil, ErrCannotDeriveCacheKey(err)
+	}
+
+	return newSecretCache(filepath.Join(o.credentialStore.ConfigDir().Path(), cacheDirName), exported, o.ttl, o.offline), nil
+}
+
+// secretCache stores secret versions on disk, encrypted with a key that
+// is derived from the account credential. Every account credential has its
+// own cache directory, of which the name is also derived from the credential.
+type secretCache struct {
+	dir           string
+	encryptionKey []byte
+	filenameKey   []byte
+	ttl           time.Duration
+	offline       bool
+}
+
+// newSecretCache creates a secretCache in a subdirectory of the given directory
+// for the given credential. Cached secrets expire after the given ttl.
+// When offline is set, secrets are only read from the cache.
+func newSecretCache(dir string, credential []byte, ttl time.Duration, offline bool) *secretCache {
+	masterKey := sha256.Sum256(credential)
+	return &secretCache{
+		dir:           filepath.Join(dir, hex.EncodeToString(deriveCacheKey(masterKey[:], cacheKeyLabelDirectory)[:16])),
+		encryptionKey: deriveCacheKey(masterKey[:], cacheKeyLabelEncryption),
+		filenameKey:   deriveCacheKey(masterKey[:], cacheKeyLabelFilename),
+		ttl:           ttl,
+		offline:       offline,
+	}
+}
+
+// deriveCacheKey derives a 256-bit key for the given purpose from the master key.
+func deriveCacheKey(masterKey []byte, label string) []byte {
+	mac := hmac.New(sha256.New, masterKey)
+	_, _ = mac.Write([]byte(label))
+	return mac.Sum(nil)
+}
+
+// cachedSecret is a secret version as it is stored in the cache.
+type cachedSecret struct {
+	Path     string    `json:"path"`
+	Version  int       `json:"version"`
+	Data     []byte    `json:"data"`
+	CachedAt time.Time `json:"cached_at"`
+}
+
+// filename returns the path to the cache file of the secret at the given path.
+// The secret path is hashed, so the cache does not leak which secrets it contains.
+func (c *secretCache) filename(path string) string {
+	mac := hmac.New(sha256.New, c.filenameKey)
+	_, _ = mac.Write([]byte(path))
+	return filepath.Join(c.dir, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// Get returns the cached secret at the given path.
+// Expired and unreadable entries are removed from the cache.
+func (c *secretCache) Get(path string) (*cachedSecret, error) {
+	filename := c.filename(path)
+	encrypted, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, ErrSecretNotCached(path)
+	} else if err != nil {
+		return nil, err
+	}
+
+	secret, err := c.decrypt(path, encrypted)
+	if err != nil || secret.Path != path || time.Since(secret.CachedAt) > c.ttl {
+		_ = os.Remove(filename)
+		return nil, ErrSecretNotCached(path)
+	}
+
+	return secret, nil
+}
+
+// Set stores the secret version at the given path in the cache.
+func (c *secretCache) Set(path string, version int, data []byte) error {
+	encrypted, err := c.encrypt(path, cachedSecret{
+		Path:     path,
+		Version:  version,
+		Data:     data,
+		CachedAt: time.Now(),
+	})
+	if err != nil {
+		return ErrCannotWriteCache(err)
+	}
+
+	err = os.MkdirAll(c.dir, cacheDirPerm)
+	if err != nil {
+		return ErrCannotWriteCache(err)
+	}
+
+	// Write to a temporary file first, so concurrent readers never see a partially written entry.
+	file, err := ioutil.TempFile(c.dir, ".tmp-")
+	if err != nil {
+		return ErrCannotWriteCache(err)
+	}
+	defer os.Remove(file.Name())
+
+	err = file.Chmod(cacheFilePerm)
+	if err == nil {
+		_, err = file.Write(encrypted)
+	}
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return ErrCannotWriteCache(err)
+	}
+
+	err = os.Rename(file.Name(), c.filename(path))
+	if err != nil {
+		return ErrCannotWriteCache(err)
+	}
+	return nil
+}
+
+// encrypt encrypts the secret with AES-GCM. The path of the secret is used
+// as additional data, so an entry cannot be moved to the file of another secret.
+func (c *secretCache) encrypt(path string, secret cachedSecret) ([]byte, error) {
+	plaintext, err := json.Marshal(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := c.newGCM()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, []byte(path)), nil
+}
+
+// decrypt decrypts a cache entry that has been encrypted with encrypt.
+func (c *secretCache) decrypt(path string, encrypted []byte) (*cachedSecret, error) {
+	gcm, err := c.newGCM()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(encrypted) < gcm.NonceSize() {
+		return nil, errio.UnexpectedError(io.ErrUnexpectedEOF)
+	}
+
+	nonce, ciphertext := encrypted[:gcm.NonceSize()], encrypted[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, []byte(path))
+	if err != nil {
+		return nil, err
+	}
+
+	var secret cachedSecret
+	err = json.Unmarshal(plaintext, &secret)
+	if err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
+
+func (c *secretCache) newGCM() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(c.encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// isAPIUnreachable returns whether the given error indicates that the SecretHub API
+// could not be reached or failed to handle the request, in which case the offline cache can be used.
+// Other errors, e.g. for secrets that do not exist or invalid paths, are not considered to be caused
+// by an unreachable API, so they are returned instead of falling back to the cache.
+func isAPIUnreachable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var statusErr errio.PublicStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500
+	}
+
+	// The client returns these errors when a request could not be sent or timed out.
+	var publicErr errio.PublicError
+	if errors.As(err, &publicErr) {
+		return publicErr.Namespace == "http" && (publicErr.Code == "request_failed" || publicErr.Code == "timeout")
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
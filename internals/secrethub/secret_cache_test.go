@@ -0,0 +1,153 @@
+package secrethub
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/secrethub/secrethub-go/internals/assert"
+	"github.com/secrethub/secrethub-go/internals/errio"
+)
+
+func TestSecretCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrethub-cache")
+	assert.OK(t, err)
+	defer os.RemoveAll(dir)
+
+	cache := newSecretCache(dir, []byte("credential"), time.Hour, false)
+
+	err = cache.Set("path/to/secret", 3, []byte("secret value"))
+	assert.OK(t, err)
+
+	t.Run("get", func(t *testing.T) {
+		secret, err := cache.Get("path/to/secret")
+		assert.OK(t, err)
+		assert.Equal(t, secret.Path, "path/to/secret")
+		assert.Equal(t, secret.Version, 3)
+		assert.Equal(t, string(secret.Data), "secret value")
+	})
+
+	t.Run("not cached", func(t *testing.T) {
+		_, err := cache.Get("path/to/other")
+		assert.Equal(t, err, ErrSecretNotCached("path/to/other"))
+	})
+
+	t.Run("other credential", func(t *testing.T) {
+		other := newSecretCache(dir, []byte("other credential"), time.Hour, false)
+
+		_, err := other.Get("path/to/secret")
+		assert.Equal(t, err, ErrSecretNotCached("path/to/secret"))
+	})
+
+	t.Run("encrypted on disk", func(t *testing.T) {
+		files, err := ioutil.ReadDir(cache.dir)
+		assert.OK(t, err)
+		assert.Equal(t, len(files), 1)
+
+		contents, err := ioutil.ReadFile(cache.filename("path/to/secret"))
+		assert.OK(t, err)
+		assert.Equal(t, bytes.Contains(contents, []byte("secret value")), false)
+		assert.Equal(t, bytes.Contains(contents, []byte("path/to/secret")), false)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		expired := newSecretCache(dir, []byte("credential"), time.Nanosecond, false)
+		time.Sleep(time.Millisecond)
+
+		_, err := expired.Get("path/to/secret")
+		assert.Equal(t, err, ErrSecretNotCached("path/to/secret"))
+
+		_, err = os.Stat(cache.filename("path/to/secret"))
+		assert.Equal(t, os.IsNotExist(err), true)
+	})
+}
+
+func TestSecretCacheOptions_open(t *testing.T) {
+	cases := map[string]struct {
+		options secretCacheOptions
+		err     error
+	}{
+		"disabled": {
+			options: secretCacheOptions{},
+		},
+		"offline without cache": {
+			options: secretCacheOptions{
+				offline: true,
+			},
+			err: ErrOfflineWithoutCache,
+		},
+		"negative ttl": {
+			options: secretCacheOptions{
+				ttl: -time.Minute,
+			},
+			err: ErrInvalidCacheTTL,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cache, err := tc.options.open()
+
+			assert.Equal(t, err, tc.err)
+			assert.Equal(t, cache == nil, true)
+		})
+	}
+}
+
+func TestIsAPIUnreachable(t *testing.T) {
+	errTest := errio.Namespace("test")
+
+	cases := map[string]struct {
+		err      error
+		expected bool
+	}{
+		"no error": {
+			err:      nil,
+			expected: false,
+		},
+		"connection error": {
+			err:      &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+			expected: true,
+		},
+		"request failed": {
+			err:      errio.Namespace("http").Code("request_failed").ErrorPref("request to API server failed: %v")("connection refused"),
+			expected: true,
+		},
+		"timeout": {
+			err:      errio.Namespace("http").Code("timeout").Error("client timed out during request"),
+			expected: true,
+		},
+		"not found": {
+			err:      errTest.Code("not_found").StatusError("not found", http.StatusNotFound),
+			expected: false,
+		},
+		"wrapped not found": {
+			err:      fmt.Errorf("secret not found: %w", errTest.Code("not_found").StatusError("not found", http.StatusNotFound)),
+			expected: false,
+		},
+		"invalid path": {
+			err:      errTest.Code("invalid_path").Error("invalid path"),
+			expected: false,
+		},
+		"other error": {
+			err:      errors.New("test error"),
+			expected: false,
+		},
+		"server error": {
+			err:      errTest.Code("bad_gateway").StatusError("bad gateway", http.StatusBadGateway),
+			expected: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, isAPIUnreachable(tc.err), tc.expected)
+		})
+	}
+}
@@ -1,12 +1,31 @@
 package secrethub
 
 import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+
 	"github.com/secrethub/secrethub-cli/internals/secrethub/tpl"
 	"github.com/secrethub/secrethub-go/internals/api"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Errors
+var (
+	ErrSecretNotStructured = errMain.Code("secret_not_structured").ErrorPref("cannot select field %s of secret %s: the secret is not a valid JSON or YAML object or list")
+	ErrSecretFieldNotFound = errMain.Code("secret_field_not_found").ErrorPref("secret %s has no field %s")
+)
+
+const (
+	// maxConcurrentSecretReads is the maximum number of secrets that are read in parallel when prefetching secrets.
+	maxConcurrentSecretReads = 8
 )
 
 type secretReader struct {
 	newClient newClientFunc
+	cache     *secretCache
 }
 
 // newSecretReader wraps a client to implement tpl.SecretReader.
@@ -16,18 +35,51 @@ func newSecretReader(newClient newClientFunc) *secretReader {
 	}
 }
 
+// newCachedSecretReader wraps a client to implement tpl.SecretReader.
+// Secrets that are read are stored in the given cache, which is used
+// as a fallback when the SecretHub API cannot be reached.
+// When the cache is nil, no caching is done.
+func newCachedSecretReader(newClient newClientFunc, cache *secretCache) *secretReader {
+	return &secretReader{
+		newClient: newClient,
+		cache:     cache,
+	}
+}
+
 // ReadSecret reads the secret using the provided client.
 func (sr secretReader) ReadSecret(path string) (string, error) {
+	if sr.cache != nil && sr.cache.offline {
+		return sr.readCachedSecret(path)
+	}
+
 	client, err := sr.newClient()
 	if err != nil {
 		return "", err
 	}
 
 	secret, err := client.Secrets().Versions().GetWithData(path)
-	if err != nil {
+	if sr.cache != nil && isAPIUnreachable(err) {
+		return sr.readCachedSecret(path)
+	} else if err != nil {
 		return "", err
 	}
 
+	if sr.cache != nil {
+		err = sr.cache.Set(path, secret.Version, secret.Data)
+		if err != nil {
+			cacheLog.Debugf("cannot write secret %s to the offline cache: %s", path, err)
+		}
+	}
+
+	return string(secret.Data), nil
+}
+
+// readCachedSecret reads the secret from the offline cache.
+func (sr secretReader) readCachedSecret(path string) (string, error) {
+	secret, err := sr.cache.Get(path)
+	if err != nil {
+		return "", err
+	}
 	return string(secret.Data), nil
 }
 
@@ -57,6 +109,12 @@ func (sr *bufferedSecretReader) ReadSecret(path string) (string, error) {
 	return secret, err
 }
 
+// RecordFilteredSecret stores the value of a secret tag after its filters
+// are applied, so that it is returned by the Values function too.
+func (sr *bufferedSecretReader) RecordFilteredSecret(value string) {
+	sr.secretsRead = append(sr.secretsRead, value)
+}
+
 type secretReaderNotAllowed struct{}
 
 func (sr secretReaderNotAllowed) ReadSecret(path string) (string, error) {
@@ -87,3 +145,219 @@ func (sr *ignoreMissingSecretReader) ReadSecret(path string) (string, error) {
 	}
 	return secret, err
 }
+
+// secretPathCollector implements tpl.SecretReader by recording the paths of the secrets that are read,
+// without actually reading them. It can be used to find out which secrets are needed to resolve a value.
+type secretPathCollector struct {
+	paths []string
+	seen  map[string]struct{}
+}
+
+func newSecretPathCollector() *secretPathCollector {
+	return &secretPathCollector{
+		seen: make(map[string]struct{}),
+	}
+}
+
+// ReadSecret records the path of the secret and returns an empty value.
+// A field selector in the reference to the secret is not recorded,
+// as the whole secret is read to select a field from it.
+func (c *secretPathCollector) ReadSecret(ref string) (string, error) {
+	path, _ := tpl.SplitSecretField(ref)
+	if _, ok := c.seen[path]; !ok {
+		c.seen[path] = struct{}{}
+		c.paths = append(c.paths, path)
+	}
+	return "", nil
+}
+
+// Paths returns the unique paths of the secrets that have been read, in the order they were first read.
+func (c *secretPathCollector) Paths() []string {
+	return c.paths
+}
+
+// collectSecretPaths returns the unique paths of all secrets that are read when resolving the given values.
+func collectSecretPaths(values map[string]value) ([]string, error) {
+	collector := newSecretPathCollector()
+	for _, value := range values {
+		_, err := value.resolve(collector)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return collector.Paths(), nil
+}
+
+type prefetchedSecret struct {
+	value string
+	err   error
+}
+
+type prefetchSecretReader struct {
+	secretReader tpl.SecretReader
+	secrets      map[string]prefetchedSecret
+}
+
+// newPrefetchSecretReader wraps a secret reader to read secrets concurrently up front
+// using the Prefetch function.
+func newPrefetchSecretReader(sr tpl.SecretReader) *prefetchSecretReader {
+	return &prefetchSecretReader{
+		secretReader: sr,
+		secrets:      make(map[string]prefetchedSecret),
+	}
+}
+
+// Prefetch reads the secrets at the given paths with the underlying secret reader, using at most
+// maxConcurrentSecretReads concurrent reads. Every path is only read once. Errors that occur while
+// reading a secret are returned when the secret is read with ReadSecret.
+func (sr *prefetchSecretReader) Prefetch(paths []string) {
+	var unique []string
+	seen := make(map[string]struct{}, len(paths))
+	for _, path := range paths {
+		_, isPrefetched := sr.secrets[path]
+		_, isDuplicate := seen[path]
+		if !isPrefetched && !isDuplicate {
+			seen[path] = struct{}{}
+			unique = append(unique, path)
+		}
+	}
+
+	results := make([]prefetchedSecret, len(unique))
+	semaphore := make(chan struct{}, maxConcurrentSecretReads)
+	var wg sync.WaitGroup
+	for i, path := range unique {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int, path string) {
+			defer wg.Done()
+			value, err := sr.secretReader.ReadSecret(path)
+			results[i] = prefetchedSecret{value: value, err: err}
+			<-semaphore
+		}(i, path)
+	}
+	wg.Wait()
+
+	for i, path := range unique {
+		sr.secrets[path] = results[i]
+	}
+}
+
+// ReadSecret returns the prefetched secret at the given path.
+// Secrets that have not been prefetched are read with the underlying secret reader.
+func (sr *prefetchSecretReader) ReadSecret(path string) (string, error) {
+	secret, ok := sr.secrets[path]
+	if !ok {
+		return sr.secretReader.ReadSecret(path)
+	}
+	return secret.value, secret.err
+}
+
+type fieldSecretReader struct {
+	secretReader tpl.SecretReader
+}
+
+// newFieldSecretReader wraps a secret reader to resolve references to a field in
+// a JSON or YAML secret, e.g. `path/to/secret#.credentials.password`.
+func newFieldSecretReader(sr tpl.SecretReader) *fieldSecretReader {
+	return &fieldSecretReader{
+		secretReader: sr,
+	}
+}
+
+// ReadSecret uses the underlying secret reader to read the secret and
+// returns the selected field when the reference contains a field selector.
+func (sr *fieldSecretReader) ReadSecret(ref string) (string, error) {
+	path, field := tpl.SplitSecretField(ref)
+	secret, err := sr.secretReader.ReadSecret(path)
+	if err != nil || field == "" {
+		return secret, err
+	}
+	return selectSecretField(path, secret, field)
+}
+
+// selectSecretField returns the value of the given field of a secret that contains a JSON or YAML object or list.
+// Strings are returned as is, other scalar values in their canonical form and objects and lists are encoded as JSON.
+func selectSecretField(path string, secret string, field string) (string, error) {
+	keys, err := tpl.ParseSecretField(field)
+	if err != nil {
+		return "", err
+	}
+
+	// As JSON is a subset of YAML, both are parsed as YAML. The parse error
+	// is not returned, because it can contain parts of the secret.
+	var data interface{}
+	err = yaml.Unmarshal([]byte(secret), &data)
+	if err != nil {
+		return "", ErrSecretNotStructured(field, path)
+	}
+
+	for i, key := range keys {
+		var ok bool
+		switch v := data.(type) {
+		case map[interface{}]interface{}:
+			data, ok = lookupYAMLKey(v, key)
+		case []interface{}:
+			var index int
+			index, err = strconv.Atoi(key)
+			ok = err == nil && index >= 0 && index < len(v)
+			if ok {
+				data = v[index]
+			}
+		default:
+			if i == 0 {
+				return "", ErrSecretNotStructured(field, path)
+			}
+		}
+
+		if !ok {
+			return "", ErrSecretFieldNotFound(path, field)
+		}
+	}
+
+	switch v := data.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case map[interface{}]interface{}, []interface{}:
+		res, err := json.Marshal(jsonCompatible(v))
+		if err != nil {
+			return "", err
+		}
+		return string(res), nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// lookupYAMLKey returns the value of the given key in a YAML mapping.
+// Keys that are not strings, such as numbers, are matched by their string representation.
+func lookupYAMLKey(m map[interface{}]interface{}, key string) (interface{}, bool) {
+	for k, v := range m {
+		if fmt.Sprint(k) == key {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// jsonCompatible converts parsed YAML to values that can be encoded as JSON,
+// by converting the keys of all mappings to strings.
+func jsonCompatible(data interface{}) interface{} {
+	switch v := data.(type) {
+	case map[interface{}]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			res[fmt.Sprint(key)] = jsonCompatible(value)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, value := range v {
+			res[i] = jsonCompatible(value)
+		}
+		return res
+	default:
+		return v
+	}
+}
@@ -0,0 +1,306 @@
+package secrethub
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/secrethub/secrethub-cli/internals/secrethub/tpl"
+	"github.com/secrethub/secrethub-cli/internals/secrethub/tpl/fakes"
+
+	"github.com/secrethub/secrethub-go/internals/api"
+	"github.com/secrethub/secrethub-go/internals/assert"
+	"github.com/secrethub/secrethub-go/pkg/secrethub"
+	"github.com/secrethub/secrethub-go/pkg/secrethub/fakeclient"
+)
+
+// countingSecretReader counts the number of reads per path.
+type countingSecretReader struct {
+	secretReader fakes.FakeSecretReader
+	reads        map[string]int
+	mutex        sync.Mutex
+}
+
+func (sr *countingSecretReader) ReadSecret(path string) (string, error) {
+	sr.mutex.Lock()
+	sr.reads[path]++
+	sr.mutex.Unlock()
+
+	return sr.secretReader.ReadSecret(path)
+}
+
+func TestSecretPathCollector(t *testing.T) {
+	collector := newSecretPathCollector()
+
+	for _, path := range []string{"path/to/foo", "path/to/bar", "path/to/foo", "path/to/bar#.field"} {
+		value, err := collector.ReadSecret(path)
+		assert.OK(t, err)
+		assert.Equal(t, value, "")
+	}
+
+	assert.Equal(t, collector.Paths(), []string{"path/to/foo", "path/to/bar"})
+}
+
+func TestPrefetchSecretReader(t *testing.T) {
+	counter := &countingSecretReader{
+		secretReader: fakes.FakeSecretReader{
+			Secrets: map[string]string{
+				"path/to/foo": "foo",
+				"path/to/bar": "bar",
+				"path/to/baz": "baz",
+			},
+		},
+		reads: make(map[string]int),
+	}
+
+	sr := newPrefetchSecretReader(counter)
+	sr.Prefetch([]string{"path/to/foo", "path/to/bar", "path/to/foo", "path/to/unknown"})
+
+	assert.Equal(t, counter.reads, map[string]int{
+		"path/to/foo":     1,
+		"path/to/bar":     1,
+		"path/to/unknown": 1,
+	})
+
+	cases := map[string]struct {
+		path     string
+		expected string
+		err      error
+	}{
+		"prefetched": {
+			path:     "path/to/foo",
+			expected: "foo",
+		},
+		"prefetched error": {
+			path: "path/to/unknown",
+			err:  errors.New("secret not found"),
+		},
+		"not prefetched": {
+			path:     "path/to/baz",
+			expected: "baz",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			actual, err := sr.ReadSecret(tc.path)
+
+			assert.Equal(t, err, tc.err)
+			assert.Equal(t, actual, tc.expected)
+		})
+	}
+
+	assert.Equal(t, counter.reads, map[string]int{
+		"path/to/foo":     1,
+		"path/to/bar":     1,
+		"path/to/baz":     1,
+		"path/to/unknown": 1,
+	})
+}
+
+func TestBufferedSecretReader(t *testing.T) {
+	template, err := tpl.NewV2Parser().Parse("A={{ path/to/foo }}\nB={{ path/to/bar | base64 }}\n", 1, 1)
+	assert.OK(t, err)
+
+	sr := newBufferedSecretReader(fakes.FakeSecretReader{
+		Secrets: map[string]string{
+			"path/to/foo": "foo",
+			"path/to/bar": "bar",
+		},
+	})
+
+	_, err = template.Evaluate(fakes.FakeVariableReader{}, sr)
+	assert.OK(t, err)
+
+	assert.Equal(t, sr.Values(), []string{"foo", "bar", "YmFy"})
+}
+
+func TestCollectSecretPaths(t *testing.T) {
+	values := map[string]value{
+		"FOO":   newSecretValue("path/to/foo"),
+		"BAR":   newSecretValue("path/to/foo"),
+		"PLAIN": newPlaintextValue("plain"),
+	}
+
+	paths, err := collectSecretPaths(values)
+	assert.OK(t, err)
+	assert.Equal(t, paths, []string{"path/to/foo"})
+}
+
+func TestFieldSecretReader(t *testing.T) {
+	secrets := map[string]string{
+		"path/to/json":    `{"credentials": {"user": "admin", "password": "p@ss", "port": 5432, "enabled": true, "hosts": ["a", "b"], "empty": null}}`,
+		"path/to/yaml":    "credentials:\n  password: p@ss\n  1: one\n",
+		"path/to/text":    "plain text",
+		"path/to/invalid": "{\"unclosed\": ",
+	}
+
+	cases := map[string]struct {
+		ref      string
+		expected string
+		err      error
+	}{
+		"without field": {
+			ref:      "path/to/text",
+			expected: "plain text",
+		},
+		"json string": {
+			ref:      "path/to/json#.credentials.password",
+			expected: "p@ss",
+		},
+		"json number": {
+			ref:      "path/to/json#.credentials.port",
+			expected: "5432",
+		},
+		"json bool": {
+			ref:      "path/to/json#.credentials.enabled",
+			expected: "true",
+		},
+		"json null": {
+			ref:      "path/to/json#.credentials.empty",
+			expected: "",
+		},
+		"json list element": {
+			ref:      "path/to/json#.credentials.hosts.1",
+			expected: "b",
+		},
+		"json list": {
+			ref:      "path/to/json#.credentials.hosts",
+			expected: `["a","b"]`,
+		},
+		"json object": {
+			ref:      "path/to/json#.credentials",
+			expected: `{"empty":null,"enabled":true,"hosts":["a","b"],"password":"p@ss","port":5432,"user":"admin"}`,
+		},
+		"yaml": {
+			ref:      "path/to/yaml#.credentials.password",
+			expected: "p@ss",
+		},
+		"yaml numeric key": {
+			ref:      "path/to/yaml#.credentials.1",
+			expected: "one",
+		},
+		"missing key": {
+			ref: "path/to/json#.credentials.username",
+			err: ErrSecretFieldNotFound("path/to/json", ".credentials.username"),
+		},
+		"index out of range": {
+			ref: "path/to/json#.credentials.hosts.2",
+			err: ErrSecretFieldNotFound("path/to/json", ".credentials.hosts.2"),
+		},
+		"key in scalar": {
+			ref: "path/to/json#.credentials.password.value",
+			err: ErrSecretFieldNotFound("path/to/json", ".credentials.password.value"),
+		},
+		"not structured": {
+			ref: "path/to/text#.password",
+			err: ErrSecretNotStructured(".password", "path/to/text"),
+		},
+		"invalid json": {
+			ref: "path/to/invalid#.unclosed",
+			err: ErrSecretNotStructured(".unclosed", "path/to/invalid"),
+		},
+		"invalid field": {
+			ref: "path/to/json#credentials",
+			err: tpl.ErrInvalidSecretField("credentials"),
+		},
+		"secret not found": {
+			ref: "path/to/unknown#.password",
+			err: errors.New("secret not found"),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			sr := newFieldSecretReader(fakes.FakeSecretReader{Secrets: secrets})
+
+			actual, err := sr.ReadSecret(tc.ref)
+			assert.Equal(t, err, tc.err)
+			assert.Equal(t, actual, tc.expected)
+		})
+	}
+}
+
+func TestCachedSecretReader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrethub-cache")
+	assert.OK(t, err)
+	defer os.RemoveAll(dir)
+
+	// A cache directory inside of a file cannot be created, so writing to the cache fails.
+	file := filepath.Join(dir, "file")
+	err = ioutil.WriteFile(file, nil, 0600)
+	assert.OK(t, err)
+
+	notFound := fmt.Errorf("secret not found: %w", api.ErrSecretNotFound)
+
+	cases := map[string]struct {
+		cacheDir string
+		cached   string
+		data     string
+		getErr   error
+		expected string
+		err      error
+	}{
+		"read": {
+			cacheDir: filepath.Join(dir, "read"),
+			data:     "secret value",
+			expected: "secret value",
+		},
+		"cache not writable": {
+			cacheDir: filepath.Join(file, "cache"),
+			data:     "secret value",
+			expected: "secret value",
+		},
+		"api unreachable": {
+			cacheDir: filepath.Join(dir, "unreachable"),
+			cached:   "cached value",
+			getErr:   &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+			expected: "cached value",
+		},
+		"not found": {
+			cacheDir: filepath.Join(dir, "not_found"),
+			cached:   "cached value",
+			getErr:   notFound,
+			err:      notFound,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			cache := newSecretCache(tc.cacheDir, []byte("credential"), time.Hour, false)
+			if tc.cached != "" {
+				err := cache.Set("path/to/secret", 1, []byte(tc.cached))
+				assert.OK(t, err)
+			}
+
+			sr := newCachedSecretReader(func() (secrethub.ClientInterface, error) {
+				return fakeclient.Client{
+					SecretService: &fakeclient.SecretService{
+						VersionService: &fakeclient.SecretVersionService{
+							GetWithDataFunc: func(path string) (*api.SecretVersion, error) {
+								if tc.getErr != nil {
+									return nil, tc.getErr
+								}
+								return &api.SecretVersion{Version: 1, Data: []byte(tc.data)}, nil
+							},
+						},
+					},
+				}, nil
+			}, cache)
+
+			// Act
+			actual, err := sr.ReadSecret("path/to/secret")
+
+			// Assert
+			assert.Equal(t, err, tc.err)
+			assert.Equal(t, actual, tc.expected)
+		})
+	}
+}
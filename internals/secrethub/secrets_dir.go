@@ -0,0 +1,195 @@
+package secrethub
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Errors
+var (
+	ErrNoSecretsDir     = errRun.Code("no_secrets_dir").Error("no tmpfs found to store the secret files in: use --secrets-dir to set a memory backed directory to store them in")
+	ErrCreateSecretsDir = errRun.Code("create_secrets_dir_failed").ErrorPref("could not create the secrets directory: %s")
+	ErrSecretsDirOnDisk = errRun.Code("secrets_dir_on_disk").ErrorPref("cannot store the secret files in %s: it is not on a memory backed (tmpfs or ramfs) file system")
+	ErrWriteSecretFile  = errRun.Code("write_secret_file_failed").ErrorPref("could not write secret file %s: %s")
+	ErrShredSecretsDir  = errRun.Code("shred_secrets_dir_failed").ErrorPref("could not shred the secrets directory %s: %s")
+)
+
+const (
+	// defaultSecretsDirParent is the tmpfs in which the secrets directory is created by default.
+	defaultSecretsDirParent = "/dev/shm"
+	// secretFileMode is the file mode of the files containing a secret.
+	secretFileMode os.FileMode = 0400
+	// secretFileEnvVarSuffix is appended to the name of an environment variable
+	// to get the name of the variable containing the path to its secret file.
+	secretFileEnvVarSuffix = "_FILE"
+)
+
+// secretsDir is a private directory in which the values of environment variables
+// are stored as files, so that they are not exposed through the environment of a process.
+type secretsDir struct {
+	path  string
+	files map[string]string
+}
+
+// newSecretsDir creates a private directory (0700) in the given parent directory to store secret files in.
+// When no parent directory is given, the directory is created on the default tmpfs. The parent directory
+// has to be memory backed, so that the secrets are never written to disk.
+func newSecretsDir(parent string) (*secretsDir, error) {
+	if parent == "" {
+		info, err := os.Stat(defaultSecretsDirParent)
+		if err != nil || !info.IsDir() {
+			return nil, ErrNoSecretsDir
+		}
+		memoryBacked, err := isMemoryBacked(defaultSecretsDirParent)
+		if err != nil || !memoryBacked {
+			return nil, ErrNoSecretsDir
+		}
+		parent = defaultSecretsDirParent
+	} else {
+		memoryBacked, err := isMemoryBacked(parent)
+		if err != nil {
+			return nil, ErrCreateSecretsDir(err)
+		}
+		if !memoryBacked {
+			return nil, ErrSecretsDirOnDisk(parent)
+		}
+	}
+
+	path, err := ioutil.TempDir(parent, "secrethub-")
+	if err != nil {
+		return nil, ErrCreateSecretsDir(err)
+	}
+
+	return &secretsDir{
+		path:  path,
+		files: make(map[string]string),
+	}, nil
+}
+
+// write writes the values of the environment variables with the given names to
+// files in the secrets directory. It returns the given environment, with these
+// variables replaced by a NAME_FILE variable that contains the path to the file.
+// Files of variables that are no longer in the environment are shredded.
+func (d *secretsDir) write(environment []string, names map[string]struct{}) ([]string, error) {
+	result := make([]string, 0, len(environment))
+	written := make(map[string]struct{}, len(names))
+	for _, envVar := range environment {
+		split := strings.SplitN(envVar, "=", 2)
+		name := split[0]
+		if _, ok := names[name]; !ok || len(split) != 2 {
+			result = append(result, envVar)
+			continue
+		}
+
+		path, err := d.writeFile(name, split[1])
+		if err != nil {
+			return nil, err
+		}
+		written[name] = struct{}{}
+		result = append(result, name+secretFileEnvVarSuffix+"="+path)
+	}
+
+	for name, path := range d.files {
+		if _, ok := written[name]; ok {
+			continue
+		}
+		err := shredFile(path)
+		if err != nil {
+			return nil, ErrShredSecretsDir(d.path, err)
+		}
+		delete(d.files, name)
+	}
+
+	return result, nil
+}
+
+// writeFile writes the given value to the read-only file of the environment
+// variable with the given name and returns the path of the file. An existing
+// file for the variable is shredded before the new value is written.
+func (d *secretsDir) writeFile(name string, value string) (string, error) {
+	path := filepath.Join(d.path, name)
+	if _, ok := d.files[name]; ok {
+		err := shredFile(path)
+		if err != nil {
+			return "", ErrWriteSecretFile(path, err)
+		}
+		delete(d.files, name)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, secretFileMode)
+	if err != nil {
+		return "", ErrWriteSecretFile(path, err)
+	}
+	d.files[name] = path
+
+	_, err = f.WriteString(value)
+	if err != nil {
+		_ = f.Close()
+		return "", ErrWriteSecretFile(path, err)
+	}
+
+	err = f.Close()
+	if err != nil {
+		return "", ErrWriteSecretFile(path, err)
+	}
+	return path, nil
+}
+
+// shred overwrites all secret files before removing them together with the secrets directory.
+// Shredding an already shredded directory is a no-op.
+func (d *secretsDir) shred() error {
+	for name, path := range d.files {
+		err := shredFile(path)
+		if err != nil {
+			return ErrShredSecretsDir(d.path, err)
+		}
+		delete(d.files, name)
+	}
+
+	err := os.RemoveAll(d.path)
+	if err != nil {
+		return ErrShredSecretsDir(d.path, err)
+	}
+	return nil
+}
+
+// shredFile overwrites the contents of the file at the given path with zeros and removes it.
+func shredFile(path string) error {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	err = os.Chmod(path, 0600)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(make([]byte, info.Size()))
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	err = f.Sync()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
@@ -0,0 +1,19 @@
+package secrethub
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// isMemoryBacked returns whether the directory at the given path is on a tmpfs or ramfs file system,
+// so that the files in it are never written to disk.
+func isMemoryBacked(path string) (bool, error) {
+	var stat unix.Statfs_t
+	err := unix.Statfs(path, &stat)
+	if err != nil {
+		return false, err
+	}
+
+	// The type of the magic number differs between architectures, so it is compared as an uint32.
+	fsType := uint32(stat.Type)
+	return fsType == uint32(unix.TMPFS_MAGIC) || fsType == uint32(unix.RAMFS_MAGIC), nil
+}
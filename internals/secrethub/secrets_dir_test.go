@@ -0,0 +1,107 @@
+package secrethub
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"testing"
+
+	"github.com/secrethub/secrethub-go/internals/assert"
+)
+
+func TestSecretsDir(t *testing.T) {
+	memoryBacked, err := isMemoryBacked(defaultSecretsDirParent)
+	if err != nil || !memoryBacked {
+		t.Skip("no memory backed file system available at " + defaultSecretsDirParent)
+	}
+
+	parent, err := ioutil.TempDir(defaultSecretsDirParent, "secrethub-test")
+	assert.OK(t, err)
+	defer os.RemoveAll(parent)
+
+	dir, err := newSecretsDir(parent)
+	assert.OK(t, err)
+
+	info, err := os.Stat(dir.path)
+	assert.OK(t, err)
+	if runtime.GOOS != "windows" {
+		assert.Equal(t, info.Mode().Perm(), os.FileMode(0700))
+	}
+
+	names := map[string]struct{}{"DB_PASSWORD": {}, "API_KEY": {}}
+	passwordPath := filepath.Join(dir.path, "DB_PASSWORD")
+	apiKeyPath := filepath.Join(dir.path, "API_KEY")
+
+	// Write the secret variables as files.
+	env, err := dir.write([]string{"HOME=/home/user", "DB_PASSWORD=foo", "API_KEY=bar"}, names)
+	assert.OK(t, err)
+
+	sort.Strings(env)
+	assert.Equal(t, env, []string{"API_KEY_FILE=" + apiKeyPath, "DB_PASSWORD_FILE=" + passwordPath, "HOME=/home/user"})
+	assertSecretFile(t, passwordPath, "foo")
+	assertSecretFile(t, apiKeyPath, "bar")
+
+	// Update a secret and remove a variable.
+	env, err = dir.write([]string{"HOME=/home/user", "DB_PASSWORD=baz"}, names)
+	assert.OK(t, err)
+
+	sort.Strings(env)
+	assert.Equal(t, env, []string{"DB_PASSWORD_FILE=" + passwordPath, "HOME=/home/user"})
+	assertSecretFile(t, passwordPath, "baz")
+	_, err = os.Stat(apiKeyPath)
+	assert.Equal(t, os.IsNotExist(err), true)
+
+	// Shred the directory twice.
+	assert.OK(t, dir.shred())
+	assert.OK(t, dir.shred())
+	_, err = os.Stat(dir.path)
+	assert.Equal(t, os.IsNotExist(err), true)
+}
+
+func TestSecretsDir_OnDisk(t *testing.T) {
+	parent, err := ioutil.TempDir("", "secrethub-test")
+	assert.OK(t, err)
+	defer os.RemoveAll(parent)
+
+	memoryBacked, err := isMemoryBacked(parent)
+	assert.OK(t, err)
+	if memoryBacked {
+		t.Skip("the temporary directory is memory backed")
+	}
+
+	_, err = newSecretsDir(parent)
+	assert.Equal(t, err, ErrSecretsDirOnDisk(parent))
+}
+
+func assertSecretFile(t *testing.T, path string, expected string) {
+	t.Helper()
+
+	info, err := os.Stat(path)
+	assert.OK(t, err)
+	if runtime.GOOS != "windows" {
+		assert.Equal(t, info.Mode().Perm(), secretFileMode)
+	}
+
+	content, err := ioutil.ReadFile(path)
+	assert.OK(t, err)
+	assert.Equal(t, string(content), expected)
+}
+
+func TestShredFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "secrethub-test")
+	assert.OK(t, err)
+	_, err = f.WriteString("secret")
+	assert.OK(t, err)
+	assert.OK(t, f.Close())
+	assert.OK(t, os.Chmod(f.Name(), secretFileMode))
+
+	assert.OK(t, shredFile(f.Name()))
+
+	_, err = os.Stat(f.Name())
+	assert.Equal(t, os.IsNotExist(err), true)
+
+	// Shredding a file that does not exist is a no-op.
+	assert.OK(t, shredFile(f.Name()))
+}
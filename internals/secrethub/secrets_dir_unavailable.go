@@ -0,0 +1,9 @@
+// +build !linux
+
+package secrethub
+
+// isMemoryBacked returns whether the directory at the given path is on a memory backed file system.
+// This cannot be determined on this platform, so no directory is considered memory backed.
+func isMemoryBacked(path string) (bool, error) {
+	return false, nil
+}
@@ -15,6 +15,7 @@ import (
 type ServiceLsCommand struct {
 	repoPath api.RepoPath
 	quiet    bool
+	format   listFormat
 
 	io              ui.IO
 	useTimestamps   bool
@@ -65,6 +66,7 @@ func (cmd *ServiceLsCommand) Register(r command.Registerer) {
 	clause.Arg("repo-path", "The path to the repository to list services for").Required().PlaceHolder(repoPathPlaceHolder).SetValue(&cmd.repoPath)
 	clause.Flag("quiet", "Only print service IDs.").Short('q').BoolVar(&cmd.quiet)
 	registerTimestampFlag(clause).BoolVar(&cmd.useTimestamps)
+	cmd.format.registerWithTemplate(clause)
 
 	command.BindAction(clause, cmd.Run)
 }
@@ -97,6 +99,20 @@ outer:
 		for _, service := range included {
 			fmt.Fprintf(cmd.io.Output(), "%s\n", service.ServiceID)
 		}
+	} else if !cmd.format.isTable() {
+		serviceTable := cmd.newServiceTable(NewTimestampFormatter())
+
+		formatter, err := cmd.format.newItemFormatter(cmd.io.Output(), serviceTable.fields()...)
+		if err != nil {
+			return err
+		}
+
+		for _, service := range included {
+			err = formatter.Write(service, serviceTable.row(service))
+			if err != nil {
+				return err
+			}
+		}
 	} else {
 		w := tabwriter.NewWriter(cmd.io.Output(), 0, 2, 2, ' ', 0)
 		serviceTable := cmd.newServiceTable(NewTimeFormatter(cmd.useTimestamps))
@@ -118,6 +134,7 @@ outer:
 
 type serviceTable interface {
 	header() []string
+	fields() []string
 	row(service *api.Service) []string
 }
 
@@ -130,6 +147,11 @@ func (sw baseServiceTable) header(content ...string) []string {
 	return append(res, "CREATED")
 }
 
+func (sw baseServiceTable) fields(content ...string) []string {
+	res := append([]string{"id", "description"}, content...)
+	return append(res, "created at")
+}
+
 func (sw baseServiceTable) row(service *api.Service, content ...string) []string {
 	res := append([]string{service.ServiceID, service.Description}, content...)
 	return append(res, sw.timeFormatter.Format(service.CreatedAt.Local()))
@@ -147,6 +169,10 @@ func (sw keyServiceTable) header() []string {
 	return sw.baseServiceTable.header("TYPE")
 }
 
+func (sw keyServiceTable) fields() []string {
+	return sw.baseServiceTable.fields("type")
+}
+
 func (sw keyServiceTable) row(service *api.Service) []string {
 	return sw.baseServiceTable.row(service, string(service.Credential.Type))
 }
@@ -163,6 +189,10 @@ func (sw awsServiceTable) header() []string {
 	return sw.baseServiceTable.header("ROLE", "KMS-KEY")
 }
 
+func (sw awsServiceTable) fields() []string {
+	return sw.baseServiceTable.fields("role", "kms key")
+}
+
 func (sw awsServiceTable) row(service *api.Service) []string {
 	return sw.baseServiceTable.row(service, service.Credential.Metadata[api.CredentialMetadataAWSRole], service.Credential.Metadata[api.CredentialMetadataAWSKMSKey])
 }
@@ -187,6 +217,10 @@ func (sw gcpServiceTable) header() []string {
 	return sw.baseServiceTable.header("SERVICE-ACCOUNT-EMAIL", "KMS-KEY")
 }
 
+func (sw gcpServiceTable) fields() []string {
+	return sw.baseServiceTable.fields("service account email", "kms key")
+}
+
 func (sw gcpServiceTable) row(service *api.Service) []string {
 	return sw.baseServiceTable.row(service, service.Credential.Metadata[api.CredentialMetadataGCPServiceAccountEmail], service.Credential.Metadata[api.CredentialMetadataGCPKMSKeyResourceID])
 }
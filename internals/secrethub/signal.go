@@ -0,0 +1,21 @@
+package secrethub
+
+import (
+	"os"
+	"strings"
+)
+
+// parseSignal returns the signal with the given name. The name is case insensitive
+// and can be given with or without the SIG prefix, e.g. SIGHUP or hup.
+func parseSignal(name string) (os.Signal, error) {
+	normalized := strings.ToUpper(name)
+	if !strings.HasPrefix(normalized, "SIG") {
+		normalized = "SIG" + normalized
+	}
+
+	signal, ok := signalsByName[normalized]
+	if !ok {
+		return nil, ErrUnknownSignal(name)
+	}
+	return signal, nil
+}
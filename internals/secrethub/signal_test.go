@@ -0,0 +1,43 @@
+package secrethub
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/secrethub/secrethub-go/internals/assert"
+)
+
+func TestParseSignal(t *testing.T) {
+	cases := map[string]struct {
+		name     string
+		expected os.Signal
+		err      error
+	}{
+		"full name": {
+			name:     "SIGHUP",
+			expected: syscall.SIGHUP,
+		},
+		"without prefix": {
+			name:     "TERM",
+			expected: syscall.SIGTERM,
+		},
+		"lowercase": {
+			name:     "sighup",
+			expected: syscall.SIGHUP,
+		},
+		"unknown signal": {
+			name: "SIGFOO",
+			err:  ErrUnknownSignal("SIGFOO"),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			actual, err := parseSignal(tc.name)
+
+			assert.Equal(t, err, tc.err)
+			assert.Equal(t, actual, tc.expected)
+		})
+	}
+}
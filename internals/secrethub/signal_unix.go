@@ -0,0 +1,87 @@
+// +build linux darwin
+
+package secrethub
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// signalsByName contains the signals that can be sent to a process by their name.
+var signalsByName = map[string]syscall.Signal{
+	"SIGHUP":   syscall.SIGHUP,
+	"SIGINT":   syscall.SIGINT,
+	"SIGQUIT":  syscall.SIGQUIT,
+	"SIGTERM":  syscall.SIGTERM,
+	"SIGUSR1":  syscall.SIGUSR1,
+	"SIGUSR2":  syscall.SIGUSR2,
+	"SIGWINCH": syscall.SIGWINCH,
+}
+
+// windowChangeSignal is sent to a process when the window size of its terminal changes.
+var windowChangeSignal os.Signal = syscall.SIGWINCH
+
+// setProcessGroup configures the command to start in its own process group.
+func setProcessGroup(command *exec.Cmd) {
+	if command.SysProcAttr == nil {
+		command.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	command.SysProcAttr.Setpgid = true
+}
+
+// setForegroundProcessGroup configures the command to start in its own process group,
+// which becomes the foreground process group of the given terminal.
+func setForegroundProcessGroup(command *exec.Cmd, tty *os.File) {
+	setProcessGroup(command)
+	command.SysProcAttr.Foreground = true
+	command.SysProcAttr.Ctty = int(tty.Fd())
+}
+
+// isForeground returns whether the process group of the run command is
+// the foreground process group of the given terminal.
+func isForeground(tty *os.File) bool {
+	pgrp, err := unix.IoctlGetInt(int(tty.Fd()), unix.TIOCGPGRP)
+	return err == nil && pgrp == syscall.Getpgrp()
+}
+
+// takeForeground makes the process group of the run command the foreground process group of the given terminal.
+// The process group must not be stopped when this is done from the background, see ignoreBackgroundSignals.
+func takeForeground(tty *os.File) error {
+	pgrp := int32(syscall.Getpgrp())
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, tty.Fd(), syscall.TIOCSPGRP, uintptr(unsafe.Pointer(&pgrp)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// ignoreBackgroundSignals ignores the signal that stops processes that write to
+// or configure their terminal while they are not in its foreground process group.
+func ignoreBackgroundSignals() {
+	signal.Ignore(syscall.SIGTTOU)
+}
+
+// signalProcessGroup sends the given signal to the process group led by the given process.
+// No error is returned when all processes in the group have already exited.
+func signalProcessGroup(process *os.Process, sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return process.Signal(sig)
+	}
+
+	err := syscall.Kill(-process.Pid, s)
+	if err == syscall.ESRCH {
+		return nil
+	}
+	return err
+}
+
+// killProcessGroup kills all processes in the process group led by the given process.
+func killProcessGroup(process *os.Process) error {
+	return signalProcessGroup(process, syscall.SIGKILL)
+}
@@ -0,0 +1,35 @@
+// +build linux darwin
+
+package secrethub
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/secrethub/secrethub-cli/internals/cli/ui/fakeui"
+
+	"github.com/secrethub/secrethub-go/internals/assert"
+)
+
+func TestRunCommand_interrupt(t *testing.T) {
+	// The process ignores interrupts, like an interactive process that handles them would.
+	cmd := RunCommand{
+		io:          fakeui.NewIO(t),
+		command:     []string{"sh", "-c", "trap '' INT; sleep 1"},
+		gracePeriod: 100 * time.Millisecond,
+		environment: &environment{
+			osStat: osStatFunc("secrethub.env", os.ErrNotExist),
+		},
+	}
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		_ = syscall.Kill(os.Getpid(), syscall.SIGINT)
+	}()
+
+	// A single interrupt is passed on to the process, without killing it after the grace period.
+	err := cmd.Run()
+	assert.OK(t, err)
+}
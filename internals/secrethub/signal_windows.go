@@ -0,0 +1,48 @@
+package secrethub
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// signalsByName contains the signals that can be sent to a process by their name.
+var signalsByName = map[string]syscall.Signal{
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGINT":  syscall.SIGINT,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGTERM": syscall.SIGTERM,
+}
+
+// windowChangeSignal is nil, as Windows has no signal for window size changes.
+var windowChangeSignal os.Signal
+
+// setProcessGroup does nothing, as process groups are not supported on Windows.
+func setProcessGroup(_ *exec.Cmd) {}
+
+// setForegroundProcessGroup does nothing, as process groups are not supported on Windows.
+func setForegroundProcessGroup(_ *exec.Cmd, _ *os.File) {}
+
+// isForeground returns false, as process groups are not supported on Windows.
+func isForeground(_ *os.File) bool {
+	return false
+}
+
+// takeForeground does nothing, as process groups are not supported on Windows.
+func takeForeground(_ *os.File) error {
+	return nil
+}
+
+// ignoreBackgroundSignals does nothing, as Windows has no signals for background processes.
+func ignoreBackgroundSignals() {}
+
+// signalProcessGroup sends the given signal to the given process,
+// as process groups are not supported on Windows.
+func signalProcessGroup(process *os.Process, sig os.Signal) error {
+	return process.Signal(sig)
+}
+
+// killProcessGroup kills the given process, as process groups are not supported on Windows.
+func killProcessGroup(process *os.Process) error {
+	return process.Kill()
+}
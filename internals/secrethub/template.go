@@ -1,9 +1,32 @@
 package secrethub
 
 import (
+	"github.com/secrethub/secrethub-cli/internals/cli/ui"
+	"github.com/secrethub/secrethub-cli/internals/secrethub/command"
 	"github.com/secrethub/secrethub-cli/internals/secrethub/tpl"
 )
 
+// TemplateCommand handles operations on templates for inject and env files for run.
+type TemplateCommand struct {
+	io        ui.IO
+	newClient newClientFunc
+}
+
+// NewTemplateCommand creates a new TemplateCommand.
+func NewTemplateCommand(io ui.IO, newClient newClientFunc) *TemplateCommand {
+	return &TemplateCommand{
+		io:        io,
+		newClient: newClient,
+	}
+}
+
+// Register registers the command and its sub-commands on the provided Registerer.
+func (cmd *TemplateCommand) Register(r command.Registerer) {
+	clause := r.Command("template", "Manage templates for inject and env files for run.")
+	NewTemplateLintCommand(cmd.io, cmd.newClient).Register(clause)
+	NewTemplateUpgradeCommand(cmd.io).Register(clause)
+}
+
 func getTemplateParser(raw []byte, version string) (tpl.Parser, error) {
 	switch version {
 	case "auto":
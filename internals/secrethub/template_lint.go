@@ -0,0 +1,558 @@
+package secrethub
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/secrethub/secrethub-cli/internals/cli"
+	"github.com/secrethub/secrethub-cli/internals/cli/ui"
+	"github.com/secrethub/secrethub-cli/internals/cli/validation"
+	"github.com/secrethub/secrethub-cli/internals/secrethub/command"
+	"github.com/secrethub/secrethub-cli/internals/secrethub/tpl"
+
+	"github.com/secrethub/secrethub-go/internals/api"
+	"github.com/secrethub/secrethub-go/internals/errio"
+	"github.com/secrethub/secrethub-go/pkg/secrethub"
+)
+
+// Errors
+var (
+	ErrTemplateLintFailed = errMain.Code("template_lint_failed").ErrorPref("found %d problem(s) in the templates")
+)
+
+const (
+	formatText  = "text"
+	formatSARIF = "sarif"
+
+	lintSeverityError   = "error"
+	lintSeverityWarning = "warning"
+
+	lintRuleSyntax            = "syntax"
+	lintRuleUndefinedVariable = "undefined-variable"
+	lintRuleEvaluation        = "evaluation"
+	lintRuleInvalidName       = "invalid-name"
+	lintRuleInvalidSecretPath = "invalid-secret-path"
+	lintRuleSecretNotFound    = "secret-not-found"
+	lintRuleSecretNotReadable = "secret-not-readable"
+	lintRuleAmbiguousVersion  = "ambiguous-version"
+
+	// lintVariablePlaceholder is the value of undefined template variables while linting.
+	// It cannot occur in secret paths, so secrets with an undefined variable in their path can be recognized.
+	lintVariablePlaceholder = "\x00"
+
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+// lintRule is a problem that is checked for by the template lint command.
+type lintRule struct {
+	ID          string
+	Description string
+}
+
+// lintRules contains all rules that are checked by the template lint command.
+var lintRules = []lintRule{
+	{ID: lintRuleSyntax, Description: "The template contains a syntax error."},
+	{ID: lintRuleUndefinedVariable, Description: "The template uses a template variable that is not defined and has no default value."},
+	{ID: lintRuleEvaluation, Description: "The template cannot be evaluated, for example because a required template variable is empty."},
+	{ID: lintRuleInvalidName, Description: "The env file contains an environment variable with an invalid name."},
+	{ID: lintRuleInvalidSecretPath, Description: "The template contains an invalid secret path."},
+	{ID: lintRuleSecretNotFound, Description: "The template refers to a secret that does not exist."},
+	{ID: lintRuleSecretNotReadable, Description: "The template refers to a secret that cannot be read."},
+	{ID: lintRuleAmbiguousVersion, Description: "The template contains secret tags of both template syntax versions, so the v2 tags are not replaced when the version is detected automatically."},
+}
+
+// TemplateLintCommand is a command to check templates for problems.
+type TemplateLintCommand struct {
+	io              ui.IO
+	newClient       newClientFunc
+	files           []string
+	format          string
+	templateVars    map[string]string
+	templateVersion string
+	noSecrets       bool
+	envFile         bool
+	osEnv           []string
+	readFile        func(filename string) ([]byte, error)
+}
+
+// NewTemplateLintCommand creates a new TemplateLintCommand.
+func NewTemplateLintCommand(io ui.IO, newClient newClientFunc) *TemplateLintCommand {
+	return &TemplateLintCommand{
+		io:           io,
+		newClient:    newClient,
+		templateVars: make(map[string]string),
+		osEnv:        os.Environ(),
+		readFile:     ioutil.ReadFile,
+	}
+}
+
+// Register registers the command, arguments and flags on the provided Registerer.
+func (cmd *TemplateLintCommand) Register(r command.Registerer) {
+	clause := r.Command("lint", "Check templates for inject and env files for run for problems, such as syntax errors, undefined template variables and secrets that do not exist or cannot be read.")
+	clause.HelpLong("Files with the .env extension are checked as env files for run, other files as templates for inject. " +
+		"The values of the secrets are never read, so they are not printed either. " +
+		"When a problem is found, the command exits with a non-zero exit code, so it can be used to check templates in a CI pipeline. " +
+		"Use the sarif format to annotate pull requests with the problems.")
+	clause.Arg("files", "The template files to check.").Required().StringsVar(&cmd.files)
+	clause.Flag("format", "The format in which to output the problems. Options are: text, json and sarif.").HintOptions(formatText, formatJSON, formatSARIF).Default(formatText).StringVar(&cmd.format)
+	clause.Flag("var", "Define the value for a template variable with `VAR=VALUE`, e.g. --var env=prod").Short('v').StringMapVar(&cmd.templateVars)
+	clause.Flag("template-version", "The template syntax version to be used. The options are v1, v2, latest or auto to automatically detect the version.").Default("auto").StringVar(&cmd.templateVersion)
+	clause.Flag("env-file", "Check the files as env files for run, even when they do not have the .env extension. Env files are checked for lines that are not key=value pairs and invalid environment variable names too.").BoolVar(&cmd.envFile)
+	clause.Flag("no-secrets", "Do not check whether the secrets exist and can be read, so that no credential is needed.").BoolVar(&cmd.noSecrets)
+
+	command.BindAction(clause, cmd.Run)
+}
+
+// Run checks the templates and writes the problems that are found.
+func (cmd *TemplateLintCommand) Run() error {
+	if cmd.format != formatText && cmd.format != formatJSON && cmd.format != formatSARIF {
+		return errNoSuchFormat(cmd.format)
+	}
+
+	osEnv, _ := parseKeyValueStringsToMap(cmd.osEnv)
+	varReader, err := newVariableReader(osEnv, cmd.templateVars)
+	if err != nil {
+		return err
+	}
+
+	var client secrethub.ClientInterface
+	if !cmd.noSecrets {
+		client, err = cmd.newClient()
+		if err != nil {
+			return err
+		}
+	}
+
+	diagnostics := []lintDiagnostic{}
+	for _, file := range cmd.files {
+		raw, err := cmd.readFile(file)
+		if err != nil {
+			return ErrReadFile(file, err)
+		}
+
+		fileDiagnostics, err := cmd.lint(file, raw, varReader, client)
+		if err != nil {
+			return err
+		}
+		diagnostics = append(diagnostics, fileDiagnostics...)
+	}
+
+	err = cmd.write(cmd.io.Output(), diagnostics)
+	if err != nil {
+		return err
+	}
+
+	errors := 0
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == lintSeverityError {
+			errors++
+		}
+	}
+	if errors > 0 {
+		return ErrTemplateLintFailed(errors)
+	}
+	return nil
+}
+
+// lint returns the problems in the given template. Env files are linted per environment variable,
+// the same way they are parsed by the run command. When the client is nil, the secrets are not checked.
+func (cmd *TemplateLintCommand) lint(file string, raw []byte, varReader tpl.VariableReader, client secrethub.ClientInterface) ([]lintDiagnostic, error) {
+	parser, err := getTemplateParser(raw, cmd.templateVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &templateLinter{
+		file:        file,
+		raw:         raw,
+		parser:      parser,
+		varReader:   newLintVariableReader(varReader),
+		collector:   newSecretPathCollector(),
+		diagnostics: []lintDiagnostic{},
+	}
+
+	if cmd.templateVersion == "auto" && tpl.IsV1Template(raw) && bytes.Contains(raw, []byte("{{")) {
+		l.add(lintSeverityWarning, lintRuleAmbiguousVersion, findPosition(raw, []byte("{{")),
+			"the template contains both v1 (${ path }) and v2 ({{ path }}) secret tags, so it is parsed as a v1 template and the v2 tags are not replaced; set --template-version to choose the version explicitly")
+	}
+
+	if cmd.envFile || filepath.Ext(file) == ".env" {
+		l.lintEnvFile()
+	} else {
+		l.lintTemplate(string(raw), [2]int{1, 1})
+	}
+
+	for _, name := range l.varReader.undefined {
+		l.add(lintSeverityError, lintRuleUndefinedVariable, findVariablePosition(raw, name),
+			fmt.Sprintf("template variable %s is not defined; set it with --var %s=<value> or the %s environment variable", name, name, templateVarEnvVarPrefix+strings.ToUpper(name)))
+	}
+
+	if client != nil {
+		l.checkSecrets(client)
+	}
+
+	return l.diagnostics, nil
+}
+
+// templateLinter collects the problems in a single template file.
+type templateLinter struct {
+	file        string
+	raw         []byte
+	parser      tpl.Parser
+	varReader   *lintVariableReader
+	collector   *secretPathCollector
+	diagnostics []lintDiagnostic
+}
+
+// add records a problem at the given line and column.
+func (l *templateLinter) add(severity, rule string, position [2]int, message string) {
+	l.diagnostics = append(l.diagnostics, lintDiagnostic{
+		File:     l.file,
+		Line:     position[0],
+		Column:   position[1],
+		Severity: severity,
+		Rule:     rule,
+		Message:  message,
+	})
+}
+
+// lintTemplate parses the given template, which starts at the given position in the file,
+// and evaluates it to collect the template variables and secrets it uses.
+func (l *templateLinter) lintTemplate(raw string, position [2]int) {
+	template, ok := l.parse(raw, position)
+	if !ok {
+		return
+	}
+
+	_, err := template.Evaluate(l.varReader, l.collector)
+	if err != nil {
+		l.add(lintSeverityError, lintRuleEvaluation, position, errorMessage(err))
+	}
+}
+
+// parse parses the given template, which starts at the given position in the file.
+// Syntax errors are recorded and reported with false.
+func (l *templateLinter) parse(raw string, position [2]int) (tpl.Template, bool) {
+	template, err := l.parser.Parse(raw, position[0], position[1])
+	if err != nil {
+		syntaxErr, ok := err.(tpl.SyntaxError)
+		if !ok {
+			l.add(lintSeverityError, lintRuleSyntax, [2]int{}, errorMessage(err))
+			return nil, false
+		}
+
+		line, column := syntaxErr.Position()
+		l.add(lintSeverityError, lintRuleSyntax, [2]int{line, column}, syntaxErr.Message())
+		return nil, false
+	}
+	return template, true
+}
+
+// lintEnvFile lints the file as an env file for the run command,
+// checking the name and the value of every environment variable in it.
+func (l *templateLinter) lintEnvFile() {
+	env, err := parseEnvironment(bytes.NewReader(l.raw))
+	if err != nil {
+		l.lintEnvFileLines()
+		return
+	}
+
+	sort.Slice(env, func(i, j int) bool {
+		if env[i].lineNumber != env[j].lineNumber {
+			return env[i].lineNumber < env[j].lineNumber
+		}
+		return env[i].key < env[j].key
+	})
+
+	for _, envvar := range env {
+		keyPosition := [2]int{envvar.lineNumber, envvar.columnNumberKey}
+		valuePosition := [2]int{envvar.lineNumber, envvar.columnNumberValue}
+		// The positions of the variables in a yml file are not known, so they are looked up instead.
+		if envvar.lineNumber < 0 {
+			keyPosition = findPosition(l.raw, []byte(envvar.key))
+			valuePosition = findPosition(l.raw, []byte(envvar.value))
+		}
+
+		_, ok := l.parse(envvar.key, keyPosition)
+		if ok {
+			err := validation.ValidateEnvarName(envvar.key)
+			if err != nil {
+				l.add(lintSeverityError, lintRuleInvalidName, keyPosition, errorMessage(err))
+			}
+		}
+
+		l.lintTemplate(envvar.value, valuePosition)
+	}
+}
+
+// lintEnvFileLines records every line of the env file that is not a key=value pair.
+func (l *templateLinter) lintEnvFileLines() {
+	for i, line := range strings.Split(string(l.raw), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.Contains(line, "=") {
+			continue
+		}
+		l.add(lintSeverityError, lintRuleSyntax, [2]int{i + 1, 1}, "line is not formatted as a key=value pair")
+	}
+}
+
+// checkSecrets checks whether the collected secrets exist and can be read.
+func (l *templateLinter) checkSecrets(client secrethub.ClientInterface) {
+	for _, path := range l.collector.Paths() {
+		// The path of the secret is unknown when it contains an undefined variable.
+		if strings.Contains(path, lintVariablePlaceholder) {
+			continue
+		}
+
+		position := findPosition(l.raw, []byte(path))
+
+		err := api.ValidateSecretPath(path)
+		if err != nil {
+			l.add(lintSeverityError, lintRuleInvalidSecretPath, position, fmt.Sprintf("invalid secret path %s: %s", path, err))
+			continue
+		}
+
+		_, err = client.Secrets().Versions().GetWithoutData(path)
+		if api.IsErrNotFound(err) {
+			l.add(lintSeverityError, lintRuleSecretNotFound, position, fmt.Sprintf("secret %s does not exist", path))
+		} else if err != nil {
+			l.add(lintSeverityError, lintRuleSecretNotReadable, position, fmt.Sprintf("secret %s cannot be read: %s", path, err))
+		}
+	}
+}
+
+// errorMessage returns the message of the error, without the error code of public errors.
+func errorMessage(err error) string {
+	publicErr, ok := err.(errio.PublicError)
+	if ok {
+		return publicErr.Message
+	}
+	return err.Error()
+}
+
+// write writes the diagnostics in the configured format.
+func (cmd *TemplateLintCommand) write(w io.Writer, diagnostics []lintDiagnostic) error {
+	switch cmd.format {
+	case formatJSON:
+		output, err := cli.PrettyJSON(diagnostics)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(w, output)
+		return err
+	case formatSARIF:
+		output, err := cli.PrettyJSON(newSARIFLog(diagnostics))
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(w, output)
+		return err
+	default:
+		for _, diagnostic := range diagnostics {
+			_, err := fmt.Fprintln(w, diagnostic)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// lintDiagnostic is a problem found in a template. Line and Column are 0 when the position of the problem is unknown.
+type lintDiagnostic struct {
+	File     string
+	Line     int
+	Column   int
+	Severity string
+	Rule     string
+	Message  string
+}
+
+// String returns the diagnostic in the format file:line:column: severity: message [rule].
+func (d lintDiagnostic) String() string {
+	location := d.File
+	if d.Line > 0 {
+		location = fmt.Sprintf("%s:%d:%d", d.File, d.Line, d.Column)
+	}
+	return fmt.Sprintf("%s: %s: %s [%s]", location, d.Severity, d.Message, d.Rule)
+}
+
+// findPosition returns the line and column of the first occurrence of the given
+// value in the raw template, or zeros when the value does not occur in it.
+func findPosition(raw []byte, value []byte) [2]int {
+	i := bytes.Index(raw, value)
+	if i < 0 {
+		return [2]int{}
+	}
+
+	lineStart := bytes.LastIndexByte(raw[:i], '\n') + 1
+	return [2]int{bytes.Count(raw[:i], []byte("\n")) + 1, utf8.RuneCount(raw[lineStart:i]) + 1}
+}
+
+// findVariablePosition returns the line and column of the first variable tag of
+// the given variable in the raw template, or zeros when no tag is found.
+func findVariablePosition(raw []byte, name string) [2]int {
+	pattern := regexp.MustCompile(`(?i)\$\{?[\t ]*` + regexp.QuoteMeta(name) + `\b`)
+	match := pattern.Find(raw)
+	if match == nil {
+		return [2]int{}
+	}
+	return findPosition(raw, match)
+}
+
+// lintVariableReader implements tpl.VariableReader by recording the variables that are not defined,
+// instead of returning an error for them, so that all of them can be reported.
+type lintVariableReader struct {
+	reader    tpl.VariableReader
+	undefined []string
+	seen      map[string]struct{}
+}
+
+func newLintVariableReader(reader tpl.VariableReader) *lintVariableReader {
+	return &lintVariableReader{
+		reader: reader,
+		seen:   make(map[string]struct{}),
+	}
+}
+
+// ReadVariable reads the variable with the underlying reader. When the variable is not defined,
+// it is recorded and a placeholder value is returned.
+func (r *lintVariableReader) ReadVariable(name string) (string, error) {
+	value, err := r.reader.ReadVariable(name)
+	if err != tpl.ErrTemplateVarNotFound(name) {
+		return value, err
+	}
+
+	if _, ok := r.seen[name]; !ok {
+		r.seen[name] = struct{}{}
+		r.undefined = append(r.undefined, name)
+	}
+	return lintVariablePlaceholder + name, nil
+}
+
+// LookupVariable looks up the variable with the underlying reader. Variables that are looked up
+// have a default value, so they are not recorded when they are not defined.
+func (r *lintVariableReader) LookupVariable(name string) (string, bool, error) {
+	value, err := r.reader.ReadVariable(name)
+	if err == tpl.ErrTemplateVarNotFound(name) {
+		return "", false, nil
+	}
+	return value, err == nil, err
+}
+
+// sarifLog is the root of a report in the Static Analysis Results Interchange Format (SARIF).
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+// newSARIFLog returns a SARIF report of the given diagnostics.
+func newSARIFLog(diagnostics []lintDiagnostic) sarifLog {
+	rules := make([]sarifRule, len(lintRules))
+	for i, rule := range lintRules {
+		rules[i] = sarifRule{
+			ID:               rule.ID,
+			ShortDescription: sarifMessage{Text: rule.Description},
+		}
+	}
+
+	results := make([]sarifResult, len(diagnostics))
+	for i, diagnostic := range diagnostics {
+		location := sarifLocation{
+			PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(diagnostic.File)},
+			},
+		}
+		if diagnostic.Line > 0 {
+			location.PhysicalLocation.Region = &sarifRegion{
+				StartLine:   diagnostic.Line,
+				StartColumn: diagnostic.Column,
+			}
+		}
+
+		results[i] = sarifResult{
+			RuleID:    diagnostic.Rule,
+			Level:     diagnostic.Severity,
+			Message:   sarifMessage{Text: diagnostic.Message},
+			Locations: []sarifLocation{location},
+		}
+	}
+
+	return sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{
+			{
+				Tool: sarifTool{
+					Driver: sarifDriver{
+						Name:           ApplicationName,
+						InformationURI: "https://secrethub.io",
+						Rules:          rules,
+					},
+				},
+				Results: results,
+			},
+		},
+	}
+}
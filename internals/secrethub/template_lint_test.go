@@ -0,0 +1,345 @@
+package secrethub
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/secrethub/secrethub-cli/internals/cli/ui/fakeui"
+
+	"github.com/secrethub/secrethub-go/internals/api"
+	"github.com/secrethub/secrethub-go/internals/assert"
+	"github.com/secrethub/secrethub-go/pkg/secrethub"
+	"github.com/secrethub/secrethub-go/pkg/secrethub/fakeclient"
+)
+
+func TestTemplateLintCommand_Run(t *testing.T) {
+	testErr := errors.New("test error")
+
+	secretExists := func(path string) (*api.SecretVersion, error) {
+		return &api.SecretVersion{}, nil
+	}
+
+	cases := map[string]struct {
+		cmd                  TemplateLintCommand
+		secretVersionService fakeclient.SecretVersionService
+		newClientErr         error
+		out                  string
+		err                  error
+	}{
+		"no problems": {
+			cmd: TemplateLintCommand{
+				files:           []string{"secrethub.env"},
+				templateVersion: "auto",
+				format:          formatText,
+				readFile:        readFileFunc("secrethub.env", "A={{ company/repo/a }}\nB={{ company/repo/b#.password | base64 }}\n"),
+			},
+			secretVersionService: fakeclient.SecretVersionService{
+				GetWithoutDataFunc: secretExists,
+			},
+			out: "",
+		},
+		"syntax error": {
+			cmd: TemplateLintCommand{
+				files:           []string{"secrethub.env"},
+				templateVersion: "auto",
+				format:          formatText,
+				readFile:        readFileFunc("secrethub.env", "A={{ company/repo/a }}\nB={{ company/repo/b"),
+			},
+			secretVersionService: fakeclient.SecretVersionService{
+				GetWithoutDataFunc: secretExists,
+			},
+			out: "secrethub.env:2:20: error: expected the closing of a secret tag `}}`, but reached the end of the template. [syntax]\n",
+			err: ErrTemplateLintFailed(1),
+		},
+		"malformed line": {
+			cmd: TemplateLintCommand{
+				files:           []string{"secrethub.env"},
+				templateVersion: "auto",
+				format:          formatText,
+				readFile:        readFileFunc("secrethub.env", "A={{ company/repo/a }}\n# comment\nB\n"),
+			},
+			out: "secrethub.env:3:1: error: line is not formatted as a key=value pair [syntax]\n",
+			err: ErrTemplateLintFailed(1),
+		},
+		"invalid name": {
+			cmd: TemplateLintCommand{
+				files:           []string{"secrethub.env"},
+				templateVersion: "auto",
+				format:          formatText,
+				readFile:        readFileFunc("secrethub.env", "A={{ company/repo/a }}\n B\u00e9=foo\n"),
+			},
+			secretVersionService: fakeclient.SecretVersionService{
+				GetWithoutDataFunc: secretExists,
+			},
+			out: "secrethub.env:2:2: error: environment variable names may not contain NUL or = characters and may only contain characters of the portable character set defined in IEEE Std 1003.1: B\u00e9 [invalid-name]\n",
+			err: ErrTemplateLintFailed(1),
+		},
+		"env file flag": {
+			cmd: TemplateLintCommand{
+				files:           []string{"config"},
+				templateVersion: "auto",
+				format:          formatText,
+				envFile:         true,
+				readFile:        readFileFunc("config", "A=foo\nB\n"),
+			},
+			out: "config:2:1: error: line is not formatted as a key=value pair [syntax]\n",
+			err: ErrTemplateLintFailed(1),
+		},
+		"template file": {
+			cmd: TemplateLintCommand{
+				files:           []string{"config.yml"},
+				templateVersion: "auto",
+				format:          formatText,
+				readFile:        readFileFunc("config.yml", "password: {{ company/repo/a }}\nB\n"),
+			},
+			secretVersionService: fakeclient.SecretVersionService{
+				GetWithoutDataFunc: secretExists,
+			},
+			out: "",
+		},
+		"required variable empty": {
+			cmd: TemplateLintCommand{
+				files:           []string{"secrethub.env"},
+				templateVersion: "auto",
+				format:          formatText,
+				templateVars:    map[string]string{"env": ""},
+				readFile:        readFileFunc("secrethub.env", "A={{ company/${env:?env must be set}/a }}\nB={{ company/repo/b }}\n"),
+			},
+			secretVersionService: fakeclient.SecretVersionService{
+				GetWithoutDataFunc: func(path string) (*api.SecretVersion, error) {
+					return nil, api.ErrSecretNotFound
+				},
+			},
+			out: "secrethub.env:1:3: error: no value was supplied for required template variable 'env': env must be set [evaluation]\n" +
+				"secrethub.env:2:6: error: secret company/repo/b does not exist [secret-not-found]\n",
+			err: ErrTemplateLintFailed(2),
+		},
+		"undefined variable": {
+			cmd: TemplateLintCommand{
+				files:           []string{"secrethub.env"},
+				templateVersion: "auto",
+				format:          formatText,
+				readFile:        readFileFunc("secrethub.env", "A={{ company/${env}/a }}\nB={{ company/repo/${app:-b} }}\n"),
+			},
+			secretVersionService: fakeclient.SecretVersionService{
+				GetWithoutDataFunc: func(path string) (*api.SecretVersion, error) {
+					if path != "company/repo/b" {
+						return nil, testErr
+					}
+					return &api.SecretVersion{}, nil
+				},
+			},
+			out: "secrethub.env:1:14: error: template variable env is not defined; set it with --var env=<value> or the SECRETHUB_VAR_ENV environment variable [undefined-variable]\n",
+			err: ErrTemplateLintFailed(1),
+		},
+		"variable defined": {
+			cmd: TemplateLintCommand{
+				files:           []string{"secrethub.env"},
+				templateVersion: "auto",
+				format:          formatText,
+				templateVars:    map[string]string{"env": "prod"},
+				readFile:        readFileFunc("secrethub.env", "A={{ company/${env}/a }}\n"),
+			},
+			secretVersionService: fakeclient.SecretVersionService{
+				GetWithoutDataFunc: func(path string) (*api.SecretVersion, error) {
+					if path != "company/prod/a" {
+						return nil, testErr
+					}
+					return &api.SecretVersion{}, nil
+				},
+			},
+			out: "",
+		},
+		"secret not found": {
+			cmd: TemplateLintCommand{
+				files:           []string{"secrethub.env"},
+				templateVersion: "auto",
+				format:          formatText,
+				readFile:        readFileFunc("secrethub.env", "A={{ company/repo/a }}\nB={{ company/repo/b }}\n"),
+			},
+			secretVersionService: fakeclient.SecretVersionService{
+				GetWithoutDataFunc: func(path string) (*api.SecretVersion, error) {
+					if path == "company/repo/b" {
+						return nil, api.ErrSecretNotFound
+					}
+					return &api.SecretVersion{}, nil
+				},
+			},
+			out: "secrethub.env:2:6: error: secret company/repo/b does not exist [secret-not-found]\n",
+			err: ErrTemplateLintFailed(1),
+		},
+		"secret not readable": {
+			cmd: TemplateLintCommand{
+				files:           []string{"secrethub.env"},
+				templateVersion: "auto",
+				format:          formatText,
+				readFile:        readFileFunc("secrethub.env", "A={{ company/repo/a }}\n"),
+			},
+			secretVersionService: fakeclient.SecretVersionService{
+				GetWithoutDataFunc: func(path string) (*api.SecretVersion, error) {
+					return nil, testErr
+				},
+			},
+			out: "secrethub.env:1:6: error: secret company/repo/a cannot be read: test error [secret-not-readable]\n",
+			err: ErrTemplateLintFailed(1),
+		},
+		"no secrets": {
+			cmd: TemplateLintCommand{
+				files:           []string{"secrethub.env"},
+				templateVersion: "auto",
+				format:          formatText,
+				noSecrets:       true,
+				readFile:        readFileFunc("secrethub.env", "A={{ company/repo/a }}\n"),
+			},
+			newClientErr: testErr,
+			out:          "",
+		},
+		"ambiguous version": {
+			cmd: TemplateLintCommand{
+				files:           []string{"secrethub.env"},
+				templateVersion: "auto",
+				format:          formatText,
+				readFile:        readFileFunc("secrethub.env", "A=${ company/repo/a }\nB={{ company/repo/b }}\n"),
+			},
+			secretVersionService: fakeclient.SecretVersionService{
+				GetWithoutDataFunc: secretExists,
+			},
+			out: "secrethub.env:2:3: warning: the template contains both v1 (${ path }) and v2 ({{ path }}) secret tags, " +
+				"so it is parsed as a v1 template and the v2 tags are not replaced; set --template-version to choose the version explicitly [ambiguous-version]\n",
+		},
+		"json": {
+			cmd: TemplateLintCommand{
+				files:           []string{"secrethub.env"},
+				templateVersion: "auto",
+				format:          formatJSON,
+				readFile:        readFileFunc("secrethub.env", "A={{ company/repo/a }}\n"),
+			},
+			secretVersionService: fakeclient.SecretVersionService{
+				GetWithoutDataFunc: func(path string) (*api.SecretVersion, error) {
+					return nil, api.ErrSecretNotFound
+				},
+			},
+			out: "" +
+				"[\n" +
+				"    {\n" +
+				"        \"File\": \"secrethub.env\",\n" +
+				"        \"Line\": 1,\n" +
+				"        \"Column\": 6,\n" +
+				"        \"Severity\": \"error\",\n" +
+				"        \"Rule\": \"secret-not-found\",\n" +
+				"        \"Message\": \"secret company/repo/a does not exist\"\n" +
+				"    }\n" +
+				"]\n",
+			err: ErrTemplateLintFailed(1),
+		},
+		"invalid format": {
+			cmd: TemplateLintCommand{
+				files:  []string{"secrethub.env"},
+				format: "xml",
+			},
+			err: errNoSuchFormat("xml"),
+		},
+		"file not found": {
+			cmd: TemplateLintCommand{
+				files:    []string{"foo.env"},
+				format:   formatText,
+				readFile: readFileFunc("secrethub.env", ""),
+			},
+			secretVersionService: fakeclient.SecretVersionService{
+				GetWithoutDataFunc: secretExists,
+			},
+			err: ErrReadFile("foo.env", os.ErrNotExist),
+		},
+		"no client": {
+			cmd: TemplateLintCommand{
+				files:           []string{"secrethub.env"},
+				templateVersion: "auto",
+				format:          formatText,
+				readFile:        readFileFunc("secrethub.env", "A={{ company/repo/a }}\n"),
+			},
+			newClientErr: testErr,
+			err:          testErr,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			tc.cmd.newClient = func() (secrethub.ClientInterface, error) {
+				return fakeclient.Client{
+					SecretService: &fakeclient.SecretService{
+						VersionService: &tc.secretVersionService,
+					},
+				}, tc.newClientErr
+			}
+
+			io := fakeui.NewIO(t)
+			tc.cmd.io = io
+
+			// Act
+			err := tc.cmd.Run()
+
+			// Assert
+			assert.Equal(t, err, tc.err)
+			assert.Equal(t, io.Out.String(), tc.out)
+		})
+	}
+}
+
+func TestNewSARIFLog(t *testing.T) {
+	diagnostics := []lintDiagnostic{
+		{
+			File:     "deploy/secrethub.env",
+			Line:     2,
+			Column:   6,
+			Severity: lintSeverityError,
+			Rule:     lintRuleSecretNotFound,
+			Message:  "secret company/repo/b does not exist",
+		},
+		{
+			File:     "config.yml",
+			Severity: lintSeverityError,
+			Rule:     lintRuleSyntax,
+			Message:  "template is not formatted as key=value pairs",
+		},
+	}
+
+	expected := []sarifResult{
+		{
+			RuleID:  lintRuleSecretNotFound,
+			Level:   "error",
+			Message: sarifMessage{Text: "secret company/repo/b does not exist"},
+			Locations: []sarifLocation{
+				{
+					PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifactLocation{URI: "deploy/secrethub.env"},
+						Region: &sarifRegion{
+							StartLine:   2,
+							StartColumn: 6,
+						},
+					},
+				},
+			},
+		},
+		{
+			RuleID:  lintRuleSyntax,
+			Level:   "error",
+			Message: sarifMessage{Text: "template is not formatted as key=value pairs"},
+			Locations: []sarifLocation{
+				{
+					PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifactLocation{URI: "config.yml"},
+					},
+				},
+			},
+		},
+	}
+
+	actual := newSARIFLog(diagnostics)
+
+	assert.Equal(t, actual.Version, sarifVersion)
+	assert.Equal(t, len(actual.Runs), 1)
+	assert.Equal(t, len(actual.Runs[0].Tool.Driver.Rules), len(lintRules))
+	assert.Equal(t, actual.Runs[0].Results, expected)
+}
@@ -0,0 +1,102 @@
+package secrethub
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/secrethub/secrethub-cli/internals/cli/ui"
+	"github.com/secrethub/secrethub-cli/internals/secrethub/command"
+	"github.com/secrethub/secrethub-cli/internals/secrethub/tpl"
+)
+
+// Errors
+var (
+	ErrTemplateUpgradeFailed   = errMain.Code("template_upgrade_failed").ErrorPref("could not upgrade template %s: %s")
+	ErrTemplateUpgradeRequired = errMain.Code("template_upgrade_required").ErrorPref("%d template(s) must be upgraded to the v2 syntax")
+)
+
+// TemplateUpgradeCommand is a command to upgrade templates from the v1 to the v2 syntax.
+type TemplateUpgradeCommand struct {
+	io        ui.IO
+	files     []string
+	check     bool
+	write     bool
+	readFile  func(filename string) ([]byte, error)
+	writeFile func(filename string, data []byte, perm os.FileMode) error
+}
+
+// NewTemplateUpgradeCommand creates a new TemplateUpgradeCommand.
+func NewTemplateUpgradeCommand(io ui.IO) *TemplateUpgradeCommand {
+	return &TemplateUpgradeCommand{
+		io:        io,
+		readFile:  ioutil.ReadFile,
+		writeFile: ioutil.WriteFile,
+	}
+}
+
+// Register registers the command, arguments and flags on the provided Registerer.
+func (cmd *TemplateUpgradeCommand) Register(r command.Registerer) {
+	clause := r.Command("upgrade", "Upgrade templates for inject and env files for run from the v1 to the v2 syntax.")
+	clause.HelpLong("Secret tags like ${ path/to/secret } are rewritten to {{ path/to/secret }}. " +
+		"Characters outside of the secret tags that have a meaning in v2 templates are escaped with a backslash, so the upgraded template results in exactly the same output. " +
+		"Templates that are not detected as v1 templates are left unchanged. " +
+		"By default, the upgraded templates are written to stdout.")
+	clause.Arg("files", "The template files to upgrade.").Required().StringsVar(&cmd.files)
+	clause.Flag("check", "Do not upgrade the templates, but list the templates that need to be upgraded and exit with a non-zero exit code when there are any.").BoolVar(&cmd.check)
+	clause.Flag("write", "Write the upgraded templates back to their files instead of to stdout and list the upgraded templates.").Short('w').BoolVar(&cmd.write)
+
+	command.BindAction(clause, cmd.Run)
+}
+
+// Run upgrades the templates.
+func (cmd *TemplateUpgradeCommand) Run() error {
+	if cmd.check && cmd.write {
+		return ErrFlagsConflict("--check and --write")
+	}
+
+	upgradeRequired := 0
+	for _, file := range cmd.files {
+		raw, err := cmd.readFile(file)
+		if err != nil {
+			return ErrReadFile(file, err)
+		}
+
+		isV1 := tpl.IsV1Template(raw)
+
+		upgraded := raw
+		if isV1 {
+			res, err := tpl.UpgradeV1(string(raw))
+			if err != nil {
+				return ErrTemplateUpgradeFailed(file, err)
+			}
+			upgraded = []byte(res)
+		}
+
+		switch {
+		case cmd.check:
+			if isV1 {
+				upgradeRequired++
+				fmt.Fprintln(cmd.io.Output(), file)
+			}
+		case cmd.write:
+			if isV1 {
+				err = cmd.writeFile(file, upgraded, 0600)
+				if err != nil {
+					return ErrCannotWrite(file, err)
+				}
+				fmt.Fprintln(cmd.io.Output(), file)
+			}
+		default:
+			_, err = cmd.io.Output().Write(upgraded)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if upgradeRequired > 0 {
+		return ErrTemplateUpgradeRequired(upgradeRequired)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package secrethub
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/secrethub/secrethub-cli/internals/cli/ui/fakeui"
+	"github.com/secrethub/secrethub-cli/internals/secrethub/tpl"
+
+	"github.com/secrethub/secrethub-go/internals/assert"
+)
+
+func TestTemplateUpgradeCommand_Run(t *testing.T) {
+	testErr := errors.New("test error")
+
+	readFile := func(filename string) ([]byte, error) {
+		switch filename {
+		case "v1.env":
+			return []byte("A=${ company/repo/a }\nHOME=$HOME\n"), nil
+		case "v2.env":
+			return []byte("A={{ company/repo/a }}\n"), nil
+		case "invalid.env":
+			return []byte("A=${ company/repo/a }\nB=${ company/$repo/b }\n"), nil
+		}
+		return nil, os.ErrNotExist
+	}
+
+	cases := map[string]struct {
+		cmd     TemplateUpgradeCommand
+		written map[string]string
+		out     string
+		err     error
+	}{
+		"stdout": {
+			cmd: TemplateUpgradeCommand{
+				files:    []string{"v1.env", "v2.env"},
+				readFile: readFile,
+			},
+			written: map[string]string{},
+			out:     "A={{ company/repo/a }}\nHOME=\\$HOME\nA={{ company/repo/a }}\n",
+		},
+		"check upgrade required": {
+			cmd: TemplateUpgradeCommand{
+				files:    []string{"v1.env", "v2.env"},
+				check:    true,
+				readFile: readFile,
+			},
+			written: map[string]string{},
+			out:     "v1.env\n",
+			err:     ErrTemplateUpgradeRequired(1),
+		},
+		"check no upgrade required": {
+			cmd: TemplateUpgradeCommand{
+				files:    []string{"v2.env"},
+				check:    true,
+				readFile: readFile,
+			},
+			written: map[string]string{},
+			out:     "",
+		},
+		"write": {
+			cmd: TemplateUpgradeCommand{
+				files:    []string{"v1.env", "v2.env"},
+				write:    true,
+				readFile: readFile,
+			},
+			written: map[string]string{
+				"v1.env": "A={{ company/repo/a }}\nHOME=\\$HOME\n",
+			},
+			out: "v1.env\n",
+		},
+		"write error": {
+			cmd: TemplateUpgradeCommand{
+				files:    []string{"v1.env"},
+				write:    true,
+				readFile: readFile,
+				writeFile: func(filename string, data []byte, perm os.FileMode) error {
+					return testErr
+				},
+			},
+			written: map[string]string{},
+			err:     ErrCannotWrite("v1.env", testErr),
+		},
+		"cannot upgrade": {
+			cmd: TemplateUpgradeCommand{
+				files:    []string{"invalid.env"},
+				readFile: readFile,
+			},
+			written: map[string]string{},
+			err:     ErrTemplateUpgradeFailed("invalid.env", tpl.ErrCannotUpgradeSecretTag("company/$repo/b")),
+		},
+		"file not found": {
+			cmd: TemplateUpgradeCommand{
+				files:    []string{"foo.env"},
+				readFile: readFile,
+			},
+			written: map[string]string{},
+			err:     ErrReadFile("foo.env", os.ErrNotExist),
+		},
+		"check and write": {
+			cmd: TemplateUpgradeCommand{
+				files: []string{"v1.env"},
+				check: true,
+				write: true,
+			},
+			written: map[string]string{},
+			err:     ErrFlagsConflict("--check and --write"),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			written := map[string]string{}
+			if tc.cmd.writeFile == nil {
+				tc.cmd.writeFile = func(filename string, data []byte, perm os.FileMode) error {
+					written[filename] = string(data)
+					return nil
+				}
+			}
+
+			io := fakeui.NewIO(t)
+			tc.cmd.io = io
+
+			// Act
+			err := tc.cmd.Run()
+
+			// Assert
+			assert.Equal(t, err, tc.err)
+			assert.Equal(t, io.Out.String(), tc.out)
+			assert.Equal(t, written, tc.written)
+		})
+	}
+}
@@ -2,13 +2,30 @@ package tpl
 
 import (
 	"fmt"
+	"strings"
 )
 
 // Evaluate errors
 var (
 	ErrTemplateVarNotFound = tplError.Code("template_var_not_found").ErrorPref("no value was supplied for template variable '%s'")
+	ErrTemplateVarRequired = tplError.Code("template_var_required").ErrorPref("no value was supplied for required template variable '%s': %s")
+	ErrInvalidSecretField  = tplError.Code("invalid_secret_field").ErrorPref("invalid field selector '%s': a selector consists of keys that are each preceded by a dot, e.g. .credentials.password")
 )
 
+// Upgrade errors
+var (
+	ErrCannotUpgradeSecretTag = tplError.Code("cannot_upgrade_secret_tag").ErrorPref("cannot upgrade secret tag '${ %s }' to the v2 syntax: the path contains characters that have a different meaning in v2 templates")
+)
+
+// SyntaxError is an error in the syntax of a template at a specific position in the template.
+type SyntaxError interface {
+	error
+	// Position returns the line and column at which the error occurred.
+	Position() (int, int)
+	// Message returns the description of the error, without its position.
+	Message() string
+}
+
 // Parse errors
 type templateSyntaxError struct {
 	lineNo int
@@ -21,6 +38,16 @@ func (err templateSyntaxError) Error() string {
 	return tplError.Code(err.code).Errorf("template syntax error at %d:%d: %s", err.lineNo, err.colNo, err.msg).Error()
 }
 
+// Position implements SyntaxError.
+func (err templateSyntaxError) Position() (int, int) {
+	return err.lineNo, err.colNo
+}
+
+// Message implements SyntaxError.
+func (err templateSyntaxError) Message() string {
+	return err.msg
+}
+
 // ErrUnexpectedCharacter is returned when expecting a specific character, for example
 // the first character of a closing delimiter after a space occurred in a tag, or
 // the second character of a closing delimiter after the first character of the closing
@@ -73,3 +100,64 @@ func ErrVariableTagNotClosed(lineNo, colNo int) error {
 		msg:    "expected the closing of a variable tag `}`, but reached the end of the template.",
 	}
 }
+
+// ErrMissingFilterName is returned when a filter separator in a secret tag is not followed by the name of a filter.
+func ErrMissingFilterName(lineNo, colNo int, char rune) error {
+	return templateSyntaxError{
+		lineNo: lineNo,
+		colNo:  colNo,
+		code:   "missing_filter_name",
+		msg:    fmt.Sprintf("unexpected '%c', expected the name of a filter after '|'", char),
+	}
+}
+
+// ErrUnknownFilter is returned when a secret tag contains a filter that does not exist.
+func ErrUnknownFilter(lineNo, colNo int, name string) error {
+	return templateSyntaxError{
+		lineNo: lineNo,
+		colNo:  colNo,
+		code:   "unknown_filter",
+		msg:    fmt.Sprintf("unknown filter '%s'. The available filters are: %s.", name, strings.Join(filterNames(), ", ")),
+	}
+}
+
+// ErrInvalidFilterArguments is returned when a filter in a secret tag is given invalid arguments.
+func ErrInvalidFilterArguments(lineNo, colNo int, name string, err error) error {
+	return templateSyntaxError{
+		lineNo: lineNo,
+		colNo:  colNo,
+		code:   "invalid_filter_arguments",
+		msg:    fmt.Sprintf("invalid arguments for filter '%s': %s", name, err),
+	}
+}
+
+// ErrIllegalFilterCharacter is returned when a filter in a secret tag contains a character that is not allowed.
+func ErrIllegalFilterCharacter(lineNo, colNo int, char rune) error {
+	return templateSyntaxError{
+		lineNo: lineNo,
+		colNo:  colNo,
+		code:   "illegal_filter_character",
+		msg:    fmt.Sprintf("illegal character '%c'. Filter names and arguments can only contain letters, digits, underscores and hyphens.", char),
+	}
+}
+
+// ErrIllegalVariableModifier is returned when the name of a variable in a variable tag is followed
+// by a colon that does not start a default value or a required marker.
+func ErrIllegalVariableModifier(lineNo, colNo int, char rune) error {
+	return templateSyntaxError{
+		lineNo: lineNo,
+		colNo:  colNo,
+		code:   "illegal_variable_modifier",
+		msg:    fmt.Sprintf("unexpected '%c' after ':'. Use ':-' to give a default value or ':?' to give an error message for a required variable.", char),
+	}
+}
+
+// ErrIllegalFieldCharacter is returned when the field selector in a secret tag contains a character that is not allowed.
+func ErrIllegalFieldCharacter(lineNo, colNo int, char rune) error {
+	return templateSyntaxError{
+		lineNo: lineNo,
+		colNo:  colNo,
+		code:   "illegal_field_character",
+		msg:    fmt.Sprintf("illegal character '%c'. Field selectors consist of keys that are each preceded by a dot and can only contain letters, digits, underscores and hyphens.", char),
+	}
+}
@@ -0,0 +1,42 @@
+package tpl
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SecretFieldSeparator separates the path of a secret from the selector of a field in the secret,
+// e.g. `path/to/secret#.credentials.password`.
+const SecretFieldSeparator = "#"
+
+// SplitSecretField splits a reference to a secret into the path of the secret and the selector
+// of a field in the secret. The selector is empty when the reference does not contain one.
+func SplitSecretField(ref string) (string, string) {
+	i := strings.Index(ref, SecretFieldSeparator)
+	if i < 0 {
+		return ref, ""
+	}
+	return ref[:i], ref[i+len(SecretFieldSeparator):]
+}
+
+// ParseSecretField parses the selector of a field in a JSON or YAML secret into the keys it consists of.
+// A selector is a sequence of keys that are each preceded by a dot, e.g. `.credentials.password`.
+// Keys can contain letters, digits, underscores and hyphens. Numeric keys also select elements of lists.
+func ParseSecretField(field string) ([]string, error) {
+	if !strings.HasPrefix(field, ".") {
+		return nil, ErrInvalidSecretField(field)
+	}
+
+	keys := strings.Split(field[1:], ".")
+	for _, key := range keys {
+		if key == "" || strings.IndexFunc(key, func(r rune) bool { return !isSecretFieldRune(r) }) >= 0 {
+			return nil, ErrInvalidSecretField(field)
+		}
+	}
+	return keys, nil
+}
+
+// isSecretFieldRune returns whether the given rune is allowed to be used in a key of a field selector.
+func isSecretFieldRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-'
+}
@@ -0,0 +1,83 @@
+package tpl
+
+import (
+	"testing"
+
+	"github.com/secrethub/secrethub-go/internals/assert"
+)
+
+func TestSplitSecretField(t *testing.T) {
+	cases := map[string]struct {
+		ref           string
+		expectedPath  string
+		expectedField string
+	}{
+		"without field": {
+			ref:          "namespace/repo/secret",
+			expectedPath: "namespace/repo/secret",
+		},
+		"with field": {
+			ref:           "namespace/repo/secret#.credentials.password",
+			expectedPath:  "namespace/repo/secret",
+			expectedField: ".credentials.password",
+		},
+		"with version and field": {
+			ref:           "namespace/repo/secret:1#.password",
+			expectedPath:  "namespace/repo/secret:1",
+			expectedField: ".password",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			path, field := SplitSecretField(tc.ref)
+			assert.Equal(t, path, tc.expectedPath)
+			assert.Equal(t, field, tc.expectedField)
+		})
+	}
+}
+
+func TestParseSecretField(t *testing.T) {
+	cases := map[string]struct {
+		field    string
+		expected []string
+		err      error
+	}{
+		"single key": {
+			field:    ".password",
+			expected: []string{"password"},
+		},
+		"nested keys": {
+			field:    ".credentials.db_user-1.0",
+			expected: []string{"credentials", "db_user-1", "0"},
+		},
+		"no leading dot": {
+			field: "password",
+			err:   ErrInvalidSecretField("password"),
+		},
+		"empty": {
+			field: "",
+			err:   ErrInvalidSecretField(""),
+		},
+		"empty key": {
+			field: ".credentials..password",
+			err:   ErrInvalidSecretField(".credentials..password"),
+		},
+		"trailing dot": {
+			field: ".password.",
+			err:   ErrInvalidSecretField(".password."),
+		},
+		"illegal character": {
+			field: ".pass word",
+			err:   ErrInvalidSecretField(".pass word"),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			actual, err := ParseSecretField(tc.field)
+			assert.Equal(t, err, tc.err)
+			assert.Equal(t, actual, tc.expected)
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package tpl
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// filter transforms the value of a secret in a secret tag, e.g. `{{ path/to/secret | base64 }}`.
+type filter interface {
+	apply(value string) string
+}
+
+// newFilterFunc creates a filter from the arguments given to it in a template.
+type newFilterFunc func(args []string) (filter, error)
+
+// filtersByName contains the filters that can be used in secret tags by their name.
+var filtersByName = map[string]newFilterFunc{
+	"base64": newFilterWithoutArgs(base64Filter{}),
+	"json":   newFilterWithoutArgs(jsonFilter{}),
+	"yaml":   newFilterWithoutArgs(yamlFilter{}),
+	"trim":   newFilterWithoutArgs(trimFilter{}),
+	"sha256": newFilterWithoutArgs(sha256Filter{}),
+	"indent": newIndentFilter,
+}
+
+// filterNames returns the names of all available filters in alphabetical order.
+func filterNames() []string {
+	names := make([]string, 0, len(filtersByName))
+	for name := range filtersByName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newFilterWithoutArgs returns a newFilterFunc for a filter that does not take any arguments.
+func newFilterWithoutArgs(f filter) newFilterFunc {
+	return func(args []string) (filter, error) {
+		if len(args) != 0 {
+			return nil, fmt.Errorf("expected no arguments, got %d", len(args))
+		}
+		return f, nil
+	}
+}
+
+// base64Filter encodes the value with standard base64 encoding.
+type base64Filter struct{}
+
+func (f base64Filter) apply(value string) string {
+	return base64.StdEncoding.EncodeToString([]byte(value))
+}
+
+// jsonFilter encodes the value as a JSON string, including the surrounding double quotes.
+type jsonFilter struct{}
+
+func (f jsonFilter) apply(value string) string {
+	return quoteString(value, func(r rune) string {
+		if r > 0xffff {
+			r1, r2 := utf16.EncodeRune(r)
+			return fmt.Sprintf(`\u%04x\u%04x`, r1, r2)
+		}
+		return fmt.Sprintf(`\u%04x`, r)
+	})
+}
+
+// yamlFilter encodes the value as a double quoted YAML string, so it can be used as
+// a scalar value in flow and block context.
+type yamlFilter struct{}
+
+func (f yamlFilter) apply(value string) string {
+	return quoteString(value, func(r rune) string {
+		if r > 0xffff {
+			return fmt.Sprintf(`\U%08x`, r)
+		}
+		return fmt.Sprintf(`\u%04x`, r)
+	})
+}
+
+// quoteString returns the value between double quotes, with double quotes, backslashes and
+// common control characters escaped with a backslash. Other non-printable characters are
+// escaped with the given function.
+func quoteString(value string, escape func(r rune) string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range value {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if unicode.IsPrint(r) {
+				b.WriteRune(r)
+			} else {
+				b.WriteString(escape(r))
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// trimFilter removes leading and trailing whitespace from the value.
+type trimFilter struct{}
+
+func (f trimFilter) apply(value string) string {
+	return strings.TrimSpace(value)
+}
+
+// sha256Filter replaces the value by its hex encoded SHA-256 hash.
+type sha256Filter struct{}
+
+func (f sha256Filter) apply(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// indentFilter indents every non-empty line of the value with the given number of spaces,
+// e.g. to use a multiline secret in a YAML block scalar.
+type indentFilter struct {
+	width int
+}
+
+func newIndentFilter(args []string) (filter, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("expected the number of spaces to indent with as argument, got %d arguments", len(args))
+	}
+
+	width, err := strconv.Atoi(args[0])
+	if err != nil || width < 0 {
+		return nil, errors.New("the number of spaces to indent with must be a non-negative number")
+	}
+
+	return indentFilter{width: width}, nil
+}
+
+func (f indentFilter) apply(value string) string {
+	indent := strings.Repeat(" ", f.width)
+
+	lines := strings.Split(value, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = indent + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
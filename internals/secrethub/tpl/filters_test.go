@@ -0,0 +1,68 @@
+package tpl
+
+import (
+	"testing"
+
+	"github.com/secrethub/secrethub-go/internals/assert"
+)
+
+func TestFilters(t *testing.T) {
+	cases := map[string]struct {
+		filter   filter
+		value    string
+		expected string
+	}{
+		"base64": {
+			filter:   base64Filter{},
+			value:    "foo?bar",
+			expected: "Zm9vP2Jhcg==",
+		},
+		"json": {
+			filter:   jsonFilter{},
+			value:    "a \"quoted\" \\ value\n\twith <html> & é",
+			expected: `"a \"quoted\" \\ value\n\twith <html> & é"`,
+		},
+		"json control characters": {
+			filter:   jsonFilter{},
+			value:    "\x00\x1b\u2028",
+			expected: `"\u0000\u001b\u2028"`,
+		},
+		"yaml": {
+			filter:   yamlFilter{},
+			value:    "key: value # not a comment\r\n- not a list",
+			expected: `"key: value # not a comment\r\n- not a list"`,
+		},
+		"yaml control characters": {
+			filter:   yamlFilter{},
+			value:    "\x07\U000e0001",
+			expected: `"\u0007\U000e0001"`,
+		},
+		"trim": {
+			filter:   trimFilter{},
+			value:    " \n\tvalue \n",
+			expected: "value",
+		},
+		"sha256": {
+			filter:   sha256Filter{},
+			value:    "foo",
+			expected: "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae",
+		},
+		"indent": {
+			filter:   indentFilter{width: 4},
+			value:    "line 1\n\nline 2\n",
+			expected: "    line 1\n\n    line 2\n",
+		},
+		"indent zero": {
+			filter:   indentFilter{width: 0},
+			value:    "line 1\nline 2",
+			expected: "line 1\nline 2",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			actual := tc.filter.apply(tc.value)
+			assert.Equal(t, actual, tc.expected)
+		})
+	}
+}
@@ -6,6 +6,12 @@ var (
 	LBracket  = '{'
 	RBracket  = '}'
 	Backslash = '\\'
+	Pipe      = '|'
+	Colon     = ':'
+	Minus     = '-'
+	Question  = '?'
+	Hash      = '#'
+	Dot       = '.'
 
 	tokens = []rune{Dollar, LBracket, RBracket, Backslash}
 )
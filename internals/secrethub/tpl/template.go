@@ -30,7 +30,7 @@ func NewParser() Parser {
 	return NewV2Parser()
 }
 
-var v1SecretTag = regexp.MustCompile(`\${[\t ]*[_\-\.a-zA-Z0-9]+/[_\-\.a-zA-Z0-9]+(?:/[_\-\.a-zA-Z0-9]+)+(?::(?:[0-9]{1,9}|latest))?[\t ]*}`)
+var v1SecretTag = regexp.MustCompile(`\${[\t ]*[_\-\.a-zA-Z0-9]+/[_\-\.a-zA-Z0-9]+(?:/[_\-\.a-zA-Z0-9]+)+(?::(?:[0-9]{1,9}|latest))?(?:#(?:\.[_\-a-zA-Z0-9]+)+)?[\t ]*}`)
 
 // IsV1Template returns whether v1 secret tags are used in the given raw bytes.
 func IsV1Template(raw []byte) bool {
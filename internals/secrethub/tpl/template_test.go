@@ -27,6 +27,10 @@ func TestIsV1Template(t *testing.T) {
 			raw:      "${ path/to/secret:latest }",
 			expected: true,
 		},
+		"v1 tag with field": {
+			raw:      "${ path/to/secret:1#.credentials.password }",
+			expected: true,
+		},
 		"v1 tag with 1 dir": {
 			raw:      "${ path/to/dir/secret }",
 			expected: true,
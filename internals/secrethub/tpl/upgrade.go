@@ -0,0 +1,94 @@
+package tpl
+
+import (
+	"strings"
+
+	"github.com/secrethub/secrethub-cli/internals/secrethub/tpl/internal/token"
+	"github.com/secrethub/secrethub-cli/internals/tpl"
+)
+
+// UpgradeV1 rewrites a v1 template to a v2 template that evaluates to the same result.
+// Secret tags are rewritten to v2 secret tags and the characters outside of the tags
+// that have a meaning in v2 templates are escaped, so everything outside of the tags
+// is preserved.
+func UpgradeV1(raw string) (string, error) {
+	t, err := tpl.NewParser("${", "}").Parse(raw)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	segments := t.Segments()
+	for i, segment := range segments {
+		if segment.IsKey {
+			tag, err := upgradeSecretTag(segment.Value)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(tag)
+			continue
+		}
+
+		// Text is always followed by a secret tag, unless it is at the end of the template.
+		var next rune
+		if i < len(segments)-1 {
+			next = token.LBracket
+		}
+		b.WriteString(escapeV2(segment.Value, next))
+	}
+	return b.String(), nil
+}
+
+// upgradeSecretTag returns the v2 secret tag for the path of a v1 secret tag.
+// An error is returned when the v2 secret tag would not read the same secret,
+// e.g. because the path contains a variable or filter in the v2 syntax.
+func upgradeSecretTag(path string) (string, error) {
+	tag := "{{ " + path + " }}"
+
+	t, err := NewV2Parser().Parse(tag, 1, 1)
+	if err != nil {
+		return "", ErrCannotUpgradeSecretTag(path)
+	}
+
+	res, err := t.Evaluate(upgradeReader{}, upgradeReader{})
+	if err != nil || res != path {
+		return "", ErrCannotUpgradeSecretTag(path)
+	}
+	return tag, nil
+}
+
+// escapeV2 escapes the characters in the text that would start a tag or an escape sequence
+// in a v2 template. The next rune is the rune that follows the text in the template, or 0
+// when the text is at the end of the template.
+func escapeV2(text string, next rune) string {
+	runes := []rune(text)
+
+	var b strings.Builder
+	for i, r := range runes {
+		n := next
+		if i < len(runes)-1 {
+			n = runes[i+1]
+		}
+
+		if r == token.Dollar && (n == token.LBracket || v2Parser{}.isVariableStartRune(n)) ||
+			r == token.LBracket && n == token.LBracket ||
+			r == token.Backslash && token.IsToken(n) {
+			b.WriteRune(token.Backslash)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// upgradeReader implements SecretReader and VariableReader to check whether a v2 secret tag
+// reads the same secret as the v1 secret tag it is upgraded from. Secrets resolve to their
+// path and variables are never set.
+type upgradeReader struct{}
+
+func (upgradeReader) ReadSecret(path string) (string, error) {
+	return path, nil
+}
+
+func (upgradeReader) ReadVariable(name string) (string, error) {
+	return "", ErrTemplateVarNotFound(name)
+}
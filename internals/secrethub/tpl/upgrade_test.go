@@ -0,0 +1,109 @@
+package tpl
+
+import (
+	"testing"
+
+	"github.com/secrethub/secrethub-cli/internals/secrethub/tpl/fakes"
+	"github.com/secrethub/secrethub-cli/internals/tpl"
+
+	"github.com/secrethub/secrethub-go/internals/assert"
+)
+
+func TestUpgradeV1(t *testing.T) {
+	secrets := map[string]string{
+		"company/repo/a":       "foo",
+		"company/repo/b:1":     "bar",
+		"company/repo/c#.pass": "baz",
+	}
+
+	cases := map[string]struct {
+		raw      string
+		expected string
+		err      error
+	}{
+		"no tags": {
+			raw:      "foo=bar",
+			expected: "foo=bar",
+		},
+		"secret": {
+			raw:      "A=${company/repo/a}\nB=${ company/repo/b:1 }\n",
+			expected: "A={{ company/repo/a }}\nB={{ company/repo/b:1 }}\n",
+		},
+		"field": {
+			raw:      "C=${ company/repo/c#.pass }",
+			expected: "C={{ company/repo/c#.pass }}",
+		},
+		"consecutive secrets": {
+			raw:      "${ company/repo/a }${ company/repo/b:1 }",
+			expected: "{{ company/repo/a }}{{ company/repo/b:1 }}",
+		},
+		"variable in text": {
+			raw:      "HOME=$HOME\nA=${ company/repo/a }",
+			expected: "HOME=\\$HOME\nA={{ company/repo/a }}",
+		},
+		"dollar followed by secret": {
+			raw:      "A=$${ company/repo/a }",
+			expected: "A=\\${{ company/repo/a }}",
+		},
+		"dollar not followed by variable": {
+			raw:      "PRICE=$5 ${ company/repo/a }$",
+			expected: "PRICE=$5 {{ company/repo/a }}$",
+		},
+		"brackets in text": {
+			raw:      "{\"a\": {{\"b\": \"${ company/repo/a }\"}}}",
+			expected: "{\"a\": \\{{\"b\": \"{{ company/repo/a }}\"}}}",
+		},
+		"bracket followed by secret": {
+			raw:      "{${ company/repo/a }}",
+			expected: "\\{{{ company/repo/a }}}",
+		},
+		"backslashes in text": {
+			raw:      "A=\\n\\${ company/repo/a }\\\\}\\",
+			expected: "A=\\n\\\\{{ company/repo/a }}\\\\\\\\}\\",
+		},
+		"unclosed tag": {
+			raw: "A=${ company/repo/a",
+			err: tpl.ErrTagNotClosed("}"),
+		},
+		"variable in path": {
+			raw: "A=${ company/$repo/a }",
+			err: ErrCannotUpgradeSecretTag("company/$repo/a"),
+		},
+		"filter in path": {
+			raw: "A=${ company/repo/a | base64 }",
+			err: ErrCannotUpgradeSecretTag("company/repo/a | base64"),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Act
+			actual, err := UpgradeV1(tc.raw)
+
+			// Assert
+			assert.Equal(t, err, tc.err)
+			assert.Equal(t, actual, tc.expected)
+
+			if err == nil {
+				assertEquivalent(t, tc.raw, actual, secrets)
+			}
+		})
+	}
+}
+
+// assertEquivalent asserts that the v1 template and the v2 template evaluate to the same result.
+func assertEquivalent(t *testing.T, v1 string, v2 string, secrets map[string]string) {
+	sr := fakes.FakeSecretReader{Secrets: secrets}
+
+	v1Template, err := NewV1Parser().Parse(v1, 1, 1)
+	assert.OK(t, err)
+	expected, err := v1Template.Evaluate(fakes.FakeVariableReader{}, sr)
+	assert.OK(t, err)
+
+	v2Template, err := NewV2Parser().Parse(v2, 1, 1)
+	assert.OK(t, err)
+	actual, err := v2Template.Evaluate(fakes.FakeVariableReader{}, sr)
+	assert.OK(t, err)
+
+	assert.Equal(t, actual, expected)
+}
@@ -20,6 +20,23 @@ import (
 // {{ ${app}/db/secret }}
 // Variables cannot be used outside of secret paths.
 //
+// Variable tags can give a default value that is used when the
+// variable is not set or empty: ${env:-dev}
+// Variable tags can also mark a variable as required with an error
+// message that is returned when it is not set or empty:
+// ${env:?set the environment to deploy to}
+//
+// The value of a secret can be transformed by filters, which
+// are given after the secret path and separated by `|`:
+// {{ path/to/secret | trim | base64 }}
+// The available filters are base64, json, yaml, trim, sha256
+// and indent, which takes the number of spaces as argument:
+// {{ path/to/certificate | indent 4 }}
+//
+// A field of a JSON or YAML secret can be selected by adding
+// a selector after the secret path, separated by `#`:
+// {{ path/to/secret#.credentials.password }}
+//
 // Spaces directly after opening delimiters (`{{` and `${`) and directly
 // before closing delimiters (`}}`, `}`) are ignored. They are not
 // included in the secret pahts and variable names.
@@ -36,12 +53,41 @@ func (ctx context) secret(path string) (string, error) {
 	return ctx.secretReader.ReadSecret(path)
 }
 
+// recordFilteredSecret reports the value a secret tag evaluated to after
+// its filters were applied, if the secret reader wants to know about it.
+func (ctx context) recordFilteredSecret(value string) {
+	recorder, ok := ctx.secretReader.(FilteredSecretRecorder)
+	if ok {
+		recorder.RecordFilteredSecret(value)
+	}
+}
+
+// lookupVariable returns the value of the variable and whether it is set,
+// without prompting for it when it is not set.
+func (ctx context) lookupVariable(name string) (string, bool, error) {
+	lookup, ok := ctx.varReader.(VariableLookup)
+	if ok {
+		return lookup.LookupVariable(name)
+	}
+
+	res, err := ctx.varReader.ReadVariable(name)
+	if err == ErrTemplateVarNotFound(name) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return res, true, nil
+}
+
 type node interface {
 	evaluate(ctx context) (string, error)
 }
 
 type secret struct {
-	path []node
+	path    []node
+	field   string
+	filters []filter
 }
 
 func (s secret) evaluate(ctx context) (string, error) {
@@ -54,7 +100,25 @@ func (s secret) evaluate(ctx context) (string, error) {
 
 		buffer.WriteString(eval)
 	}
-	return ctx.secret(buffer.String())
+
+	if s.field != "" {
+		buffer.WriteString(SecretFieldSeparator + s.field)
+	}
+
+	res, err := ctx.secret(buffer.String())
+	if err != nil {
+		return "", err
+	}
+
+	if len(s.filters) == 0 {
+		return res, nil
+	}
+
+	for _, f := range s.filters {
+		res = f.apply(res)
+	}
+	ctx.recordFilteredSecret(res)
+	return res, nil
 }
 
 type variable struct {
@@ -69,6 +133,45 @@ func (v variable) evaluate(ctx context) (string, error) {
 	return res, nil
 }
 
+// variableWithDefault is a variable that evaluates to its default value
+// when it is not set or empty: `${var:-default}`.
+type variableWithDefault struct {
+	key          string
+	defaultValue string
+}
+
+func (v variableWithDefault) evaluate(ctx context) (string, error) {
+	res, ok, err := ctx.lookupVariable(v.key)
+	if err != nil {
+		return "", err
+	}
+	if !ok || res == "" {
+		return v.defaultValue, nil
+	}
+	return res, nil
+}
+
+// requiredVariable is a variable that returns an error with the given
+// message when it is not set or empty: `${var:?message}`.
+type requiredVariable struct {
+	key     string
+	message string
+}
+
+func (v requiredVariable) evaluate(ctx context) (string, error) {
+	res, err := ctx.varReader.ReadVariable(v.key)
+	if err == ErrTemplateVarNotFound(v.key) || err == nil && res == "" {
+		if v.message == "" {
+			return "", ErrTemplateVarNotFound(v.key)
+		}
+		return "", ErrTemplateVarRequired(v.key, v.message)
+	}
+	if err != nil {
+		return "", err
+	}
+	return res, nil
+}
+
 type character rune
 
 func (c character) evaluate(ctx context) (string, error) {
@@ -92,6 +195,14 @@ type parserV2 struct{}
 //   closing delimiter of a tag: {{ path/to/secret }} has the same output as
 //   {{path/to/secret}} has.
 // - Secret tags can also contain variable tags: `{{ path/with/${var}/to/secret }}`
+// - Secret tags can contain a field selector directly after the secret path, separated by
+//   a hash: `{{ path/to/secret#.credentials.password }}`.
+// - Secret tags can contain filters after the secret path, separated by a pipe:
+//   `{{ path/to/secret | base64 }}`. Filters can be chained and can take arguments
+//   separated by spaces: `{{ path/to/secret | trim | indent 4 }}`.
+// - Variable tags can contain a default value (`${ var:-default }`) or an error message
+//   for required variables (`${ var:?message }`). Spaces directly after `:-` and `:?`
+//   are ignored. A closing bracket can be included in them by escaping it: `\}`.
 // - Variable tags cannot contain secret tags.
 // - Secret tags cannot contain secret tags (they cannot be nested).
 // - Variable tags cannot contain variable tags (they cannot be nested).
@@ -289,10 +400,79 @@ func (p *v2Parser) parseVar() (node, error) {
 			continue
 		}
 
+		if p.next == token.Colon {
+			return p.parseVarModifier(strings.ToLower(buffer.String()))
+		}
+
 		return nil, ErrIllegalVariableCharacter(p.lineNo, p.columnNo+1, p.next)
 	}
 }
 
+// parseVarModifier parses the default value or the error message of a required variable
+// in a variable tag up to the closing delimiter. The next character should be the colon
+// directly after the variable name when parseVarModifier is called.
+//
+// When parseVarModifier returns, the next character in the buffer is the closing delimiter
+// of the template variable ('}').
+func (p *v2Parser) parseVarModifier(key string) (node, error) {
+	var buffer bytes.Buffer
+
+	checkError := func(err error) error {
+		if err == io.EOF {
+			return ErrVariableTagNotClosed(p.lineNo, p.columnNo+1)
+		}
+		return err
+	}
+
+	err := p.readRune()
+	if err != nil {
+		return nil, checkError(err)
+	}
+
+	if p.next != token.Minus && p.next != token.Question {
+		return nil, ErrIllegalVariableModifier(p.lineNo, p.columnNo+1, p.next)
+	}
+
+	err = p.readRune()
+	if err != nil {
+		return nil, checkError(err)
+	}
+	modifier := p.current
+
+	err = p.skipWhiteSpace()
+	if err != nil {
+		return nil, checkError(err)
+	}
+
+	for p.next != token.RBracket {
+		err := p.readRune()
+		if err != nil {
+			return nil, checkError(err)
+		}
+
+		if p.current == token.Backslash && token.IsToken(p.next) {
+			err := p.readRune()
+			if err != nil {
+				return nil, checkError(err)
+			}
+		}
+
+		buffer.WriteRune(p.current)
+	}
+
+	value := strings.TrimRight(buffer.String(), " \t")
+	if modifier == token.Question {
+		return requiredVariable{
+			key:     key,
+			message: value,
+		}, nil
+	}
+	return variableWithDefault{
+		key:          key,
+		defaultValue: value,
+	}, nil
+}
+
 // parseSecret parses the contents of a secret tag up to the closing delimiter.
 // The next character should be the last character of the opening delimiter ('{')
 // when parseSecret is called.
@@ -355,27 +535,21 @@ func (p *v2Parser) parseSecret() (node, error) {
 		}
 
 		if p.isAllowedWhiteSpace(p.current) {
-			err := p.skipWhiteSpace()
-			if err != nil {
-				return nil, checkError(err)
-			}
-
-			if p.next != token.RBracket {
-				return nil, ErrUnexpectedCharacter(p.lineNo, p.columnNo+1, p.next, token.RBracket)
-			}
+			return p.parseSecretEnd(secret{
+				path: path,
+			})
+		}
 
-			err = p.readRune()
+		if p.current == token.Hash {
+			field, err := p.parseSecretField()
 			if err != nil {
 				return nil, checkError(err)
 			}
 
-			if p.next != token.RBracket {
-				return nil, ErrUnexpectedCharacter(p.lineNo, p.columnNo+1, p.next, token.RBracket)
-			}
-
-			return secret{
-				path: path,
-			}, nil
+			return p.parseSecretEnd(secret{
+				path:  path,
+				field: field,
+			})
 		}
 
 		if p.current == token.RBracket {
@@ -387,6 +561,12 @@ func (p *v2Parser) parseSecret() (node, error) {
 			return nil, ErrUnexpectedCharacter(p.lineNo, p.columnNo+1, p.next, token.RBracket)
 		}
 
+		if p.current == token.Pipe {
+			return p.parseFilters(secret{
+				path: path,
+			})
+		}
+
 		if p.isSecretPathRune(p.current) {
 			path = append(path, character(p.current))
 			continue
@@ -396,6 +576,191 @@ func (p *v2Parser) parseSecret() (node, error) {
 	}
 }
 
+// parseSecretField parses the field selector of a secret tag.
+// The current character should be the hash that precedes the selector
+// when parseSecretField is called.
+//
+// When parseSecretField returns, the next character in the buffer is the first
+// character after the selector.
+func (p *v2Parser) parseSecretField() (string, error) {
+	var buffer bytes.Buffer
+
+	for {
+		if p.next != token.Dot {
+			return "", ErrUnexpectedCharacter(p.lineNo, p.columnNo+1, p.next, token.Dot)
+		}
+		buffer.WriteRune(p.next)
+
+		err := p.readRune()
+		if err != nil {
+			return "", err
+		}
+
+		if !isSecretFieldRune(p.next) {
+			return "", ErrIllegalFieldCharacter(p.lineNo, p.columnNo+1, p.next)
+		}
+
+		for isSecretFieldRune(p.next) {
+			buffer.WriteRune(p.next)
+
+			err := p.readRune()
+			if err != nil {
+				return "", err
+			}
+		}
+
+		if p.next != token.Dot {
+			return buffer.String(), nil
+		}
+	}
+}
+
+// parseSecretEnd parses the remainder of a secret tag after the secret path and field selector,
+// which can contain spaces and filters, up to the closing delimiter.
+// The next character should be the first character after the secret path and field selector
+// when parseSecretEnd is called.
+//
+// When parseSecretEnd returns, the next character in the buffer is the last character
+// of the closing delimiter of the secret tag ('}').
+func (p *v2Parser) parseSecretEnd(s secret) (node, error) {
+	checkError := func(err error) error {
+		if err == io.EOF {
+			return ErrSecretTagNotClosed(p.lineNo, p.columnNo+1)
+		}
+		return err
+	}
+
+	err := p.skipWhiteSpace()
+	if err != nil {
+		return nil, checkError(err)
+	}
+
+	if p.next == token.Pipe {
+		err := p.readRune()
+		if err != nil {
+			return nil, checkError(err)
+		}
+
+		return p.parseFilters(s)
+	}
+
+	if p.next != token.RBracket {
+		return nil, ErrUnexpectedCharacter(p.lineNo, p.columnNo+1, p.next, token.RBracket)
+	}
+
+	err = p.readRune()
+	if err != nil {
+		return nil, checkError(err)
+	}
+
+	if p.next != token.RBracket {
+		return nil, ErrUnexpectedCharacter(p.lineNo, p.columnNo+1, p.next, token.RBracket)
+	}
+
+	return s, nil
+}
+
+// parseFilters parses the filters of a secret tag up to the closing delimiter
+// and adds them to the given secret.
+// The current character should be the pipe that precedes the first filter
+// when parseFilters is called.
+//
+// When parseFilters returns, the next character in the buffer is the last character
+// of the closing delimiter of the secret tag ('}').
+func (p *v2Parser) parseFilters(s secret) (node, error) {
+	filters := []filter{}
+
+	checkError := func(err error) error {
+		if err == io.EOF {
+			return ErrSecretTagNotClosed(p.lineNo, p.columnNo+1)
+		}
+		return err
+	}
+
+	for {
+		err := p.skipWhiteSpace()
+		if err != nil {
+			return nil, checkError(err)
+		}
+
+		lineNo, colNo := p.lineNo, p.columnNo+1
+		if !p.isFilterRune(p.next) {
+			return nil, ErrMissingFilterName(lineNo, colNo, p.next)
+		}
+
+		name, err := p.parseFilterWord()
+		if err != nil {
+			return nil, checkError(err)
+		}
+
+		newFilter, ok := filtersByName[name]
+		if !ok {
+			return nil, ErrUnknownFilter(lineNo, colNo, name)
+		}
+
+		args := []string{}
+		for p.isAllowedWhiteSpace(p.next) {
+			err := p.skipWhiteSpace()
+			if err != nil {
+				return nil, checkError(err)
+			}
+
+			if !p.isFilterRune(p.next) {
+				break
+			}
+
+			arg, err := p.parseFilterWord()
+			if err != nil {
+				return nil, checkError(err)
+			}
+			args = append(args, arg)
+		}
+
+		f, err := newFilter(args)
+		if err != nil {
+			return nil, ErrInvalidFilterArguments(lineNo, colNo, name, err)
+		}
+		filters = append(filters, f)
+
+		switch p.next {
+		case token.Pipe:
+			err := p.readRune()
+			if err != nil {
+				return nil, checkError(err)
+			}
+		case token.RBracket:
+			err := p.readRune()
+			if err != nil {
+				return nil, checkError(err)
+			}
+
+			if p.next != token.RBracket {
+				return nil, ErrUnexpectedCharacter(p.lineNo, p.columnNo+1, p.next, token.RBracket)
+			}
+
+			s.filters = filters
+			return s, nil
+		default:
+			return nil, ErrIllegalFilterCharacter(p.lineNo, p.columnNo+1, p.next)
+		}
+	}
+}
+
+// parseFilterWord parses the name or an argument of a filter.
+// When parseFilterWord returns, the next character is the first character after the word.
+func (p *v2Parser) parseFilterWord() (string, error) {
+	var buffer bytes.Buffer
+	for p.isFilterRune(p.next) {
+		buffer.WriteRune(p.next)
+
+		err := p.readRune()
+		if err != nil {
+			return "", err
+		}
+	}
+	return buffer.String(), nil
+}
+
 // isSecretPathRune returns whether the given rune is allowed to be used in
 // a secret path.
 func (p v2Parser) isSecretPathRune(r rune) bool {
@@ -412,6 +777,11 @@ func (p v2Parser) isVariableStartRune(r rune) bool {
 	return unicode.IsLetter(r) || r == '_'
 }
 
+// isFilterRune returns whether the given rune is allowed to be used in the name or an argument of a filter.
+func (p v2Parser) isFilterRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-'
+}
+
 // isAllowedWhiteSpace returns whether the given rune is allowed as extra whitespace
 // just after the opening tag and just before the closing tag.
 func (p v2Parser) isAllowedWhiteSpace(r rune) bool {
@@ -434,11 +804,25 @@ type SecretReader interface {
 	ReadSecret(path string) (string, error)
 }
 
+// FilteredSecretRecorder is implemented by secret readers that also need to know
+// the value of a secret tag after its filters are applied, e.g. to mask it.
+// The filtered value is derived from the secret, but does not contain it.
+type FilteredSecretRecorder interface {
+	RecordFilteredSecret(value string)
+}
+
 // VariableReader fetches a template variable by its name.
 type VariableReader interface {
 	ReadVariable(name string) (string, error)
 }
 
+// VariableLookup is implemented by variable readers that can look up a template variable
+// without prompting for it or returning an error when it is not set.
+// It is used to evaluate variables that have a default value.
+type VariableLookup interface {
+	LookupVariable(name string) (string, bool, error)
+}
+
 // Evaluate renders a template. It replaces all variable- and secret tags in the template.
 // The supplied variables should have lowercase keys.
 func (t templateV2) Evaluate(varReader VariableReader, sr SecretReader) (string, error) {
@@ -496,6 +496,168 @@ func TestParserV2_parse(t *testing.T) {
 				character('a'),
 			},
 		},
+		"variable with default": {
+			input: "${ env:-dev }",
+			expected: []node{
+				variableWithDefault{
+					key:          "env",
+					defaultValue: "dev",
+				},
+			},
+		},
+		"variable with default with spaces": {
+			input: "${ENV:- a default value\t}",
+			expected: []node{
+				variableWithDefault{
+					key:          "env",
+					defaultValue: "a default value",
+				},
+			},
+		},
+		"variable with empty default": {
+			input: "${env:-}",
+			expected: []node{
+				variableWithDefault{
+					key: "env",
+				},
+			},
+		},
+		"variable with escaped bracket in default": {
+			input: `${env:-{\}\a}`,
+			expected: []node{
+				variableWithDefault{
+					key:          "env",
+					defaultValue: `{}\a`,
+				},
+			},
+		},
+		"required variable": {
+			input: "${env:?env must be set}",
+			expected: []node{
+				requiredVariable{
+					key:     "env",
+					message: "env must be set",
+				},
+			},
+		},
+		"variable with default in secret path": {
+			input: "{{ ${org:-acme}/db }}",
+			expected: []node{
+				secret{
+					path: []node{
+						variableWithDefault{
+							key:          "org",
+							defaultValue: "acme",
+						},
+						character('/'),
+						character('d'),
+						character('b'),
+					},
+				},
+			},
+		},
+		"secret with field": {
+			input: "{{ a/b#.credentials.pass_word-1 }}",
+			expected: []node{
+				secret{
+					path: []node{
+						character('a'),
+						character('/'),
+						character('b'),
+					},
+					field: ".credentials.pass_word-1",
+				},
+			},
+		},
+		"secret with field and filter": {
+			input: "{{a:1#.0|base64}}",
+			expected: []node{
+				secret{
+					path: []node{
+						character('a'),
+						character(':'),
+						character('1'),
+					},
+					field: ".0",
+					filters: []filter{
+						base64Filter{},
+					},
+				},
+			},
+		},
+		"secret with filter": {
+			input: "{{ a/b | base64 }}",
+			expected: []node{
+				secret{
+					path: []node{
+						character('a'),
+						character('/'),
+						character('b'),
+					},
+					filters: []filter{
+						base64Filter{},
+					},
+				},
+			},
+		},
+		"secret with filter without spaces": {
+			input: "{{a/b|base64}}",
+			expected: []node{
+				secret{
+					path: []node{
+						character('a'),
+						character('/'),
+						character('b'),
+					},
+					filters: []filter{
+						base64Filter{},
+					},
+				},
+			},
+		},
+		"secret with filter chain": {
+			input: "{{ a | trim |\tjson }}",
+			expected: []node{
+				secret{
+					path: []node{
+						character('a'),
+					},
+					filters: []filter{
+						trimFilter{},
+						jsonFilter{},
+					},
+				},
+			},
+		},
+		"secret with filter with argument": {
+			input: "{{ a | indent 4 | sha256}}",
+			expected: []node{
+				secret{
+					path: []node{
+						character('a'),
+					},
+					filters: []filter{
+						indentFilter{width: 4},
+						sha256Filter{},
+					},
+				},
+			},
+		},
+		"variable in secret path with filter": {
+			input: "{{ ${var}| yaml }}",
+			expected: []node{
+				secret{
+					path: []node{
+						variable{
+							key: "var",
+						},
+					},
+					filters: []filter{
+						yamlFilter{},
+					},
+				},
+			},
+		},
 		"illegal variable space": {
 			input: "${ va r }",
 			err:   ErrUnexpectedCharacter(1, 7, 'r', '}'),
@@ -556,6 +718,74 @@ func TestParserV2_parse(t *testing.T) {
 			input: "{{ path/to/secret }}\n{{ a%b }}",
 			err:   ErrIllegalSecretCharacter(2, 5, '%'),
 		},
+		"illegal variable modifier": {
+			input: "${env:=dev}",
+			err:   ErrIllegalVariableModifier(1, 7, '='),
+		},
+		"variable with default not closed": {
+			input: "${env:-dev",
+			err:   ErrVariableTagNotClosed(1, 11),
+		},
+		"variable with escaped closing bracket not closed": {
+			input: `${env:?\}`,
+			err:   ErrVariableTagNotClosed(1, 10),
+		},
+		"field without dot": {
+			input: "{{ a/b#password }}",
+			err:   ErrUnexpectedCharacter(1, 8, 'p', '.'),
+		},
+		"field with empty key": {
+			input: "{{ a/b#.credentials..password }}",
+			err:   ErrIllegalFieldCharacter(1, 21, '.'),
+		},
+		"field with illegal character": {
+			input: "{{ a/b#.pass@word }}",
+			err:   ErrUnexpectedCharacter(1, 13, '@', '}'),
+		},
+		"field not closed": {
+			input: "{{ a/b#.password",
+			err:   ErrSecretTagNotClosed(1, 17),
+		},
+		"unknown filter": {
+			input: "{{ path | base32 }}",
+			err:   ErrUnknownFilter(1, 11, "base32"),
+		},
+		"unknown filter on new line": {
+			input: "{{ path }}\n{{ path | trim | foo }}",
+			err:   ErrUnknownFilter(2, 18, "foo"),
+		},
+		"missing filter name": {
+			input: "{{ path | }}",
+			err:   ErrMissingFilterName(1, 11, '}'),
+		},
+		"missing filter name between pipes": {
+			input: "{{ path | trim || base64 }}",
+			err:   ErrMissingFilterName(1, 17, '|'),
+		},
+		"illegal filter character": {
+			input: "{{ path | trim@ }}",
+			err:   ErrIllegalFilterCharacter(1, 15, '@'),
+		},
+		"filter argument not allowed": {
+			input: "{{ path | base64 4 }}",
+			err:   ErrInvalidFilterArguments(1, 11, "base64", errors.New("expected no arguments, got 1")),
+		},
+		"missing filter argument": {
+			input: "{{ path | indent }}",
+			err:   ErrInvalidFilterArguments(1, 11, "indent", errors.New("expected the number of spaces to indent with as argument, got 0 arguments")),
+		},
+		"invalid filter argument": {
+			input: "{{ path | indent four }}",
+			err:   ErrInvalidFilterArguments(1, 11, "indent", errors.New("the number of spaces to indent with must be a non-negative number")),
+		},
+		"filter not closed": {
+			input: "{{ path | base64 }",
+			err:   ErrSecretTagNotClosed(1, 19),
+		},
+		"secret tag with filter not closed": {
+			input: "{{ path | base64",
+			err:   ErrSecretTagNotClosed(1, 17),
+		},
 		"secret tag not closed": {
 			input: "{{ path",
 			err:   ErrSecretTagNotClosed(1, 8),
@@ -662,6 +892,45 @@ func TestV2(t *testing.T) {
 			},
 			expected: "hello world",
 		},
+		"filters": {
+			raw: `{"cert": "{{ app/cert | base64 }}", "password": {{ app/password | json }}}`,
+			secrets: map[string]string{
+				"app/cert":     "-----BEGIN CERTIFICATE-----\n",
+				"app/password": `p"ss\`,
+			},
+			expected: `{"cert": "LS0tLS1CRUdJTiBDRVJUSUZJQ0FURS0tLS0tCg==", "password": "p\"ss\\"}`,
+		},
+		"filter chain": {
+			raw: "cert: |\n{{ app/cert | trim | indent 2 }}",
+			secrets: map[string]string{
+				"app/cert": "\nline 1\n\nline 2\n",
+			},
+			expected: "cert: |\n  line 1\n\n  line 2",
+		},
+		"field": {
+			raw: "{{ app/db#.password | json }}",
+			secrets: map[string]string{
+				"app/db#.password": "secret",
+			},
+			expected: `"secret"`,
+		},
+		"variable with default": {
+			raw: "{{ ${app:-company/helloworld}/greeting }} from ${env:-dev}",
+			vars: map[string]string{
+				"env": "prod",
+			},
+			secrets: map[string]string{
+				"company/helloworld/greeting": "hello world",
+			},
+			expected: "hello world from prod",
+		},
+		"required variable": {
+			raw:  "hello ${name:?tell us who you are}",
+			vars: map[string]string{
+				"name": "",
+			},
+			evalErr: ErrTemplateVarRequired("name", "tell us who you are"),
+		},
 		"missing var": {
 			raw:  "hello {{ ${app}/greeting }}",
 			vars: map[string]string{},
@@ -14,6 +14,7 @@ import (
 // TreeCommand lists the contents of a directory at a given path in a tree-like format.
 type TreeCommand struct {
 	path      api.DirPath
+	format    listFormat
 	io        ui.IO
 	newClient newClientFunc
 }
@@ -38,6 +39,14 @@ func (cmd *TreeCommand) Run() error {
 		return err
 	}
 
+	if !cmd.format.isTable() {
+		formatter, err := cmd.format.newFormatter(cmd.io.Output(), "path", "type", "status")
+		if err != nil {
+			return err
+		}
+		return writeDirContentsRecursively(formatter, t.RootDir, cmd.path.Value())
+	}
+
 	printTree(t, cmd.io.Output())
 	return nil
 }
@@ -46,6 +55,7 @@ func (cmd *TreeCommand) Run() error {
 func (cmd *TreeCommand) Register(r command.Registerer) {
 	clause := r.Command("tree", "List contents of a directory in a tree-like format.")
 	clause.Arg("dir-path", "The path to to show contents for").Required().PlaceHolder(optionalDirPathPlaceHolder).SetValue(&cmd.path)
+	cmd.format.register(clause)
 
 	command.BindAction(clause, cmd.Run)
 }
@@ -98,3 +108,32 @@ func printDirContentsRecursively(dir *api.Dir, prefix string, w io.Writer) {
 		i++
 	}
 }
+
+// writeDirContentsRecursively writes all directories and secrets in the directory to the formatter,
+// subdirs first followed by secrets, in the same order as they are printed in the tree.
+func writeDirContentsRecursively(formatter listFormatter, dir *api.Dir, path string) error {
+	sort.Sort(api.SortDirByName(dir.SubDirs))
+	sort.Sort(api.SortSecretByName(dir.Secrets))
+
+	for _, sub := range dir.SubDirs {
+		subPath := path + "/" + sub.Name
+		err := formatter.Write([]string{subPath, "dir", sub.Status})
+		if err != nil {
+			return err
+		}
+
+		err = writeDirContentsRecursively(formatter, sub, subPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, secret := range dir.Secrets {
+		err := formatter.Write([]string{path + "/" + secret.Name, "secret", secret.Status})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
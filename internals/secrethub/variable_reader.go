@@ -51,6 +51,12 @@ func (v *variableReader) ReadVariable(name string) (string, error) {
 	return variable, nil
 }
 
+// LookupVariable fetches a template variable by name and returns whether it is set.
+func (v *variableReader) LookupVariable(name string) (string, bool, error) {
+	variable, ok := v.vars[name]
+	return variable, ok, nil
+}
+
 type promptMissingVariableReader struct {
 	reader  tpl.VariableReader
 	io      ui.IO
@@ -86,3 +92,22 @@ func (p *promptMissingVariableReader) ReadVariable(name string) (string, error)
 
 	return variable, err
 }
+
+// LookupVariable fetches a template variable or a previously given answer for it, without prompting the user.
+func (p *promptMissingVariableReader) LookupVariable(name string) (string, bool, error) {
+	lookup, ok := p.reader.(tpl.VariableLookup)
+	if ok {
+		variable, ok, err := lookup.LookupVariable(name)
+		if ok || err != nil {
+			return variable, ok, err
+		}
+	} else {
+		variable, err := p.reader.ReadVariable(name)
+		if err != tpl.ErrTemplateVarNotFound(name) {
+			return variable, err == nil, err
+		}
+	}
+
+	variable, ok := p.answers[name]
+	return variable, ok, nil
+}
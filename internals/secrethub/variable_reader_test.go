@@ -8,6 +8,7 @@ import (
 	"github.com/secrethub/secrethub-go/internals/assert"
 
 	"github.com/secrethub/secrethub-cli/internals/secrethub/tpl"
+	"github.com/secrethub/secrethub-cli/internals/secrethub/tpl/fakes"
 )
 
 func TestVariableReader(t *testing.T) {
@@ -173,3 +174,96 @@ func TestPromptVariableReader(t *testing.T) {
 		})
 	}
 }
+
+func TestVariableModifiers(t *testing.T) {
+	cases := map[string]struct {
+		template     string
+		templateVars map[string]string
+		prompt       bool
+		promptIn     []string
+		expected     string
+		err          error
+		promptOut    string
+	}{
+		"default": {
+			template: "${env:-dev}",
+			expected: "dev",
+		},
+		"default not used": {
+			template: "${env:-dev}",
+			templateVars: map[string]string{
+				"env": "prod",
+			},
+			expected: "prod",
+		},
+		"default for empty variable": {
+			template: "${env:-dev}",
+			templateVars: map[string]string{
+				"env": "",
+			},
+			expected: "dev",
+		},
+		"default not prompted": {
+			template: "{{ company/${env:-dev}/db }}",
+			prompt:   true,
+			expected: "company/dev/db",
+		},
+		"default uses earlier answer": {
+			template:  "${env}-${env:-dev}",
+			prompt:    true,
+			promptIn:  []string{"prod\n"},
+			expected:  "prod-prod",
+			promptOut: "What is the value of the \"env\" template variable?\n",
+		},
+		"required": {
+			template: "${env:?env must be set to dev or prod}",
+			templateVars: map[string]string{
+				"env": "prod",
+			},
+			expected: "prod",
+		},
+		"required missing": {
+			template: "${env:?env must be set to dev or prod}",
+			err:      tpl.ErrTemplateVarRequired("env", "env must be set to dev or prod"),
+		},
+		"required empty": {
+			template: "${env:?env must be set to dev or prod}",
+			templateVars: map[string]string{
+				"env": "",
+			},
+			err: tpl.ErrTemplateVarRequired("env", "env must be set to dev or prod"),
+		},
+		"required without message": {
+			template: "${env:?}",
+			err:      tpl.ErrTemplateVarNotFound("env"),
+		},
+		"required prompted": {
+			template:  "${env:?env must be set to dev or prod}",
+			prompt:    true,
+			promptIn:  []string{"prod\n"},
+			expected:  "prod",
+			promptOut: "What is the value of the \"env\" template variable?\n",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			io := fakeui.NewIO(t)
+			io.PromptIn.Reads = tc.promptIn
+
+			reader, err := newVariableReader(nil, tc.templateVars)
+			assert.OK(t, err)
+			if tc.prompt {
+				reader = newPromptMissingVariableReader(reader, io)
+			}
+
+			template, err := tpl.NewV2Parser().Parse(tc.template, 1, 1)
+			assert.OK(t, err)
+
+			actual, err := template.Evaluate(reader, fakes.FakeSecretReader{Secrets: map[string]string{"company/dev/db": "company/dev/db"}})
+			assert.Equal(t, err, tc.err)
+			assert.Equal(t, actual, tc.expected)
+			assert.Equal(t, io.PromptOut.String(), tc.promptOut)
+		})
+	}
+}
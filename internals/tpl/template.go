@@ -28,6 +28,13 @@ type parser struct {
 type Template interface {
 	Inject(replacements map[string]string) (string, error)
 	Keys() []string
+	Segments() []Segment
+}
+
+// Segment is a part of a template, either plain text or a key that is replaced by a value on inject.
+type Segment struct {
+	Value string
+	IsKey bool
 }
 
 type template struct {
@@ -75,6 +82,20 @@ func (t template) Keys() []string {
 	return res
 }
 
+// Segments returns the parts of the template in the order in which they occur in the raw template.
+func (t template) Segments() []Segment {
+	res := make([]Segment, len(t.nodes))
+	for i, n := range t.nodes {
+		switch n := n.(type) {
+		case key:
+			res[i] = Segment{Value: string(n), IsKey: true}
+		case val:
+			res[i] = Segment{Value: string(n)}
+		}
+	}
+	return res
+}
+
 // node is a part of the template, either a plain text value or
 // a key that will be mapped to a value.
 type node interface {
@@ -242,3 +242,41 @@ func TestInject(t *testing.T) {
 		})
 	}
 }
+
+func TestSegments(t *testing.T) {
+	// Arrange
+	cases := map[string]struct {
+		raw      string
+		expected []Segment
+	}{
+		"empty_string": {
+			raw:      "",
+			expected: []Segment{},
+		},
+		"none": {
+			raw:      "foo=bar",
+			expected: []Segment{{Value: "foo=bar"}},
+		},
+		"mixed": {
+			raw: fmt.Sprintf(`foo=${ %s }${%s}bar`, testSecretPath, testSecretPath2),
+			expected: []Segment{
+				{Value: "foo="},
+				{Value: testSecretPath, IsKey: true},
+				{Value: testSecretPath2, IsKey: true},
+				{Value: "bar"},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Act
+			tpl, err := NewParser("${", "}").Parse(tc.raw)
+			assert.OK(t, err)
+			actual := tpl.Segments()
+
+			// Assert
+			assert.Equal(t, actual, tc.expected)
+		})
+	}
+}